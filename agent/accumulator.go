@@ -2,6 +2,7 @@ package agent
 
 import (
 	"log"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -15,6 +16,7 @@ var (
 
 type MetricMaker interface {
 	Name() string
+	LogName() string
 	MakeMetric(metric telegraf.Metric) telegraf.Metric
 }
 
@@ -110,7 +112,7 @@ func (ac *accumulator) AddError(err error) {
 		return
 	}
 	NErrors.Incr(1)
-	log.Printf("E! [%s]: Error in plugin: %v", ac.maker.Name(), err)
+	log.Printf("E! [%s]: Error in plugin: %v", ac.maker.LogName(), err)
 }
 
 func (ac *accumulator) SetPrecision(precision, interval time.Duration) {
@@ -147,6 +149,100 @@ func (ac *accumulator) WithTracking(maxTracked int) telegraf.TrackingAccumulator
 	}
 }
 
+// gatedAccumulator passes metrics to an accumulator until it is closed,
+// after which they are discarded.  It is used to cut off gathers that are
+// abandoned after their timeout.
+type gatedAccumulator struct {
+	telegraf.Accumulator
+
+	sync.Mutex
+	closed bool
+}
+
+func (ac *gatedAccumulator) AddFields(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	ac.Lock()
+	defer ac.Unlock()
+	if !ac.closed {
+		ac.Accumulator.AddFields(measurement, fields, tags, t...)
+	}
+}
+
+func (ac *gatedAccumulator) AddGauge(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	ac.Lock()
+	defer ac.Unlock()
+	if !ac.closed {
+		ac.Accumulator.AddGauge(measurement, fields, tags, t...)
+	}
+}
+
+func (ac *gatedAccumulator) AddCounter(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	ac.Lock()
+	defer ac.Unlock()
+	if !ac.closed {
+		ac.Accumulator.AddCounter(measurement, fields, tags, t...)
+	}
+}
+
+func (ac *gatedAccumulator) AddSummary(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	ac.Lock()
+	defer ac.Unlock()
+	if !ac.closed {
+		ac.Accumulator.AddSummary(measurement, fields, tags, t...)
+	}
+}
+
+func (ac *gatedAccumulator) AddHistogram(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	t ...time.Time,
+) {
+	ac.Lock()
+	defer ac.Unlock()
+	if !ac.closed {
+		ac.Accumulator.AddHistogram(measurement, fields, tags, t...)
+	}
+}
+
+func (ac *gatedAccumulator) AddMetric(m telegraf.Metric) {
+	ac.Lock()
+	defer ac.Unlock()
+	if !ac.closed {
+		ac.Accumulator.AddMetric(m)
+		return
+	}
+	m.Drop()
+}
+
+// close discards all metrics added from now on.  It waits for metrics being
+// added to be passed on, afterwards the accumulator no longer sends on its
+// channel.
+func (ac *gatedAccumulator) close() {
+	ac.Lock()
+	ac.closed = true
+	ac.Unlock()
+}
+
 type trackingAccumulator struct {
 	telegraf.Accumulator
 	delivered chan telegraf.DeliveryInfo
@@ -3,9 +3,11 @@ package agent
 import (
 	"context"
 	"fmt"
+	"hash/fnv"
 	"log"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -15,15 +17,70 @@ import (
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
 )
 
+// shutdownRetryInterval is the time waited between the attempts to write an
+// output's buffer on shutdown.
+var shutdownRetryInterval = time.Second
+
 // Agent runs a set of plugins.
 type Agent struct {
 	Config *config.Config
+
+	// inputsMu protects Config.Inputs and the input state below while the
+	// agent is running.
+	inputsMu   sync.Mutex
+	inputCtx   context.Context
+	inputDst   chan<- telegraf.Metric
+	inputUnits map[*models.RunningInput]*pluginUnit
+	inputWg    sync.WaitGroup
+
+	// gatherSlots limits the number of concurrent gathers, it is nil if
+	// gathers are unlimited.
+	gatherSlots chan struct{}
+
+	// metadata adds the tags discovered from the metadata providers to all
+	// metrics, it is nil if no providers are configured.
+	metadata *metadataTags
+
+	// outputsMu protects Config.Outputs and the output state below while
+	// the agent is running.
+	outputsMu   sync.RWMutex
+	outputCtx   context.Context
+	outputUnits map[*models.RunningOutput]*pluginUnit
+	outputWg    sync.WaitGroup
+
+	// restarting is set once Reload returned ErrRestartRequired, the agent
+	// stops to be restarted and not for the final shutdown.
+	restarting int32
+}
+
+// pluginUnit tracks the goroutine running a single plugin instance so that
+// it can be stopped independently of the rest of the agent.
+type pluginUnit struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// stop cancels the unit and waits for its goroutine to return.
+func (u *pluginUnit) stop() {
+	u.cancel()
+	<-u.done
 }
 
 // NewAgent returns an Agent for the given Config.
 func NewAgent(config *config.Config) (*Agent, error) {
 	a := &Agent{
-		Config: config,
+		Config:      config,
+		inputUnits:  make(map[*models.RunningInput]*pluginUnit),
+		outputUnits: make(map[*models.RunningOutput]*pluginUnit),
+	}
+	if config.Agent.MaxConcurrentGathers > 0 {
+		a.gatherSlots = make(chan struct{}, config.Agent.MaxConcurrentGathers)
+	}
+
+	var err error
+	a.metadata, err = newMetadataTags(config.Agent)
+	if err != nil {
+		return nil, err
 	}
 	return a, nil
 }
@@ -35,6 +92,31 @@ func (a *Agent) Run(ctx context.Context) error {
 		a.Config.Agent.Interval.Duration, a.Config.Agent.Quiet,
 		a.Config.Agent.Hostname, a.Config.Agent.FlushInterval.Duration)
 
+	return a.run(ctx, a.runInputs)
+}
+
+// Once runs the inputs a single time and writes the metrics through the
+// processors, aggregators and outputs before returning.  Service inputs are
+// given wait to collect metrics before the agent stops.
+func (a *Agent) Once(ctx context.Context, wait time.Duration) error {
+	return a.run(ctx, func(
+		ctx context.Context,
+		startTime time.Time,
+		dst chan<- telegraf.Metric,
+	) error {
+		return a.gatherInputsOnce(ctx, dst, wait)
+	})
+}
+
+// run connects the outputs and runs the plugins.  The inputs are run by
+// runInputs, the agent stops once it returns and all metrics are written.
+// An error is returned if metrics were dropped because the outputs did not
+// write them before the shutdown timeout, unless the agent is stopped to be
+// restarted.
+func (a *Agent) run(
+	ctx context.Context,
+	runInputs func(context.Context, time.Time, chan<- telegraf.Metric) error,
+) error {
 	if ctx.Err() != nil {
 		return ctx.Err()
 	}
@@ -45,7 +127,17 @@ func (a *Agent) Run(ctx context.Context) error {
 		return err
 	}
 
+	if a.Config.Agent.StateDirectory != "" {
+		a.loadBuffers()
+	}
+
+	if a.metadata != nil {
+		log.Printf("D! [agent] Reading metadata tags")
+		a.metadata.refresh(ctx)
+	}
+
 	inputC := make(chan telegraf.Metric, 100)
+	metaC := make(chan telegraf.Metric, 100)
 	procC := make(chan telegraf.Metric, 100)
 	outputC := make(chan telegraf.Metric, 100)
 
@@ -66,7 +158,7 @@ func (a *Agent) Run(ctx context.Context) error {
 	go func(dst chan telegraf.Metric) {
 		defer wg.Done()
 
-		err := a.runInputs(ctx, startTime, dst)
+		err := runInputs(ctx, startTime, dst)
 		if err != nil {
 			log.Printf("E! [agent] Error running inputs: %v", err)
 		}
@@ -80,6 +172,21 @@ func (a *Agent) Run(ctx context.Context) error {
 
 	src = dst
 
+	if a.metadata != nil {
+		dst = metaC
+
+		wg.Add(1)
+		go func(src, dst chan telegraf.Metric) {
+			defer wg.Done()
+
+			a.runMetadata(src, dst)
+			close(dst)
+			log.Printf("D! [agent] Metadata channel closed")
+		}(src, dst)
+
+		src = dst
+	}
+
 	if len(a.Config.Processors) > 0 {
 		dst = procC
 
@@ -128,17 +235,74 @@ func (a *Agent) Run(ctx context.Context) error {
 
 	wg.Wait()
 
+	var dropped int
+	if a.Config.Agent.StateDirectory != "" {
+		dropped = a.saveBuffers()
+	} else {
+		dropped = a.unwrittenMetrics()
+	}
+
 	log.Printf("D! [agent] Closing outputs")
 	err = a.closeOutputs()
+	a.unregisterPluginStats()
 	if err != nil {
 		return err
 	}
 
+	if dropped > 0 {
+		if atomic.LoadInt32(&a.restarting) != 0 {
+			log.Printf("E! [agent] %d metrics were dropped on restart", dropped)
+			return nil
+		}
+		return fmt.Errorf("%d metrics were dropped on shutdown", dropped)
+	}
 	return nil
 }
 
+// unwrittenMetrics returns the number of metrics left in the output buffers
+// once the outputs have stopped, logging the outputs that did not write all
+// of their metrics.
+func (a *Agent) unwrittenMetrics() int {
+	var dropped int
+	for _, output := range a.Config.Outputs {
+		n := output.BufferLength()
+		if n == 0 {
+			continue
+		}
+		log.Printf("E! [agent] Output %s dropped %d buffered metrics on shutdown",
+			output.LogName(), n)
+		dropped += n
+	}
+	return dropped
+}
+
 // Test runs the inputs once and prints the output to stdout in line protocol.
-func (a *Agent) Test(ctx context.Context) error {
+// Service inputs are only run if wait is non-zero, they are given wait to
+// collect metrics before the test ends.
+func (a *Agent) Test(ctx context.Context, wait time.Duration) error {
+	s := influx.NewSerializer()
+	s.SetFieldSortOrder(influx.SortFields)
+	return a.test(ctx, wait, func(metric telegraf.Metric) {
+		octets, err := s.Serialize(metric)
+		if err == nil {
+			fmt.Print("> ", string(octets))
+
+		}
+	})
+}
+
+// Gather runs the inputs once as Test does and returns the metrics instead of
+// printing them.
+func (a *Agent) Gather(ctx context.Context, wait time.Duration) ([]telegraf.Metric, error) {
+	var metrics []telegraf.Metric
+	err := a.test(ctx, wait, func(metric telegraf.Metric) {
+		metrics = append(metrics, metric)
+	})
+	return metrics, err
+}
+
+// test runs the inputs once and calls fn with each metric, see Test.
+func (a *Agent) test(ctx context.Context, wait time.Duration, fn func(telegraf.Metric)) error {
 	var wg sync.WaitGroup
 	metricC := make(chan telegraf.Metric)
 	nulC := make(chan telegraf.Metric)
@@ -148,18 +312,19 @@ func (a *Agent) Test(ctx context.Context) error {
 		wg.Wait()
 	}()
 
+	if a.metadata != nil {
+		a.metadata.refresh(ctx)
+	}
+
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 
-		s := influx.NewSerializer()
-		s.SetFieldSortOrder(influx.SortFields)
 		for metric := range metricC {
-			octets, err := s.Serialize(metric)
-			if err == nil {
-				fmt.Print("> ", string(octets))
-
+			if a.metadata != nil {
+				a.metadata.apply(metric)
 			}
+			fn(metric)
 		}
 	}()
 
@@ -170,20 +335,37 @@ func (a *Agent) Test(ctx context.Context) error {
 		}
 	}()
 
+	if wait > 0 {
+		log.Printf("D! [agent] Starting service inputs")
+		err := a.startServiceInputs(ctx, metricC)
+		if err != nil {
+			return err
+		}
+		defer a.stopServiceInputs()
+	}
+
+	hasServiceInputs := false
 	for _, input := range a.Config.Inputs {
 		select {
 		case <-ctx.Done():
 			return nil
 		default:
 			if _, ok := input.Input.(telegraf.ServiceInput); ok {
-				log.Printf("W!: [agent] skipping plugin [[%s]]: service inputs not supported in --test mode",
-					input.Name())
-				continue
+				if wait == 0 {
+					log.Printf("W!: [agent] skipping plugin [[%s]]: service inputs not supported in --test mode without --test-wait",
+						input.Name())
+					continue
+				}
+				hasServiceInputs = true
+			}
+
+			precision := a.Config.Agent.Precision.Duration
+			if input.Config.Precision != 0 {
+				precision = input.Config.Precision
 			}
 
 			acc := NewAccumulator(input, metricC)
-			acc.SetPrecision(a.Config.Agent.Precision.Duration,
-				a.Config.Agent.Interval.Duration)
+			acc.SetPrecision(precision, a.Config.Agent.Interval.Duration)
 			input.SetDefaultTags(a.Config.Tags)
 
 			// Special instructions for some inputs. cpu, for example, needs to be
@@ -191,8 +373,7 @@ func (a *Agent) Test(ctx context.Context) error {
 			switch input.Name() {
 			case "inputs.cpu", "inputs.mongodb", "inputs.procstat":
 				nulAcc := NewAccumulator(input, nulC)
-				nulAcc.SetPrecision(a.Config.Agent.Precision.Duration,
-					a.Config.Agent.Interval.Duration)
+				nulAcc.SetPrecision(precision, a.Config.Agent.Interval.Duration)
 				if err := input.Input.Gather(nulAcc); err != nil {
 					return err
 				}
@@ -209,52 +390,150 @@ func (a *Agent) Test(ctx context.Context) error {
 		}
 	}
 
+	if hasServiceInputs {
+		log.Printf("D! [agent] Waiting %s for service inputs", wait)
+		internal.SleepContext(ctx, wait)
+	}
+
 	return nil
 }
 
-// runInputs starts and triggers the periodic gather for Inputs.
-//
-// When the context is done the timers are stopped and this function returns
-// after all ongoing Gather calls complete.
-func (a *Agent) runInputs(
+// gatherInputsOnce runs the Gather function of each input once, then gives
+// service inputs wait to collect metrics.
+func (a *Agent) gatherInputsOnce(
 	ctx context.Context,
-	startTime time.Time,
 	dst chan<- telegraf.Metric,
+	wait time.Duration,
 ) error {
 	var wg sync.WaitGroup
+	hasServiceInputs := false
 	for _, input := range a.Config.Inputs {
-		interval := a.Config.Agent.Interval.Duration
-		precision := a.Config.Agent.Precision.Duration
-		jitter := a.Config.Agent.CollectionJitter.Duration
+		if _, ok := input.Input.(telegraf.ServiceInput); ok {
+			hasServiceInputs = true
+		}
 
-		// Overwrite agent interval if this plugin has its own.
+		interval := a.Config.Agent.Interval.Duration
 		if input.Config.Interval != 0 {
 			interval = input.Config.Interval
 		}
 
+		precision := a.Config.Agent.Precision.Duration
+		if input.Config.Precision != 0 {
+			precision = input.Config.Precision
+		}
+
 		acc := NewAccumulator(input, dst)
 		acc.SetPrecision(precision, interval)
 
 		wg.Add(1)
 		go func(input *models.RunningInput) {
 			defer wg.Done()
+			defer panicRecover(input)
 
-			if a.Config.Agent.RoundInterval {
-				err := internal.SleepContext(
-					ctx, internal.AlignDuration(startTime, interval))
-				if err != nil {
-					return
-				}
+			if !a.acquireGatherSlot(ctx) {
+				return
+			}
+			err := a.gatherOnce(acc, input, interval,
+				input.Config.GatherTimeout, a.releaseGatherSlot)
+			if err != nil {
+				acc.AddError(err)
 			}
-
-			a.gatherOnInterval(ctx, acc, input, interval, jitter)
 		}(input)
 	}
 	wg.Wait()
 
+	if hasServiceInputs && wait > 0 {
+		log.Printf("D! [agent] Waiting %s for service inputs", wait)
+		internal.SleepContext(ctx, wait)
+	}
+
 	return nil
 }
 
+// runInputs starts and triggers the periodic gather for Inputs.
+//
+// When the context is done the timers are stopped and this function returns
+// after all ongoing Gather calls complete.
+func (a *Agent) runInputs(
+	ctx context.Context,
+	startTime time.Time,
+	dst chan<- telegraf.Metric,
+) error {
+	a.inputsMu.Lock()
+	a.inputCtx = ctx
+	a.inputDst = dst
+	for _, input := range a.Config.Inputs {
+		a.startInput(ctx, startTime, dst, input)
+	}
+	a.inputsMu.Unlock()
+
+	<-ctx.Done()
+
+	// No more inputs can be added once the context is done.
+	a.inputsMu.Lock()
+	a.inputCtx = nil
+	a.inputsMu.Unlock()
+
+	a.inputWg.Wait()
+
+	return nil
+}
+
+// startInput starts the periodic gather for a single input.  It must be
+// called with inputsMu held.
+func (a *Agent) startInput(
+	ctx context.Context,
+	startTime time.Time,
+	dst chan<- telegraf.Metric,
+	input *models.RunningInput,
+) {
+	interval := a.Config.Agent.Interval.Duration
+	precision := a.Config.Agent.Precision.Duration
+	jitter := a.Config.Agent.CollectionJitter.Duration
+
+	// Overwrite agent interval and jitter if this plugin has its own.
+	if input.Config.Interval != 0 {
+		interval = input.Config.Interval
+	}
+	if input.Config.CollectionJitter != 0 {
+		jitter = input.Config.CollectionJitter
+	}
+	if input.Config.Precision != 0 {
+		precision = input.Config.Precision
+	}
+
+	acc := NewAccumulator(input, dst)
+	acc.SetPrecision(precision, interval)
+
+	ctx, cancel := context.WithCancel(ctx)
+	unit := &pluginUnit{cancel: cancel, done: make(chan struct{})}
+	a.inputUnits[input] = unit
+
+	a.inputWg.Add(1)
+	go func() {
+		defer a.inputWg.Done()
+		defer close(unit.done)
+
+		if a.Config.Agent.RoundInterval {
+			err := internal.SleepContext(
+				ctx, internal.AlignDuration(startTime, interval))
+			if err != nil {
+				return
+			}
+		}
+
+		if a.Config.Agent.SpreadGathers {
+			err := internal.SleepContext(ctx, spreadOffset(input, interval))
+			if err != nil {
+				return
+			}
+		}
+
+		a.gatherOnInterval(ctx, acc, input, interval, jitter,
+			input.Config.GatherTimeout)
+	}()
+}
+
 // gather runs an input's gather function periodically until the context is
 // done.
 func (a *Agent) gatherOnInterval(
@@ -263,21 +542,40 @@ func (a *Agent) gatherOnInterval(
 	input *models.RunningInput,
 	interval time.Duration,
 	jitter time.Duration,
+	timeout time.Duration,
 ) {
 	defer panicRecover(input)
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	// busy is held while a gather runs, including a gather abandoned after
+	// its timeout, so that gathers of the input never overlap.
+	busy := make(chan struct{}, 1)
+	release := func() {
+		a.releaseGatherSlot()
+		<-busy
+	}
+
 	for {
 		err := internal.SleepContext(ctx, internal.RandomDuration(jitter))
 		if err != nil {
 			return
 		}
 
-		err = a.gatherOnce(acc, input, interval)
-		if err != nil {
-			acc.AddError(err)
+		select {
+		case busy <- struct{}{}:
+			if !a.acquireGatherSlot(ctx) {
+				<-busy
+				return
+			}
+			err = a.gatherOnce(acc, input, interval, timeout, release)
+			if err != nil {
+				acc.AddError(err)
+			}
+		default:
+			log.Printf("W! [agent] input %q is still running a gather abandoned "+
+				"after its timeout, skipping this interval", input.LogName())
 		}
 
 		select {
@@ -289,19 +587,73 @@ func (a *Agent) gatherOnInterval(
 	}
 }
 
+// acquireGatherSlot waits until a gather can start without exceeding
+// max_concurrent_gathers.  It returns false if the context is done first.
+func (a *Agent) acquireGatherSlot(ctx context.Context) bool {
+	if a.gatherSlots == nil {
+		return true
+	}
+
+	select {
+	case a.gatherSlots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// releaseGatherSlot frees the slot taken by acquireGatherSlot.
+func (a *Agent) releaseGatherSlot() {
+	if a.gatherSlots != nil {
+		<-a.gatherSlots
+	}
+}
+
+// spreadOffset returns the fixed offset within the interval at which the
+// input gathers when spread_gathers is set.
+func spreadOffset(input *models.RunningInput, interval time.Duration) time.Duration {
+	if interval <= 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	h.Write([]byte(input.LogName() + input.ID))
+	return time.Duration(h.Sum64() % uint64(interval))
+}
+
 // gatherOnce runs the input's Gather function once, logging a warning each
 // interval it fails to complete before.
+//
+// If timeout is non-zero the gather is abandoned once it expires: an error is
+// returned and metrics added by the gather afterwards are discarded.  The
+// release function, if any, is called when the Gather function returns.
 func (a *Agent) gatherOnce(
 	acc telegraf.Accumulator,
 	input *models.RunningInput,
+	interval time.Duration,
 	timeout time.Duration,
+	release func(),
 ) error {
-	ticker := time.NewTicker(timeout)
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
-	done := make(chan error)
+	var expired <-chan time.Time
+	var gated *gatedAccumulator
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+
+		gated = &gatedAccumulator{Accumulator: acc}
+		acc = gated
+	}
+
+	done := make(chan error, 1)
 	go func() {
-		done <- input.Gather(acc)
+		err := input.Gather(acc)
+		if release != nil {
+			release()
+		}
+		done <- err
 	}()
 
 	for {
@@ -310,7 +662,11 @@ func (a *Agent) gatherOnce(
 			return err
 		case <-ticker.C:
 			log.Printf("W! [agent] input %q did not complete within its interval",
-				input.Name())
+				input.LogName())
+		case <-expired:
+			gated.close()
+			return fmt.Errorf("gather did not complete within its timeout of %s, "+
+				"abandoning it", timeout)
 		}
 	}
 }
@@ -440,36 +796,17 @@ func (a *Agent) runOutputs(
 	startTime time.Time,
 	src <-chan telegraf.Metric,
 ) error {
-	interval := a.Config.Agent.FlushInterval.Duration
-	jitter := a.Config.Agent.FlushJitter.Duration
-
 	ctx, cancel := context.WithCancel(context.Background())
 
-	var wg sync.WaitGroup
+	a.outputsMu.Lock()
+	a.outputCtx = ctx
 	for _, output := range a.Config.Outputs {
-		interval := interval
-		// Overwrite agent flush_interval if this plugin has its own.
-		if output.Config.FlushInterval != 0 {
-			interval = output.Config.FlushInterval
-		}
-
-		wg.Add(1)
-		go func(output *models.RunningOutput) {
-			defer wg.Done()
-
-			if a.Config.Agent.RoundInterval {
-				err := internal.SleepContext(
-					ctx, internal.AlignDuration(startTime, interval))
-				if err != nil {
-					return
-				}
-			}
-
-			a.flush(ctx, output, interval, jitter)
-		}(output)
+		a.startOutput(ctx, startTime, output)
 	}
+	a.outputsMu.Unlock()
 
 	for metric := range src {
+		a.outputsMu.RLock()
 		for i, output := range a.Config.Outputs {
 			if i == len(a.Config.Outputs)-1 {
 				output.AddMetric(metric)
@@ -477,15 +814,59 @@ func (a *Agent) runOutputs(
 				output.AddMetric(metric.Copy())
 			}
 		}
+		a.outputsMu.RUnlock()
 	}
 
 	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
+	a.outputsMu.Lock()
+	a.outputCtx = nil
+	a.outputsMu.Unlock()
+
 	cancel()
-	wg.Wait()
+	a.outputWg.Wait()
 
 	return nil
 }
 
+// startOutput starts the periodic flush for a single output.  It must be
+// called with outputsMu held.
+func (a *Agent) startOutput(
+	ctx context.Context,
+	startTime time.Time,
+	output *models.RunningOutput,
+) {
+	interval := a.Config.Agent.FlushInterval.Duration
+	jitter := a.Config.Agent.FlushJitter.Duration
+
+	// Overwrite agent flush_interval if this plugin has its own.
+	if output.Config.FlushInterval != 0 {
+		interval = output.Config.FlushInterval
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	unit := &pluginUnit{cancel: cancel, done: make(chan struct{})}
+	a.outputUnits[output] = unit
+
+	a.outputWg.Add(1)
+	go func() {
+		defer a.outputWg.Done()
+		defer close(unit.done)
+
+		if a.Config.Agent.RoundInterval {
+			err := internal.SleepContext(
+				ctx, internal.AlignDuration(startTime, interval))
+			if err != nil {
+				// Flush before returning so that metrics added before
+				// the output was stopped are not lost.
+				a.flushRemaining(output, interval)
+				return
+			}
+		}
+
+		a.flush(ctx, output, interval, jitter)
+	}()
+}
+
 // flush runs an output's flush function periodically until the context is
 // done.
 func (a *Agent) flush(
@@ -501,7 +882,7 @@ func (a *Agent) flush(
 
 	logError := func(err error) {
 		if err != nil {
-			log.Printf("E! [agent] Error writing to output [%s]: %v", output.Name, err)
+			log.Printf("E! [agent] Error writing to output [%s]: %v", output.LogName(), err)
 		}
 	}
 
@@ -509,7 +890,7 @@ func (a *Agent) flush(
 		// Favor shutdown over other methods.
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, interval, output.Write))
+			a.flushRemaining(output, interval)
 			return
 		default:
 		}
@@ -526,7 +907,57 @@ func (a *Agent) flush(
 				logError(a.flushOnce(output, interval, output.WriteBatch))
 			}
 		case <-ctx.Done():
-			logError(a.flushOnce(output, interval, output.Write))
+			a.flushRemaining(output, interval)
+			return
+		}
+	}
+}
+
+// flushRemaining writes the metrics remaining in the output's buffer when the
+// output stops.  Failed writes are retried until the buffer is empty or the
+// shutdown timeout expires, the metrics still buffered afterwards are left in
+// the buffer.
+func (a *Agent) flushRemaining(output *models.RunningOutput, interval time.Duration) {
+	timeout := a.Config.Agent.ShutdownTimeout.Duration
+	if timeout <= 0 {
+		err := a.flushOnce(output, interval, output.Write)
+		if err != nil {
+			log.Printf("E! [agent] Error writing to output [%s]: %v", output.LogName(), err)
+		}
+		return
+	}
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		// The write is abandoned if it does not complete before the
+		// deadline, so the channel is buffered to not leak its goroutine.
+		done := make(chan error, 1)
+		go func() {
+			done <- output.Write()
+		}()
+
+		select {
+		case err := <-done:
+			output.LogBufferStatus()
+			if err == nil && output.BufferLength() == 0 {
+				return
+			}
+			if err != nil {
+				log.Printf("E! [agent] Error writing to output [%s]: %v", output.LogName(), err)
+			}
+		case <-deadline.C:
+			log.Printf("E! [agent] Output %s did not write its buffer within the shutdown timeout",
+				output.LogName())
+			return
+		}
+
+		select {
+		case <-time.After(shutdownRetryInterval):
+		case <-deadline.C:
+			log.Printf("E! [agent] Output %s did not write its buffer within the shutdown timeout",
+				output.LogName())
 			return
 		}
 	}
@@ -554,7 +985,7 @@ func (a *Agent) flushOnce(
 			return err
 		case <-ticker.C:
 			log.Printf("W! [agent] output %q did not complete within its flush interval",
-				output.Name)
+				output.LogName())
 			output.LogBufferStatus()
 		}
 	}
@@ -564,24 +995,33 @@ func (a *Agent) flushOnce(
 // connectOutputs connects to all outputs.
 func (a *Agent) connectOutputs(ctx context.Context) error {
 	for _, output := range a.Config.Outputs {
-		log.Printf("D! [agent] Attempting connection to output: %s\n", output.Name)
-		err := output.Output.Connect()
+		err := a.connectOutput(ctx, output)
 		if err != nil {
-			log.Printf("E! [agent] Failed to connect to output %s, retrying in 15s, "+
-				"error was '%s' \n", output.Name, err)
+			return err
+		}
+	}
+	return nil
+}
 
-			err := internal.SleepContext(ctx, 15*time.Second)
-			if err != nil {
-				return err
-			}
+// connectOutput connects to an output, retrying once after a delay.
+func (a *Agent) connectOutput(ctx context.Context, output *models.RunningOutput) error {
+	log.Printf("D! [agent] Attempting connection to output: %s\n", output.LogName())
+	err := output.Output.Connect()
+	if err != nil {
+		log.Printf("E! [agent] Failed to connect to output %s, retrying in 15s, "+
+			"error was '%s' \n", output.LogName(), err)
 
-			err = output.Output.Connect()
-			if err != nil {
-				return err
-			}
+		err := internal.SleepContext(ctx, 15*time.Second)
+		if err != nil {
+			return err
+		}
+
+		err = output.Output.Connect()
+		if err != nil {
+			return err
 		}
-		log.Printf("D! [agent] Successfully connected to output: %s\n", output.Name)
 	}
+	log.Printf("D! [agent] Successfully connected to output: %s\n", output.LogName())
 	return nil
 }
 
@@ -594,6 +1034,23 @@ func (a *Agent) closeOutputs() error {
 	return err
 }
 
+// unregisterPluginStats removes the internal metrics of all plugins, so that
+// they are not reported after the agent stops or by the agent replacing it.
+func (a *Agent) unregisterPluginStats() {
+	for _, input := range a.Config.Inputs {
+		models.UnregisterPluginStats(input.Input)
+	}
+	for _, processor := range a.Config.Processors {
+		models.UnregisterPluginStats(processor.Processor)
+	}
+	for _, aggregator := range a.Config.Aggregators {
+		models.UnregisterPluginStats(aggregator.Aggregator)
+	}
+	for _, output := range a.Config.Outputs {
+		models.UnregisterPluginStats(output.Output)
+	}
+}
+
 // startServiceInputs starts all service inputs.
 func (a *Agent) startServiceInputs(
 	ctx context.Context,
@@ -613,7 +1070,7 @@ func (a *Agent) startServiceInputs(
 			err := si.Start(acc)
 			if err != nil {
 				log.Printf("E! [agent] Service for input %s failed to start: %v",
-					input.Name(), err)
+					input.LogName(), err)
 
 				for _, si := range started {
 					si.Stop()
@@ -631,6 +1088,8 @@ func (a *Agent) startServiceInputs(
 
 // stopServiceInputs stops all service inputs.
 func (a *Agent) stopServiceInputs() {
+	a.inputsMu.Lock()
+	defer a.inputsMu.Unlock()
 	for _, input := range a.Config.Inputs {
 		if si, ok := input.Input.(telegraf.ServiceInput); ok {
 			si.Stop()
@@ -644,7 +1103,7 @@ func panicRecover(input *models.RunningInput) {
 		trace := make([]byte, 2048)
 		runtime.Stack(trace, true)
 		log.Printf("E! FATAL: Input [%s] panicked: %s, Stack:\n%s\n",
-			input.Name(), err, trace)
+			input.LogName(), err, trace)
 		log.Println("E! PLEASE REPORT THIS PANIC ON GITHUB with " +
 			"stack trace, configuration, and OS information: " +
 			"https://github.com/influxdata/telegraf/issues/new/choose")
@@ -1,9 +1,20 @@
 package agent
 
 import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
 
 	// needing to load the plugins
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
@@ -109,3 +120,306 @@ func TestAgent_LoadOutput(t *testing.T) {
 	a, _ = NewAgent(c)
 	assert.Equal(t, 3, len(a.Config.Outputs))
 }
+
+type onceInput struct {
+	gathers int
+}
+
+func (i *onceInput) SampleConfig() string { return "" }
+func (i *onceInput) Description() string  { return "" }
+func (i *onceInput) Gather(acc telegraf.Accumulator) error {
+	i.gathers++
+	acc.AddFields("once", map[string]interface{}{"value": i.gathers}, nil)
+	return nil
+}
+
+type onceServiceInput struct {
+	wg sync.WaitGroup
+}
+
+func (i *onceServiceInput) SampleConfig() string                  { return "" }
+func (i *onceServiceInput) Description() string                   { return "" }
+func (i *onceServiceInput) Gather(acc telegraf.Accumulator) error { return nil }
+func (i *onceServiceInput) Start(acc telegraf.Accumulator) error {
+	i.wg.Add(1)
+	go func() {
+		defer i.wg.Done()
+		acc.AddFields("service", map[string]interface{}{"value": 1}, nil)
+	}()
+	return nil
+}
+func (i *onceServiceInput) Stop() { i.wg.Wait() }
+
+type onceOutput struct {
+	sync.Mutex
+	metrics []telegraf.Metric
+	closed  bool
+	fail    bool
+
+	// failures is the number of writes to fail before succeeding.
+	failures int
+}
+
+func (o *onceOutput) SampleConfig() string { return "" }
+func (o *onceOutput) Description() string  { return "" }
+func (o *onceOutput) Connect() error       { return nil }
+func (o *onceOutput) Close() error {
+	o.closed = true
+	return nil
+}
+func (o *onceOutput) Write(metrics []telegraf.Metric) error {
+	o.Lock()
+	defer o.Unlock()
+	if o.fail {
+		return errors.New("write failed")
+	}
+	if o.failures > 0 {
+		o.failures--
+		return errors.New("write failed")
+	}
+	o.metrics = append(o.metrics, metrics...)
+	return nil
+}
+
+func TestAgent_Once(t *testing.T) {
+	c := config.NewConfig()
+	c.Agent.Interval.Duration = time.Hour
+	c.Agent.FlushInterval.Duration = time.Hour
+	c.Agent.RoundInterval = true
+
+	input := &onceInput{}
+	c.Inputs = append(c.Inputs,
+		models.NewRunningInput(input, &models.InputConfig{Name: "once"}),
+		models.NewRunningInput(&onceServiceInput{}, &models.InputConfig{Name: "service"}))
+	output := &onceOutput{}
+	c.Outputs = append(c.Outputs,
+		models.NewRunningOutput("once", output, &models.OutputConfig{}, 0, 0))
+
+	a, err := NewAgent(c)
+	assert.NoError(t, err)
+
+	err = a.Once(context.Background(), 10*time.Millisecond)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, input.gathers)
+	assert.True(t, output.closed)
+
+	var names []string
+	for _, m := range output.metrics {
+		names = append(names, m.Name())
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"once", "service"}, names)
+}
+
+func TestAgent_StateDirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	run := func(output *onceOutput) {
+		c := config.NewConfig()
+		c.Agent.Interval.Duration = time.Hour
+		c.Agent.FlushInterval.Duration = time.Hour
+		c.Agent.StateDirectory = dir
+		c.Agent.ShutdownTimeout.Duration = 10 * time.Millisecond
+		c.Inputs = append(c.Inputs,
+			models.NewRunningInput(&onceInput{}, &models.InputConfig{Name: "once"}))
+		c.Outputs = append(c.Outputs,
+			models.NewRunningOutput("once", output, &models.OutputConfig{}, 0, 0))
+
+		a, err := NewAgent(c)
+		assert.NoError(t, err)
+		assert.NoError(t, a.Once(context.Background(), 0))
+	}
+
+	run(&onceOutput{fail: true})
+	_, err = os.Stat(filepath.Join(dir, "outputs.once.buffer"))
+	assert.NoError(t, err)
+
+	output := &onceOutput{}
+	run(output)
+	assert.Len(t, output.metrics, 2)
+	_, err = os.Stat(filepath.Join(dir, "outputs.once.buffer"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestAgent_ShutdownTimeout(t *testing.T) {
+	shutdownRetryInterval = time.Millisecond
+	defer func() { shutdownRetryInterval = time.Second }()
+
+	run := func(output *onceOutput) error {
+		c := config.NewConfig()
+		c.Agent.Interval.Duration = time.Hour
+		c.Agent.FlushInterval.Duration = time.Hour
+		c.Agent.ShutdownTimeout.Duration = 50 * time.Millisecond
+		c.Inputs = append(c.Inputs,
+			models.NewRunningInput(&onceInput{}, &models.InputConfig{Name: "once"}))
+		c.Outputs = append(c.Outputs,
+			models.NewRunningOutput("once", output, &models.OutputConfig{}, 0, 0))
+
+		a, err := NewAgent(c)
+		assert.NoError(t, err)
+		return a.Once(context.Background(), 0)
+	}
+
+	// failed writes are retried until the deadline
+	output := &onceOutput{failures: 2}
+	assert.NoError(t, run(output))
+	assert.Len(t, output.metrics, 1)
+
+	// metrics still buffered at the deadline are dropped
+	err := run(&onceOutput{fail: true})
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "1 metrics were dropped")
+	}
+}
+
+func TestAgent_RestartShutdownTimeout(t *testing.T) {
+	shutdownRetryInterval = time.Millisecond
+	defer func() { shutdownRetryInterval = time.Second }()
+
+	newConfig := func() *config.Config {
+		c := config.NewConfig()
+		c.Agent.Interval.Duration = 10 * time.Millisecond
+		c.Agent.FlushInterval.Duration = time.Hour
+		c.Agent.ShutdownTimeout.Duration = 50 * time.Millisecond
+		return c
+	}
+	c := newConfig()
+	c.Inputs = append(c.Inputs,
+		models.NewRunningInput(&onceInput{}, &models.InputConfig{Name: "once"}))
+	output := models.NewRunningOutput("once", &onceOutput{fail: true}, &models.OutputConfig{}, 0, 0)
+	c.Outputs = append(c.Outputs, output)
+
+	a, err := NewAgent(c)
+	assert.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- a.Run(ctx) }()
+
+	for output.BufferLength() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// the metrics dropped when stopping to restart do not fail the agent
+	next := newConfig()
+	next.Agent.Interval.Duration = time.Hour
+	assert.Equal(t, ErrRestartRequired, a.Reload(ctx, next))
+	cancel()
+	assert.NoError(t, <-done)
+}
+
+func TestAgent_MetadataTags(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tags.toml")
+	err = ioutil.WriteFile(path, []byte("datacenter = \"dc1\"\n"), 0644)
+	assert.NoError(t, err)
+
+	c := config.NewConfig()
+	c.Agent.Interval.Duration = time.Hour
+	c.Agent.FlushInterval.Duration = time.Hour
+	c.Agent.MetadataTagsFile = path
+	c.Inputs = append(c.Inputs,
+		models.NewRunningInput(&onceInput{}, &models.InputConfig{Name: "once"}))
+	output := &onceOutput{}
+	c.Outputs = append(c.Outputs,
+		models.NewRunningOutput("once", output, &models.OutputConfig{}, 0, 0))
+
+	a, err := NewAgent(c)
+	assert.NoError(t, err)
+	assert.NoError(t, a.Once(context.Background(), 0))
+
+	if assert.Len(t, output.metrics, 1) {
+		assert.Equal(t, map[string]string{"datacenter": "dc1"}, output.metrics[0].Tags())
+	}
+
+	c.Agent.MetadataProviders = []string{"openstack"}
+	_, err = NewAgent(c)
+	assert.Error(t, err)
+}
+
+type slowInput struct {
+	release chan struct{}
+}
+
+func (i *slowInput) SampleConfig() string { return "" }
+func (i *slowInput) Description() string  { return "" }
+func (i *slowInput) Gather(acc telegraf.Accumulator) error {
+	<-i.release
+	acc.AddFields("slow", map[string]interface{}{"value": 1}, nil)
+	return nil
+}
+
+func TestAgent_GatherTimeout(t *testing.T) {
+	a, err := NewAgent(config.NewConfig())
+	assert.NoError(t, err)
+
+	input := &slowInput{release: make(chan struct{})}
+	ri := models.NewRunningInput(input, &models.InputConfig{Name: "slow"})
+
+	metricC := make(chan telegraf.Metric, 1)
+	acc := NewAccumulator(ri, metricC)
+
+	released := make(chan struct{})
+	err = a.gatherOnce(acc, ri, time.Hour, 10*time.Millisecond, func() {
+		close(released)
+	})
+	assert.Error(t, err)
+
+	// The abandoned gather completes later, its metrics are discarded.
+	close(input.release)
+	<-released
+	assert.Len(t, metricC, 0)
+}
+
+type concurrentInput struct {
+	running *int32
+	max     *int32
+}
+
+func (i *concurrentInput) SampleConfig() string { return "" }
+func (i *concurrentInput) Description() string  { return "" }
+func (i *concurrentInput) Gather(acc telegraf.Accumulator) error {
+	n := atomic.AddInt32(i.running, 1)
+	defer atomic.AddInt32(i.running, -1)
+	for {
+		max := atomic.LoadInt32(i.max)
+		if n <= max || atomic.CompareAndSwapInt32(i.max, max, n) {
+			break
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	return nil
+}
+
+func TestAgent_MaxConcurrentGathers(t *testing.T) {
+	c := config.NewConfig()
+	c.Agent.Interval.Duration = time.Hour
+	c.Agent.FlushInterval.Duration = time.Hour
+	c.Agent.MaxConcurrentGathers = 2
+
+	var running, max int32
+	for i := 0; i < 6; i++ {
+		c.Inputs = append(c.Inputs, models.NewRunningInput(
+			&concurrentInput{running: &running, max: &max},
+			&models.InputConfig{Name: "concurrent"}))
+	}
+	c.Outputs = append(c.Outputs,
+		models.NewRunningOutput("once", &onceOutput{}, &models.OutputConfig{}, 0, 0))
+
+	a, err := NewAgent(c)
+	assert.NoError(t, err)
+	assert.NoError(t, a.Once(context.Background(), 0))
+	assert.True(t, max > 0 && max <= 2)
+}
+
+func TestAgent_SpreadOffset(t *testing.T) {
+	ri := models.NewRunningInput(&onceInput{}, &models.InputConfig{Name: "once"})
+	offset := spreadOffset(ri, 10*time.Second)
+	assert.True(t, offset >= 0 && offset < 10*time.Second)
+	assert.Equal(t, offset, spreadOffset(ri, 10*time.Second))
+}
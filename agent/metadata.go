@@ -0,0 +1,129 @@
+package agent
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/metadata"
+)
+
+// metadataTags holds the tags discovered from the metadata providers, which
+// are added to all metrics.
+type metadataTags struct {
+	providers []metadata.Provider
+	interval  time.Duration
+
+	mu sync.RWMutex
+	// results holds the last tags returned by each provider.
+	results []map[string]string
+	tags    map[string]string
+}
+
+// newMetadataTags returns the metadataTags for the agent config, or nil if
+// no metadata providers are configured.
+func newMetadataTags(c *config.AgentConfig) (*metadataTags, error) {
+	var providers []metadata.Provider
+	for _, name := range c.MetadataProviders {
+		p, err := metadata.NewProvider(name)
+		if err != nil {
+			return nil, err
+		}
+		providers = append(providers, p)
+	}
+	// The file is last so that its tags take precedence.
+	if c.MetadataTagsFile != "" {
+		providers = append(providers, &metadata.File{Path: c.MetadataTagsFile})
+	}
+	if len(providers) == 0 {
+		return nil, nil
+	}
+
+	return &metadataTags{
+		providers: providers,
+		interval:  c.MetadataRefreshInterval.Duration,
+		results:   make([]map[string]string, len(providers)),
+	}, nil
+}
+
+// refresh queries all providers, keeping the previous tags of the providers
+// that fail.
+func (m *metadataTags) refresh(ctx context.Context) {
+	var wg sync.WaitGroup
+	results := make([]map[string]string, len(m.providers))
+	for i, p := range m.providers {
+		wg.Add(1)
+		go func(i int, p metadata.Provider) {
+			defer wg.Done()
+			tags, err := p.Tags(ctx)
+			if err != nil {
+				log.Printf("E! [agent] Error reading metadata tags from %s: %v", p.Name(), err)
+				return
+			}
+			results[i] = tags
+		}(i, p)
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tags := make(map[string]string)
+	for i := range m.results {
+		if results[i] != nil {
+			m.results[i] = results[i]
+		}
+		for k, v := range m.results[i] {
+			tags[k] = v
+		}
+	}
+	m.tags = tags
+}
+
+// run refreshes the tags on the interval until the context is done.
+func (m *metadataTags) run(ctx context.Context) {
+	if m.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.refresh(ctx)
+		}
+	}
+}
+
+// apply adds the tags to the metric, without replacing the tags the metric
+// already has.
+func (m *metadataTags) apply(metric telegraf.Metric) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for k, v := range m.tags {
+		if !metric.HasTag(k) {
+			metric.AddTag(k, v)
+		}
+	}
+}
+
+// runMetadata adds the metadata tags to the metrics from src and sends them
+// to dst, refreshing the tags until src is closed.
+func (a *Agent) runMetadata(
+	src <-chan telegraf.Metric,
+	dst chan<- telegraf.Metric,
+) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.metadata.run(ctx)
+
+	for metric := range src {
+		a.metadata.apply(metric)
+		dst <- metric
+	}
+}
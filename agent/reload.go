@@ -0,0 +1,214 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+// ErrRestartRequired is returned by Reload when the new configuration cannot
+// be applied to the running agent and the agent must be restarted instead.
+var ErrRestartRequired = errors.New("configuration change requires an agent restart")
+
+// reloader is implemented by outputs reading files other than the config,
+// which Reload calls to read them again when the configuration of the output
+// is unchanged.
+type reloader interface {
+	Reload() error
+}
+
+// Reload applies the inputs and outputs of the new config to the running
+// agent.  Plugin instances whose configuration is unchanged keep running
+// undisturbed, retaining their state and buffered metrics; instances that
+// were removed are stopped and new instances are started.
+//
+// Changes to the agent settings, global tags, processors or aggregators
+// cannot be applied in place and result in ErrRestartRequired.  The agent
+// is then expected to be stopped and restarted with the new config, metrics
+// dropped when it stops are logged rather than returned by Run.
+func (a *Agent) Reload(ctx context.Context, c *config.Config) error {
+	if !reflect.DeepEqual(a.Config.Agent, c.Agent) ||
+		!reflect.DeepEqual(a.Config.Tags, c.Tags) ||
+		!sameProcessors(a.Config.Processors, c.Processors) ||
+		!sameAggregators(a.Config.Aggregators, c.Aggregators) {
+		atomic.StoreInt32(&a.restarting, 1)
+		return ErrRestartRequired
+	}
+
+	err := a.reloadOutputs(ctx, c.Outputs)
+	if err != nil {
+		return err
+	}
+
+	return a.reloadInputs(c.Inputs)
+}
+
+// reloadInputs replaces the running inputs with the given inputs.
+func (a *Agent) reloadInputs(inputs []*models.RunningInput) error {
+	a.inputsMu.Lock()
+	defer a.inputsMu.Unlock()
+
+	if a.inputCtx == nil {
+		return errors.New("agent is not running")
+	}
+
+	current := make(map[string]*models.RunningInput)
+	for _, input := range a.Config.Inputs {
+		current[input.ID] = input
+	}
+
+	var running []*models.RunningInput
+	var added []*models.RunningInput
+	for _, input := range inputs {
+		if prev, ok := current[input.ID]; ok {
+			models.UnregisterPluginStats(input.Input)
+			running = append(running, prev)
+			delete(current, input.ID)
+			continue
+		}
+		added = append(added, input)
+	}
+
+	for _, input := range current {
+		log.Printf("I! [agent] Stopping input %s", input.LogName())
+		if unit, ok := a.inputUnits[input]; ok {
+			unit.stop()
+			delete(a.inputUnits, input)
+		}
+		if si, ok := input.Input.(telegraf.ServiceInput); ok {
+			si.Stop()
+		}
+		models.UnregisterPluginStats(input.Input)
+	}
+
+	for _, input := range added {
+		log.Printf("I! [agent] Starting input %s", input.LogName())
+		if si, ok := input.Input.(telegraf.ServiceInput); ok {
+			acc := NewAccumulator(input, a.inputDst)
+			acc.SetPrecision(time.Nanosecond, 0)
+
+			err := si.Start(acc)
+			if err != nil {
+				log.Printf("E! [agent] Service for input %s failed to start: %v",
+					input.LogName(), err)
+				continue
+			}
+		}
+
+		a.startInput(a.inputCtx, time.Now(), a.inputDst, input)
+		running = append(running, input)
+	}
+
+	a.Config.Inputs = running
+	return nil
+}
+
+// reloadOutputs replaces the running outputs with the given outputs.
+func (a *Agent) reloadOutputs(ctx context.Context, outputs []*models.RunningOutput) error {
+	a.outputsMu.RLock()
+	running := a.outputCtx != nil
+	current := make(map[string]*models.RunningOutput)
+	for _, output := range a.Config.Outputs {
+		current[output.ID] = output
+	}
+	a.outputsMu.RUnlock()
+
+	if !running {
+		return errors.New("agent is not running")
+	}
+
+	var kept []*models.RunningOutput
+	var added []*models.RunningOutput
+	for _, output := range outputs {
+		if prev, ok := current[output.ID]; ok {
+			models.UnregisterPluginStats(output.Output)
+			if r, ok := prev.Output.(reloader); ok {
+				err := r.Reload()
+				if err != nil {
+					log.Printf("E! [agent] Error reloading output %s: %v", prev.LogName(), err)
+				}
+			}
+			kept = append(kept, prev)
+			delete(current, output.ID)
+			continue
+		}
+
+		// Connect before taking the lock, connecting can take a while.
+		err := a.connectOutput(ctx, output)
+		if err != nil {
+			log.Printf("E! [agent] Failed to connect to output %s, not starting it: %v",
+				output.LogName(), err)
+			continue
+		}
+		added = append(added, output)
+	}
+
+	a.outputsMu.Lock()
+	if a.outputCtx == nil {
+		a.outputsMu.Unlock()
+		return errors.New("agent is not running")
+	}
+
+	var removed []*pluginUnit
+	for _, output := range current {
+		if unit, ok := a.outputUnits[output]; ok {
+			removed = append(removed, unit)
+			delete(a.outputUnits, output)
+		}
+	}
+
+	for _, output := range added {
+		log.Printf("I! [agent] Starting output %s", output.LogName())
+		a.startOutput(a.outputCtx, time.Now(), output)
+		kept = append(kept, output)
+	}
+	a.Config.Outputs = kept
+	a.outputsMu.Unlock()
+
+	// Removed outputs no longer receive metrics; flush whatever they have
+	// buffered before closing them.
+	for _, unit := range removed {
+		unit.stop()
+	}
+	for _, output := range current {
+		log.Printf("I! [agent] Stopped output %s", output.LogName())
+		err := output.Output.Close()
+		if err != nil {
+			log.Printf("E! [agent] Error closing output %s: %v", output.LogName(), err)
+		}
+		models.UnregisterPluginStats(output.Output)
+	}
+
+	return nil
+}
+
+func sameProcessors(a, b models.RunningProcessors) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
+		}
+	}
+	return true
+}
+
+func sameAggregators(a, b []*models.RunningAggregator) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].ID != b[i].ID {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,126 @@
+package agent
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/influxdata/telegraf/internal/models"
+	influxparser "github.com/influxdata/telegraf/plugins/parsers/influx"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+// bufferFiles returns the file used to persist the buffer of each output in
+// the state directory.  Files are named after the output and its alias, so
+// that they survive changes to the rest of the output's configuration.
+func (a *Agent) bufferFiles() map[*models.RunningOutput]string {
+	dir := a.Config.Agent.StateDirectory
+
+	files := make(map[*models.RunningOutput]string)
+	seen := make(map[string]int)
+	for _, output := range a.Config.Outputs {
+		name := strings.Map(func(r rune) rune {
+			switch {
+			case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9',
+				r == '.', r == '-', r == '_':
+				return r
+			}
+			return '_'
+		}, output.LogName())
+
+		seen[name]++
+		if n := seen[name]; n > 1 {
+			name = fmt.Sprintf("%s-%d", name, n)
+		}
+		files[output] = filepath.Join(dir, name+".buffer")
+	}
+	return files
+}
+
+// loadBuffers adds the metrics persisted by the previous run to the output
+// buffers.  The files are removed once loaded so that the metrics are sent
+// only once.
+func (a *Agent) loadBuffers() {
+	for output, path := range a.bufferFiles() {
+		data, err := ioutil.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			log.Printf("E! [agent] Error loading buffer of output %s: %v",
+				output.LogName(), err)
+			continue
+		}
+
+		parser := influxparser.NewParser(influxparser.NewMetricHandler())
+		metrics, err := parser.Parse(data)
+		if err != nil {
+			log.Printf("E! [agent] Error loading buffer of output %s: %v",
+				output.LogName(), err)
+			continue
+		}
+
+		output.Restore(metrics)
+		log.Printf("I! [agent] Loaded %d buffered metrics for output %s",
+			len(metrics), output.LogName())
+
+		err = os.Remove(path)
+		if err != nil {
+			log.Printf("E! [agent] Error removing buffer file: %v", err)
+		}
+	}
+}
+
+// saveBuffers persists the metrics that could not be written to the outputs
+// so that they are sent by the next run.  It must be called after the
+// outputs have stopped, it returns the number of metrics that could not be
+// saved.
+func (a *Agent) saveBuffers() int {
+	err := os.MkdirAll(a.Config.Agent.StateDirectory, 0755)
+	if err != nil {
+		log.Printf("E! [agent] Error creating state directory: %v", err)
+		return a.unwrittenMetrics()
+	}
+
+	var dropped int
+	for output, path := range a.bufferFiles() {
+		metrics := output.Drain()
+		if len(metrics) == 0 {
+			continue
+		}
+
+		s := influx.NewSerializer()
+		s.SetFieldTypeSupport(influx.UintSupport)
+		octets, err := s.SerializeBatch(metrics)
+		if err != nil {
+			log.Printf("E! [agent] Error saving buffer of output %s: %v",
+				output.LogName(), err)
+			dropped += len(metrics)
+			continue
+		}
+
+		// Write to a temporary file first so that a partially written
+		// buffer is never loaded.
+		tmp := path + ".tmp"
+		err = ioutil.WriteFile(tmp, octets, 0600)
+		if err == nil {
+			err = os.Rename(tmp, path)
+		}
+		if err != nil {
+			log.Printf("E! [agent] Error saving buffer of output %s: %v",
+				output.LogName(), err)
+			dropped += len(metrics)
+			continue
+		}
+
+		for _, m := range metrics {
+			m.Drop()
+		}
+		log.Printf("I! [agent] Saved %d buffered metrics for output %s",
+			len(metrics), output.LogName())
+	}
+	return dropped
+}
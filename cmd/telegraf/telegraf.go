@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
@@ -10,21 +11,23 @@ import (
 	_ "net/http/pprof" // Comment this line to disable pprof endpoint.
 	"os"
 	"os/signal"
-	"runtime"
 	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/influxdata/telegraf/agent"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/logger"
 	_ "github.com/influxdata/telegraf/plugins/aggregators/all"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	_ "github.com/influxdata/telegraf/plugins/inputs/all"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	_ "github.com/influxdata/telegraf/plugins/outputs/all"
+	"github.com/influxdata/telegraf/plugins/outputs/cmp"
 	_ "github.com/influxdata/telegraf/plugins/processors/all"
-	"github.com/kardianos/service"
 )
 
 var fDebug = flag.Bool("debug", false,
@@ -34,9 +37,16 @@ var pprofAddr = flag.String("pprof-addr", "",
 var fQuiet = flag.Bool("quiet", false,
 	"run in quiet mode")
 var fTest = flag.Bool("test", false, "gather metrics, print them out, and exit")
+var fTestWait = flag.Int("test-wait", 0,
+	"time in seconds to run service inputs in test or once mode")
+var fCMPCheckCoverage = flag.Bool("cmp-check-coverage", false,
+	"gather metrics, print the coverage of their cmp translations, and exit")
+var fOnce = flag.Bool("once", false, "gather metrics once, write them, and exit")
 var fConfig = flag.String("config", "", "configuration file to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
+var fWatchConfig = flag.String("watch-config", "",
+	"reload the config when its files change, method is \"notify\" or \"poll\"")
 var fVersion = flag.Bool("version", false, "display the version and exit")
 var fSampleConfig = flag.Bool("sample-config", false,
 	"print out full sample configuration")
@@ -77,90 +87,193 @@ func reloadLoop(
 ) {
 	reload := make(chan bool, 1)
 	reload <- true
+
+	// next is the already loaded config to restart the agent with, if any.
+	var next *config.Config
 	for <-reload {
 		reload <- false
 
 		ctx, cancel := context.WithCancel(context.Background())
 
-		signals := make(chan os.Signal)
+		// Configuration changes are first applied to the running agent,
+		// restart requests are sent when that is not possible.
+		changed := make(chan struct{}, 1)
+		restart := make(chan *config.Config, 1)
+
+		signals := make(chan os.Signal, 1)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP,
 			syscall.SIGTERM, syscall.SIGINT)
 		go func() {
-			select {
-			case sig := <-signals:
-				if sig == syscall.SIGHUP {
-					log.Printf("I! Reloading Telegraf config")
+			for {
+				select {
+				case sig := <-signals:
+					if sig == syscall.SIGHUP {
+						log.Printf("I! Reloading Telegraf config")
+						select {
+						case changed <- struct{}{}:
+						default:
+						}
+						continue
+					}
+					cancel()
+				case next = <-restart:
 					<-reload
 					reload <- true
+					cancel()
+				case <-stop:
+					cancel()
 				}
-				cancel()
-			case <-stop:
-				cancel()
+				return
 			}
 		}()
 
-		err := runAgent(ctx, inputFilters, outputFilters)
+		c := next
+		next = nil
+		err := runAgent(ctx, c, changed, restart, inputFilters, outputFilters)
+		signal.Stop(signals)
+		cancel()
 		if err != nil {
 			log.Fatalf("E! [telegraf] Error running agent: %v", err)
 		}
 	}
 }
 
-func runAgent(ctx context.Context,
+// loadConfig loads and validates the configuration files.  When reloading,
+// prev is the config currently running; files in the config directory that
+// fail to load fall back to their version in prev.
+func loadConfig(
+	prev *config.Config,
 	inputFilters []string,
 	outputFilters []string,
-) error {
-	// Setup default logging. This may need to change after reading the config
-	// file, but we can configure it to use our logger implementation now.
-	logger.SetupLogging(false, false, "")
-	log.Printf("I! Starting Telegraf %s", version)
-
-	// If no other options are specified, load the config file and run.
+) (*config.Config, error) {
 	c := config.NewConfig()
 	c.OutputFilters = outputFilters
 	c.InputFilters = inputFilters
 	err := c.LoadConfig(*fConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if *fConfigDirectory != "" {
-		err = c.LoadDirectory(*fConfigDirectory)
+		if prev == nil {
+			err = c.LoadDirectory(*fConfigDirectory)
+		} else {
+			err = c.ReloadDirectory(*fConfigDirectory, prev)
+		}
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-	if !*fTest && len(c.Outputs) == 0 {
-		return errors.New("Error: no outputs found, did you provide a valid config file?")
+	if !*fTest && !*fCMPCheckCoverage && len(c.Outputs) == 0 {
+		return nil, errors.New("Error: no outputs found, did you provide a valid config file?")
 	}
 	if len(c.Inputs) == 0 {
-		return errors.New("Error: no inputs found, did you provide a valid config file?")
+		return nil, errors.New("Error: no inputs found, did you provide a valid config file?")
 	}
 
 	if int64(c.Agent.Interval.Duration) <= 0 {
-		return fmt.Errorf("Agent interval must be positive, found %s",
+		return nil, fmt.Errorf("Agent interval must be positive, found %s",
 			c.Agent.Interval.Duration)
 	}
 
 	if int64(c.Agent.FlushInterval.Duration) <= 0 {
-		return fmt.Errorf("Agent flush_interval must be positive; found %s",
+		return nil, fmt.Errorf("Agent flush_interval must be positive; found %s",
 			c.Agent.Interval.Duration)
 	}
 
+	return c, nil
+}
+
+// reloadAgent loads the config each time it changes and applies it to the
+// running agent.  A restart is requested when the changes cannot be applied
+// in place.  A config that fails to load leaves the agent running unchanged.
+func reloadAgent(
+	ctx context.Context,
+	ag *agent.Agent,
+	changed <-chan struct{},
+	restart chan<- *config.Config,
+	inputFilters []string,
+	outputFilters []string,
+) {
+	current := ag.Config
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-changed:
+		}
+
+		// Read secrets again in case they have been rotated.
+		secret.Refresh()
+
+		c, err := loadConfig(current, inputFilters, outputFilters)
+		if err != nil {
+			log.Printf("E! [telegraf] Error reloading config, keeping the current config: %v", err)
+			continue
+		}
+
+		err = ag.Reload(ctx, c)
+		if err == agent.ErrRestartRequired {
+			log.Printf("I! [telegraf] Restarting agent to apply the new config")
+			restart <- c
+			return
+		}
+		if err != nil {
+			log.Printf("E! [telegraf] Error reloading config: %v", err)
+			continue
+		}
+		current = c
+
+		log.Printf("I! Loaded inputs: %s", strings.Join(ag.Config.InputNames(), " "))
+		log.Printf("I! Loaded outputs: %s", strings.Join(ag.Config.OutputNames(), " "))
+	}
+}
+
+func runAgent(ctx context.Context,
+	c *config.Config,
+	changed chan struct{},
+	restart chan<- *config.Config,
+	inputFilters []string,
+	outputFilters []string,
+) error {
+	// Setup default logging. This may need to change after reading the config
+	// file, but we can configure it to use our logger implementation now.
+	logger.SetupLogging(logger.LogConfig{})
+	log.Printf("I! Starting Telegraf %s", version)
+
+	// If no other options are specified, load the config file and run.
+	var err error
+	if c == nil {
+		c, err = loadConfig(nil, inputFilters, outputFilters)
+		if err != nil {
+			return err
+		}
+	}
+
 	ag, err := agent.NewAgent(c)
 	if err != nil {
 		return err
 	}
 
 	// Setup logging as configured.
-	logger.SetupLogging(
-		ag.Config.Agent.Debug || *fDebug,
-		ag.Config.Agent.Quiet || *fQuiet,
-		ag.Config.Agent.Logfile,
-	)
-
+	logger.SetupLogging(logger.LogConfig{
+		Debug:               ag.Config.Agent.Debug || *fDebug,
+		Quiet:               ag.Config.Agent.Quiet || *fQuiet,
+		Logfile:             ag.Config.Agent.Logfile,
+		Format:              ag.Config.Agent.LogFormat,
+		RotationInterval:    ag.Config.Agent.LogfileRotationInterval.Duration,
+		RotationMaxSize:     ag.Config.Agent.LogfileRotationMaxSize.Size,
+		RotationMaxArchives: ag.Config.Agent.LogfileRotationMaxArchives,
+		RateLimit:           ag.Config.Agent.LogRateLimit,
+		RateLimitInterval:   ag.Config.Agent.LogRateLimitInterval.Duration,
+	})
+
+	testWait := time.Duration(*fTestWait) * time.Second
 	if *fTest {
-		return ag.Test(ctx)
+		return ag.Test(ctx, testWait)
+	}
+	if *fCMPCheckCoverage {
+		return checkCMPCoverage(ctx, ag, testWait)
 	}
 
 	log.Printf("I! Loaded inputs: %s", strings.Join(c.InputNames(), " "))
@@ -169,6 +282,28 @@ func runAgent(ctx context.Context,
 	log.Printf("I! Loaded outputs: %s", strings.Join(c.OutputNames(), " "))
 	log.Printf("I! Tags enabled: %s", c.ListTags())
 
+	if *fOnce {
+		return ag.Once(ctx, testWait)
+	}
+
+	if *fWatchConfig != "" {
+		if *fConfig == "" {
+			log.Printf("W! [telegraf] No --config given, only watching --config-directory")
+		}
+		watcher, err := config.NewWatcher(*fWatchConfig, *fConfig, *fConfigDirectory)
+		if err != nil {
+			return err
+		}
+		go func() {
+			err := watcher.Watch(ctx, changed)
+			if err != nil {
+				log.Printf("E! [telegraf] Error watching config: %v", err)
+			}
+		}()
+	}
+
+	go reloadAgent(ctx, ag, changed, restart, inputFilters, outputFilters)
+
 	if *fPidfile != "" {
 		f, err := os.OpenFile(*fPidfile, os.O_CREATE|os.O_WRONLY, 0644)
 		if err != nil {
@@ -190,35 +325,60 @@ func runAgent(ctx context.Context,
 	return ag.Run(ctx)
 }
 
-func usageExit(rc int) {
-	fmt.Println(internal.Usage)
-	os.Exit(rc)
-}
+// printEffectiveConfig loads the configuration files and prints the
+// resulting configuration with the default values of all options.
+func printEffectiveConfig(
+	inputFilters []string,
+	outputFilters []string,
+	aggregatorFilters []string,
+	processorFilters []string,
+) error {
+	c := config.NewConfig()
+	c.InputFilters = inputFilters
+	c.OutputFilters = outputFilters
+	err := c.LoadConfig(*fConfig)
+	if err != nil {
+		return err
+	}
 
-type program struct {
-	inputFilters      []string
-	outputFilters     []string
-	aggregatorFilters []string
-	processorFilters  []string
-}
+	if *fConfigDirectory != "" {
+		err = c.LoadDirectory(*fConfigDirectory)
+		if err != nil {
+			return err
+		}
+	}
 
-func (p *program) Start(s service.Service) error {
-	go p.run()
-	return nil
+	return config.WriteEffectiveConfig(os.Stdout, c, aggregatorFilters, processorFilters)
 }
-func (p *program) run() {
-	stop = make(chan struct{})
-	reloadLoop(
-		stop,
-		p.inputFilters,
-		p.outputFilters,
-		p.aggregatorFilters,
-		p.processorFilters,
-	)
+
+// printPlugins lists the plugins included in this build, as JSON including
+// their sample config if --json is given.
+func printPlugins(args []string) error {
+	flags := flag.NewFlagSet("plugins", flag.ContinueOnError)
+	asJSON := flags.Bool("json", false,
+		"print the plugins with their sample config as JSON")
+	err := flags.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	catalog := config.PluginCatalog()
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(catalog)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	for _, p := range catalog {
+		fmt.Fprintf(w, "%s.%s\t%s\n", p.Type, p.Name, p.Description)
+	}
+	return w.Flush()
 }
-func (p *program) Stop(s service.Service) error {
-	close(stop)
-	return nil
+
+func usageExit(rc int) {
+	fmt.Println(internal.Usage)
+	os.Exit(rc)
 }
 
 func formatFullVersion() string {
@@ -244,6 +404,32 @@ func formatFullVersion() string {
 	return strings.Join(parts, " ")
 }
 
+// checkCMPCoverage runs the inputs once and prints which of their metrics
+// have a translation in the first cmp output of the config, or in the default
+// translations if there is none.
+func checkCMPCoverage(ctx context.Context, ag *agent.Agent, wait time.Duration) error {
+	var output *cmp.CMP
+	for _, ro := range ag.Config.Outputs {
+		if o, ok := ro.Output.(*cmp.CMP); ok {
+			output = o
+			break
+		}
+	}
+	if output == nil {
+		output = outputs.Outputs["cmp"]().(*cmp.CMP)
+	}
+
+	metrics, err := ag.Gather(ctx, wait)
+	if err != nil {
+		return err
+	}
+	coverage, err := output.CheckCoverage(metrics)
+	if err != nil {
+		return err
+	}
+	return coverage.Report(os.Stdout)
+}
+
 func main() {
 	flag.Usage = func() { usageExit(0) }
 	flag.Parse()
@@ -287,7 +473,25 @@ func main() {
 		case "version":
 			fmt.Println(formatFullVersion())
 			return
+		case "plugins":
+			err := printPlugins(args[1:])
+			if err != nil {
+				log.Fatalf("E! %v", err)
+			}
+			return
 		case "config":
+			if len(args) > 1 && args[1] == "effective" {
+				err := printEffectiveConfig(
+					inputFilters,
+					outputFilters,
+					aggregatorFilters,
+					processorFilters,
+				)
+				if err != nil {
+					log.Fatalf("E! %v", err)
+				}
+				return
+			}
 			config.PrintSampleConfig(
 				inputFilters,
 				outputFilters,
@@ -342,53 +546,10 @@ func main() {
 		log.Println("Telegraf version already configured to: " + internal.Version())
 	}
 
-	if runtime.GOOS == "windows" && !(*fRunAsConsole) {
-		svcConfig := &service.Config{
-			Name:        *fServiceName,
-			DisplayName: "Telegraf Data Collector Service",
-			Description: "Collects data using a series of plugins and publishes it to" +
-				"another series of plugins.",
-			Arguments: []string{"--config", "C:\\Program Files\\Telegraf\\telegraf.conf"},
-		}
-
-		prg := &program{
-			inputFilters:      inputFilters,
-			outputFilters:     outputFilters,
-			aggregatorFilters: aggregatorFilters,
-			processorFilters:  processorFilters,
-		}
-		s, err := service.New(prg, svcConfig)
-		if err != nil {
-			log.Fatal("E! " + err.Error())
-		}
-		// Handle the --service flag here to prevent any issues with tooling that
-		// may not have an interactive session, e.g. installing from Ansible.
-		if *fService != "" {
-			if *fConfig != "" {
-				(*svcConfig).Arguments = []string{"--config", *fConfig}
-			}
-			if *fConfigDirectory != "" {
-				(*svcConfig).Arguments = append((*svcConfig).Arguments, "--config-directory", *fConfigDirectory)
-			}
-			err := service.Control(s, *fService)
-			if err != nil {
-				log.Fatal("E! " + err.Error())
-			}
-			os.Exit(0)
-		} else {
-			err = s.Run()
-			if err != nil {
-				log.Println("E! " + err.Error())
-			}
-		}
-	} else {
-		stop = make(chan struct{})
-		reloadLoop(
-			stop,
-			inputFilters,
-			outputFilters,
-			aggregatorFilters,
-			processorFilters,
-		)
-	}
+	run(
+		inputFilters,
+		outputFilters,
+		aggregatorFilters,
+		processorFilters,
+	)
 }
@@ -0,0 +1,250 @@
+// +build windows
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/influxdata/telegraf/logger"
+	"github.com/kardianos/service"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+func run(inputFilters, outputFilters, aggregatorFilters, processorFilters []string) {
+	// Handle the --service flag here to prevent any issues with tooling that
+	// may not have an interactive session, e.g. installing from Ansible.
+	if *fService != "" && !*fRunAsConsole {
+		err := controlService(*fService)
+		if err != nil {
+			log.Fatal("E! " + err.Error())
+		}
+		os.Exit(0)
+	}
+
+	interactive, err := svc.IsAnInteractiveSession()
+	if err != nil {
+		log.Fatal("E! " + err.Error())
+	}
+
+	if *fRunAsConsole || interactive {
+		stop = make(chan struct{})
+		reloadLoop(
+			stop,
+			inputFilters,
+			outputFilters,
+			aggregatorFilters,
+			processorFilters,
+		)
+		return
+	}
+
+	err = logger.SetupEventLog(*fServiceName)
+	if err != nil {
+		log.Printf("E! Unable to log to the Windows Event Log: %v", err)
+	}
+
+	err = svc.Run(*fServiceName, &windowsService{
+		inputFilters:      inputFilters,
+		outputFilters:     outputFilters,
+		aggregatorFilters: aggregatorFilters,
+		processorFilters:  processorFilters,
+	})
+	if err != nil {
+		log.Println("E! " + err.Error())
+	}
+}
+
+// controlService runs the --service operation.
+func controlService(action string) error {
+	switch action {
+	case "pause":
+		return sendControl(svc.Pause, svc.Paused)
+	case "continue":
+		return sendControl(svc.Continue, svc.Running)
+	}
+
+	svcConfig := &service.Config{
+		Name:        *fServiceName,
+		DisplayName: "Telegraf Data Collector Service",
+		Description: "Collects data using a series of plugins and publishes it to" +
+			"another series of plugins.",
+		Arguments: []string{"--config", "C:\\Program Files\\Telegraf\\telegraf.conf"},
+	}
+	if *fConfig != "" {
+		svcConfig.Arguments = []string{"--config", *fConfig}
+	}
+	if *fConfigDirectory != "" {
+		svcConfig.Arguments = append(svcConfig.Arguments, "--config-directory", *fConfigDirectory)
+	}
+	if *fServiceName != "telegraf" {
+		svcConfig.Arguments = append(svcConfig.Arguments, "--service-name", *fServiceName)
+	}
+
+	s, err := service.New(&controlProgram{}, svcConfig)
+	if err != nil {
+		return err
+	}
+	err = service.Control(s, action)
+	if err != nil {
+		return err
+	}
+
+	switch action {
+	case "install":
+		err = eventlog.InstallAsEventCreate(*fServiceName,
+			eventlog.Error|eventlog.Warning|eventlog.Info)
+		if err != nil {
+			log.Printf("W! Unable to register the Windows Event Log source: %v", err)
+		}
+	case "uninstall":
+		err = eventlog.Remove(*fServiceName)
+		if err != nil {
+			log.Printf("W! Unable to remove the Windows Event Log source: %v", err)
+		}
+	}
+	return nil
+}
+
+// sendControl sends the control request to the installed service and waits
+// for the service to reach the state.
+func sendControl(c svc.Cmd, state svc.State) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(*fServiceName)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	status, err := s.Control(c)
+	if err != nil {
+		return err
+	}
+
+	timeout := time.Now().Add(time.Minute)
+	for status.State != state {
+		if time.Now().After(timeout) {
+			return fmt.Errorf("timeout waiting for service to reach state %d", state)
+		}
+		time.Sleep(500 * time.Millisecond)
+		status, err = s.Query()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// controlProgram is the service.Interface used to install and control the
+// service, the service itself is run by windowsService.
+type controlProgram struct{}
+
+func (p *controlProgram) Start(s service.Service) error { return nil }
+func (p *controlProgram) Stop(s service.Service) error  { return nil }
+
+// windowsService runs telegraf under the Windows Service Control Manager.
+// Pausing the service stops the agent, writing the buffered metrics as on
+// shutdown; continuing the service starts it again with a freshly loaded
+// config.
+type windowsService struct {
+	inputFilters      []string
+	outputFilters     []string
+	aggregatorFilters []string
+	processorFilters  []string
+
+	stop chan struct{}
+}
+
+const serviceAccepts = svc.AcceptStop | svc.AcceptShutdown | svc.AcceptPauseAndContinue
+
+func (s *windowsService) Execute(
+	args []string,
+	requests <-chan svc.ChangeRequest,
+	changes chan<- svc.Status,
+) (bool, uint32) {
+	changes <- svc.Status{State: svc.StartPending}
+	done := s.start()
+	changes <- svc.Status{State: svc.Running, Accepts: serviceAccepts}
+
+	for {
+		select {
+		case <-done:
+			// The agent stopped without being asked to.
+			return false, 0
+		case r := <-requests:
+			switch r.Cmd {
+			case svc.Interrogate:
+				changes <- r.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				log.Printf("I! Stopping the Telegraf service")
+				s.wait(done, svc.StopPending, changes)
+				return false, 0
+			case svc.Pause:
+				log.Printf("I! Pausing the Telegraf service")
+				s.wait(done, svc.PausePending, changes)
+				done = nil
+				changes <- svc.Status{State: svc.Paused, Accepts: serviceAccepts}
+			case svc.Continue:
+				log.Printf("I! Continuing the Telegraf service")
+				changes <- svc.Status{State: svc.ContinuePending}
+				if done == nil {
+					done = s.start()
+				}
+				changes <- svc.Status{State: svc.Running, Accepts: serviceAccepts}
+			default:
+				log.Printf("W! Unexpected service control request #%d", r.Cmd)
+			}
+		}
+	}
+}
+
+// start runs the agent, the returned channel is closed once it stops.
+func (s *windowsService) start() chan struct{} {
+	s.stop = make(chan struct{})
+	done := make(chan struct{})
+	go func(stop chan struct{}) {
+		defer close(done)
+		reloadLoop(
+			stop,
+			s.inputFilters,
+			s.outputFilters,
+			s.aggregatorFilters,
+			s.processorFilters,
+		)
+	}(s.stop)
+	return done
+}
+
+// wait stops the agent and waits for it to write its buffered metrics.
+// Progress is reported to the SCM while waiting so that it does not consider
+// the service hung, the agent bounds the time taken by its shutdown_timeout.
+func (s *windowsService) wait(done chan struct{}, state svc.State, changes chan<- svc.Status) {
+	if done == nil {
+		return
+	}
+
+	close(s.stop)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	var checkpoint uint32
+	for {
+		changes <- svc.Status{State: state, CheckPoint: checkpoint, WaitHint: 5000}
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			checkpoint++
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package telegraf
+
+// DeprecationInfo describes the deprecation of a plugin or of a plugin option.
+type DeprecationInfo struct {
+	// Since is the version the plugin or option was deprecated in.
+	Since string
+
+	// RemovalIn is the version the plugin or option is removed in.  Configs
+	// still using it fail to load from this version on.  If empty the
+	// removal is not scheduled.
+	RemovalIn string
+
+	// ReplacedBy is the option replacing a deprecated option.  When set, the
+	// value of the deprecated option is moved to the new option when the
+	// config is loaded.
+	ReplacedBy string
+
+	// Notice tells the user how to migrate away from the plugin or option.
+	Notice string
+}
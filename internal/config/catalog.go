@@ -0,0 +1,71 @@
+package config
+
+import (
+	"sort"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/secret"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+// PluginInfo describes a plugin available in this build.
+type PluginInfo struct {
+	// Type is the section the plugin is configured in, ie "inputs".
+	Type string `json:"type"`
+
+	Name         string `json:"name"`
+	Description  string `json:"description"`
+	SampleConfig string `json:"sample_config"`
+
+	// Service is true for inputs that run as a service instead of only
+	// gathering on an interval.
+	Service bool `json:"service,omitempty"`
+}
+
+// sampleConfigurer is implemented by all plugin types.
+type sampleConfigurer interface {
+	SampleConfig() string
+	Description() string
+}
+
+// PluginCatalog returns all registered plugins, sorted by type and name.
+func PluginCatalog() []PluginInfo {
+	var catalog []PluginInfo
+	add := func(typ, name string, plugin sampleConfigurer) {
+		_, service := plugin.(telegraf.ServiceInput)
+		catalog = append(catalog, PluginInfo{
+			Type:         typ,
+			Name:         name,
+			Description:  plugin.Description(),
+			SampleConfig: plugin.SampleConfig(),
+			Service:      typ == "inputs" && service,
+		})
+	}
+
+	for name, creator := range aggregators.Aggregators {
+		add("aggregators", name, creator())
+	}
+	for name, creator := range inputs.Inputs {
+		add("inputs", name, creator())
+	}
+	for name, creator := range outputs.Outputs {
+		add("outputs", name, creator())
+	}
+	for name, creator := range processors.Processors {
+		add("processors", name, creator())
+	}
+	for name, creator := range secret.Stores {
+		add("secretstores", name, creator())
+	}
+
+	sort.Slice(catalog, func(i, j int) bool {
+		if catalog[i].Type != catalog[j].Type {
+			return catalog[i].Type < catalog[j].Type
+		}
+		return catalog[i].Name < catalog[j].Name
+	})
+	return catalog
+}
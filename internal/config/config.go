@@ -4,9 +4,12 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"hash/fnv"
+	"io"
 	"io/ioutil"
 	"log"
 	"math"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
@@ -21,6 +24,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/plugins/aggregators"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/outputs"
@@ -39,8 +43,9 @@ var (
 	// Default output plugins
 	outputDefaults = []string{"influxdb"}
 
-	// envVarRe is a regex to find environment variables in the config file
-	envVarRe = regexp.MustCompile(`\$\w+`)
+	// envVarRe is a regex to find environment variables in the config file,
+	// either as $VAR, ${VAR} or ${VAR:-default}.
+	envVarRe = regexp.MustCompile(`\$(?:\{(\w+)(:-([^}]*))?\}|(\w+))`)
 
 	envVarEscaper = strings.NewReplacer(
 		`"`, `\"`,
@@ -62,15 +67,33 @@ type Config struct {
 	Aggregators []*models.RunningAggregator
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors models.RunningProcessors
+
+	// pluginIDs counts the plugin instances seen per ID so that identical
+	// plugin definitions still receive distinct IDs.
+	pluginIDs map[string]int
+
+	// fragments holds the contents of each successfully loaded config
+	// file, used by ReloadDirectory.
+	fragments map[string][]byte
+
+	// dryRun is set when a config is only loaded to check it for errors,
+	// global state such as secret stores is then left untouched.
+	dryRun bool
 }
 
 func NewConfig() *Config {
 	c := &Config{
 		// Agent defaults:
 		Agent: &AgentConfig{
-			Interval:      internal.Duration{Duration: 10 * time.Second},
-			RoundInterval: true,
-			FlushInterval: internal.Duration{Duration: 10 * time.Second},
+			Interval:        internal.Duration{Duration: 10 * time.Second},
+			RoundInterval:   true,
+			FlushInterval:   internal.Duration{Duration: 10 * time.Second},
+			ShutdownTimeout: internal.Duration{Duration: 30 * time.Second},
+
+			MetadataRefreshInterval: internal.Duration{Duration: time.Hour},
+
+			LogfileRotationMaxArchives: 5,
+			LogRateLimitInterval:       internal.Duration{Duration: time.Minute},
 		},
 
 		Tags:          make(map[string]string),
@@ -79,6 +102,8 @@ func NewConfig() *Config {
 		Processors:    make([]*models.RunningProcessor, 0),
 		InputFilters:  make([]string, 0),
 		OutputFilters: make([]string, 0),
+		pluginIDs:     make(map[string]int),
+		fragments:     make(map[string][]byte),
 	}
 	return c
 }
@@ -105,6 +130,14 @@ type AgentConfig struct {
 	// same time, which can have a measurable effect on the system.
 	CollectionJitter internal.Duration
 
+	// MaxConcurrentGathers limits the number of inputs gathering at the same
+	// time, zero is unlimited.
+	MaxConcurrentGathers int
+
+	// SpreadGathers offsets the gathers of each input by a fixed amount
+	// within its interval so that inputs do not all gather at once.
+	SpreadGathers bool
+
 	// FlushInterval is the Interval at which to flush data
 	FlushInterval internal.Duration
 
@@ -114,6 +147,11 @@ type AgentConfig struct {
 	// ie, a jitter of 5s and interval 10s means flushes will happen every 10-15s
 	FlushJitter internal.Duration
 
+	// ShutdownTimeout is the time the outputs are given to write their
+	// buffered metrics when the agent stops, zero waits for a single write
+	// attempt to complete however long it takes.
+	ShutdownTimeout internal.Duration
+
 	// MetricBatchSize is the maximum number of metrics that is wrote to an
 	// output plugin in one call.
 	MetricBatchSize int
@@ -141,10 +179,43 @@ type AgentConfig struct {
 	// Logfile specifies the file to send logs to
 	Logfile string
 
+	// LogFormat is the format of the log lines, text or json.
+	LogFormat string
+
+	// LogfileRotationInterval, LogfileRotationMaxSize and
+	// LogfileRotationMaxArchives configure the rotation of the log file.
+	LogfileRotationInterval    internal.Duration
+	LogfileRotationMaxSize     internal.Size
+	LogfileRotationMaxArchives int
+
+	// LogRateLimit is the maximum number of log messages of the agent and
+	// of each plugin per LogRateLimitInterval.
+	LogRateLimit         int
+	LogRateLimitInterval internal.Duration
+
+	// MetadataProviders are the cloud metadata services the tags added to
+	// all metrics are discovered from.
+	MetadataProviders []string
+
+	// MetadataTagsFile is a TOML file of static tags added to all metrics.
+	MetadataTagsFile string
+
+	// MetadataRefreshInterval is the interval at which the metadata tags
+	// are refreshed.
+	MetadataRefreshInterval internal.Duration
+
+	// StateDirectory is the directory the output buffers are persisted to
+	// when the agent stops, they are loaded again when it starts.
+	StateDirectory string
+
 	// Quiet is the option for running in quiet mode
 	Quiet        bool
 	Hostname     string
 	OmitHostname bool
+
+	// UseFQDN sets the host tag to the fully qualified domain name of the
+	// host instead of its hostname, when the hostname is not overridden.
+	UseFQDN bool `toml:"use_fqdn"`
 }
 
 // Inputs returns a list of strings of the configured inputs.
@@ -246,6 +317,15 @@ var header = `# Telegraf Configuration
   ## same time, which can have a measurable effect on the system.
   collection_jitter = "0s"
 
+  ## Maximum number of inputs gathering at the same time, 0 is unlimited.
+  ## Gathers wait for a free slot in the order they were scheduled.
+  # max_concurrent_gathers = 0
+
+  ## Spread the gathers of the inputs evenly across their interval instead
+  ## of starting them all at the same time.  The offset of each input is
+  ## fixed, so that it keeps gathering at the same point of the interval.
+  # spread_gathers = false
+
   ## Default flushing interval for all outputs. Maximum flush_interval will be
   ## flush_interval + flush_jitter
   flush_interval = "10s"
@@ -254,6 +334,11 @@ var header = `# Telegraf Configuration
   ## ie, a jitter of 5s and interval 10s means flushes will happen every 10-15s
   flush_jitter = "0s"
 
+  ## Time given to the outputs to write their buffered metrics when telegraf
+  ## stops.  Writes are retried until the deadline, the metrics still buffered
+  ## afterwards are dropped and telegraf exits with an error.
+  shutdown_timeout = "30s"
+
   ## By default or when set to "0s", precision will be set to the same
   ## timestamp order as the collection interval, with the maximum being 1s.
   ##   ie, when interval = "10s", precision will be "1s"
@@ -271,11 +356,43 @@ var header = `# Telegraf Configuration
   ## Specify the log file name. The empty string means to log to stderr.
   logfile = ""
 
+  ## Format of the log lines, "text" or "json".  JSON lines have the time,
+  ## level, source, alias and message of each log message.
+  # log_format = "text"
+
+  ## The log file is rotated once it is older than the rotation interval or
+  ## larger than the maximum size, zero disables the rotation.  At most
+  ## logfile_rotation_max_archives rotated files are kept, -1 keeps all.
+  # logfile_rotation_interval = "0h"
+  # logfile_rotation_max_size = "0MB"
+  # logfile_rotation_max_archives = 5
+
+  ## Limit the number of log messages written by the agent and each plugin
+  ## to log_rate_limit in each log_rate_limit_interval, 0 disables the limit.
+  # log_rate_limit = 0
+  # log_rate_limit_interval = "1m"
+
   ## Override default hostname, if empty use os.Hostname()
   hostname = ""
+  ## If set to true, use the fully qualified domain name of the host instead
+  ## of os.Hostname() when hostname is empty.
+  # use_fqdn = false
   ## If set to true, do no set the "host" tag in the telegraf agent.
   omit_hostname = false
 
+  ## Add tags describing the host to all metrics, discovered from the cloud
+  ## metadata services listed in metadata_providers ("ec2", "gcp" or "azure")
+  ## and read from the TOML file metadata_tags_file.  The tags are refreshed
+  ## every metadata_refresh_interval and do not replace the tags of a metric.
+  # metadata_providers = []
+  # metadata_tags_file = ""
+  # metadata_refresh_interval = "1h"
+
+  ## Directory in which metrics that could not be written to the outputs are
+  ## saved when telegraf stops, they are loaded and written when it starts
+  ## again.  If empty the metrics are dropped.
+  # state_directory = ""
+
 
 ###############################################################################
 #                            OUTPUT PLUGINS                                   #
@@ -559,6 +676,70 @@ func (c *Config) LoadDirectory(path string) error {
 	return filepath.Walk(path, walkfn)
 }
 
+// ReloadDirectory loads the *.conf files in path like LoadDirectory, except
+// that an error in one file does not fail the whole directory.  A file that
+// fails to load is replaced by the version last loaded successfully into
+// prev, or skipped if there is none, so that the plugins defined by the other
+// files are still applied.
+func (c *Config) ReloadDirectory(path string, prev *Config) error {
+	walkfn := func(thispath string, info os.FileInfo, _ error) error {
+		if info == nil {
+			log.Printf("W! Telegraf is not permitted to read %s", thispath)
+			return nil
+		}
+
+		if info.IsDir() {
+			if strings.HasPrefix(info.Name(), "..") {
+				// skip Kubernetes mounts, prevening loading the same config twice
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+		name := info.Name()
+		if len(name) < 6 || name[len(name)-5:] != ".conf" {
+			return nil
+		}
+
+		data, err := loadConfig(thispath)
+		if err != nil {
+			err = fmt.Errorf("Error loading %s, %s", thispath, err)
+		} else {
+			err = c.scratch().loadData(thispath, data)
+		}
+		if err == nil {
+			return c.loadData(thispath, data)
+		}
+
+		var last []byte
+		if prev != nil {
+			last = prev.fragments[thispath]
+		}
+		if last == nil {
+			log.Printf("E! [config] %v; skipping this file", err)
+			return nil
+		}
+		log.Printf("E! [config] %v; keeping the previous version of this file", err)
+		return c.loadData(thispath, last)
+	}
+	return filepath.Walk(path, walkfn)
+}
+
+// scratch returns an empty Config with the same settings as c, for
+// checking that a file loads without modifying c.
+func (c *Config) scratch() *Config {
+	s := NewConfig()
+	s.dryRun = true
+	agent := *c.Agent
+	s.Agent = &agent
+	for k, v := range c.Tags {
+		s.Tags[k] = v
+	}
+	s.InputFilters = c.InputFilters
+	s.OutputFilters = c.OutputFilters
+	return s
+}
+
 // Try to find a default config file at these locations (in order):
 //   1. $TELEGRAF_CONFIG_PATH
 //   2. $HOME/.telegraf/telegraf.conf
@@ -583,6 +764,29 @@ func getDefaultConfigPath() (string, error) {
 		" in $TELEGRAF_CONFIG_PATH, %s, or %s", homefile, etcfile)
 }
 
+// lookupFQDN returns the fully qualified domain name of the host, or the
+// hostname if it cannot be resolved.
+func lookupFQDN(hostname string) string {
+	cname, err := net.LookupCNAME(hostname)
+	if err == nil && strings.Contains(strings.TrimSuffix(cname, "."), ".") {
+		return strings.TrimSuffix(cname, ".")
+	}
+
+	// Fall back to the reverse lookup of the host's addresses.
+	addrs, err := net.LookupHost(hostname)
+	if err == nil {
+		for _, addr := range addrs {
+			names, err := net.LookupAddr(addr)
+			if err == nil && len(names) > 0 {
+				return strings.TrimSuffix(names[0], ".")
+			}
+		}
+	}
+
+	log.Printf("W! [agent] Unable to resolve the FQDN of %s, using the hostname", hostname)
+	return hostname
+}
+
 // LoadConfig loads the given config file and applies it to c
 func (c *Config) LoadConfig(path string) error {
 	var err error
@@ -596,6 +800,11 @@ func (c *Config) LoadConfig(path string) error {
 		return fmt.Errorf("Error loading %s, %s", path, err)
 	}
 
+	return c.loadData(path, data)
+}
+
+// loadData applies the contents of the config file at path to c.
+func (c *Config) loadData(path string, data []byte) error {
 	tbl, err := parseConfig(data)
 	if err != nil {
 		return fmt.Errorf("Error parsing %s, %s", path, err)
@@ -634,6 +843,9 @@ func (c *Config) LoadConfig(path string) error {
 				return err
 			}
 
+			if c.Agent.UseFQDN {
+				hostname = lookupFQDN(hostname)
+			}
 			c.Agent.Hostname = hostname
 		}
 
@@ -701,6 +913,20 @@ func (c *Config) LoadConfig(path string) error {
 						pluginName, path)
 				}
 			}
+		case "secretstores":
+			for storeName, storeVal := range subTable.Fields {
+				switch storeSubTable := storeVal.(type) {
+				case []*ast.Table:
+					for _, t := range storeSubTable {
+						if err = c.addSecretStore(storeName, t); err != nil {
+							return fmt.Errorf("Error parsing %s, %s", path, err)
+						}
+					}
+				default:
+					return fmt.Errorf("Unsupported config format: %s, file %s",
+						storeName, path)
+				}
+			}
 		case "aggregators":
 			for pluginName, pluginVal := range subTable.Fields {
 				switch pluginSubTable := pluginVal.(type) {
@@ -728,6 +954,11 @@ func (c *Config) LoadConfig(path string) error {
 		sort.Sort(c.Processors)
 	}
 
+	if c.fragments == nil {
+		c.fragments = make(map[string][]byte)
+	}
+	c.fragments[path] = data
+
 	return nil
 }
 
@@ -781,17 +1012,91 @@ func fetchConfig(u *url.URL) ([]byte, error) {
 // will find environment variables and replace them.
 func parseConfig(contents []byte) (*ast.Table, error) {
 	contents = trimBOM(contents)
+	contents = substituteEnv(contents)
+	return toml.Parse(contents)
+}
+
+// substituteEnv replaces the environment variables in the contents of a
+// config file.  $VAR and ${VAR} are left unchanged if VAR is not set, so that
+// templates such as the ${1} of a regex replacement are kept, and
+// ${VAR:-default} is replaced by default if VAR is unset or empty.  Values
+// are substituted in a single pass, so variables contained in values are not
+// expanded again.
+func substituteEnv(contents []byte) []byte {
+	return envVarRe.ReplaceAllFunc(contents, func(env_var []byte) []byte {
+		m := envVarRe.FindSubmatch(env_var)
+
+		// $VAR
+		if len(m[4]) > 0 {
+			env_val, ok := os.LookupEnv(string(m[4]))
+			if !ok {
+				return env_var
+			}
+			return []byte(escapeEnv(env_val))
+		}
+
+		// ${VAR} and ${VAR:-default}
+		env_val, ok := os.LookupEnv(string(m[1]))
+		if env_val == "" && len(m[2]) > 0 {
+			return m[3]
+		}
+		if !ok {
+			return env_var
+		}
+		return []byte(escapeEnv(env_val))
+	})
+}
 
-	env_vars := envVarRe.FindAll(contents, -1)
-	for _, env_var := range env_vars {
-		env_val, ok := os.LookupEnv(strings.TrimPrefix(string(env_var), "$"))
-		if ok {
-			env_val = escapeEnv(env_val)
-			contents = bytes.Replace(contents, env_var, []byte(env_val), 1)
+func (c *Config) addSecretStore(name string, table *ast.Table) error {
+	creator, ok := secret.Stores[name]
+	if !ok {
+		return fmt.Errorf("Undefined but requested secret store: %s", name)
+	}
+	store := creator()
+
+	storeConfig, err := buildSecretStore(name, table)
+	if err != nil {
+		return err
+	}
+
+	if err := toml.UnmarshalTable(table, store); err != nil {
+		return err
+	}
+
+	if !c.dryRun {
+		secret.Register(storeConfig, store)
+	}
+	return nil
+}
+
+// buildSecretStore parses the settings common to all secret stores and
+// removes them from the table.
+func buildSecretStore(name string, tbl *ast.Table) (secret.StoreConfig, error) {
+	conf := secret.StoreConfig{ID: name}
+	if node, ok := tbl.Fields["id"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				conf.ID = str.Value
+			}
 		}
 	}
 
-	return toml.Parse(contents)
+	if node, ok := tbl.Fields["refresh_interval"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return conf, err
+				}
+
+				conf.RefreshInterval = dur
+			}
+		}
+	}
+
+	delete(tbl.Fields, "id")
+	delete(tbl.Fields, "refresh_interval")
+	return conf, nil
 }
 
 func (c *Config) addAggregator(name string, table *ast.Table) error {
@@ -799,7 +1104,12 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 	if !ok {
 		return fmt.Errorf("Undefined but requested aggregator: %s", name)
 	}
+	err := migrateOptions("aggregators", name, table, aggregators.Deprecations, aggregators.DeprecatedOptions)
+	if err != nil {
+		return err
+	}
 	aggregator := creator()
+	id := c.pluginID("aggregators", name, table)
 
 	conf, err := buildAggregator(name, table)
 	if err != nil {
@@ -810,7 +1120,11 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 		return err
 	}
 
-	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(aggregator, conf))
+	ra := models.NewRunningAggregator(aggregator, conf)
+	ra.ID = id
+	models.SetLoggerOnPlugin(aggregator, models.NewLogger(ra.LogName(), conf.LogLevel))
+	models.SetStatsOnPlugin(aggregator, "aggregator", conf.Name, conf.Alias)
+	c.Aggregators = append(c.Aggregators, ra)
 	return nil
 }
 
@@ -819,7 +1133,12 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 	if !ok {
 		return fmt.Errorf("Undefined but requested processor: %s", name)
 	}
+	err := migrateOptions("processors", name, table, processors.Deprecations, processors.DeprecatedOptions)
+	if err != nil {
+		return err
+	}
 	processor := creator()
+	id := c.pluginID("processors", name, table)
 
 	processorConfig, err := buildProcessor(name, table)
 	if err != nil {
@@ -834,7 +1153,10 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		Name:      name,
 		Processor: processor,
 		Config:    processorConfig,
+		ID:        id,
 	}
+	models.SetLoggerOnPlugin(processor, models.NewLogger(rf.LogName(), processorConfig.LogLevel))
+	models.SetStatsOnPlugin(processor, "processor", processorConfig.Name, processorConfig.Alias)
 
 	c.Processors = append(c.Processors, rf)
 	return nil
@@ -848,7 +1170,12 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 	if !ok {
 		return fmt.Errorf("Undefined but requested output: %s", name)
 	}
+	err := migrateOptions("outputs", name, table, outputs.Deprecations, outputs.DeprecatedOptions)
+	if err != nil {
+		return err
+	}
 	output := creator()
+	id := c.pluginID("outputs", name, table)
 
 	// If the output has a SetSerializer function, then this means it can write
 	// arbitrary types of output, so build the serializer and set it.
@@ -872,6 +1199,9 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 
 	ro := models.NewRunningOutput(name, output, outputConfig,
 		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
+	ro.ID = id
+	models.SetLoggerOnPlugin(output, models.NewLogger(ro.LogName(), outputConfig.LogLevel))
+	models.SetStatsOnPlugin(output, "output", outputConfig.Name, outputConfig.Alias)
 	c.Outputs = append(c.Outputs, ro)
 	return nil
 }
@@ -889,7 +1219,12 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 	if !ok {
 		return fmt.Errorf("Undefined but requested input: %s", name)
 	}
+	err := migrateOptions("inputs", name, table, inputs.Deprecations, inputs.DeprecatedOptions)
+	if err != nil {
+		return err
+	}
 	input := creator()
+	id := c.pluginID("inputs", name, table)
 
 	// If the input has a SetParser function, then this means it can accept
 	// arbitrary types of input, so build the parser and set it.
@@ -923,11 +1258,67 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 	}
 
 	rp := models.NewRunningInput(input, pluginConfig)
+	rp.ID = id
+	models.SetLoggerOnPlugin(input, models.NewLogger(rp.LogName(), pluginConfig.LogLevel))
+	models.SetStatsOnPlugin(input, "input", pluginConfig.Name, pluginConfig.Alias)
 	rp.SetDefaultTags(c.Tags)
 	c.Inputs = append(c.Inputs, rp)
 	return nil
 }
 
+// pluginID returns an identifier for a plugin instance derived from its
+// kind, name and configuration table.  The ID is stable across config loads
+// as long as the plugin's configuration does not change, which lets a
+// running agent tell which instances were added, removed or modified.
+//
+// This must be called before the table is consumed by the build functions.
+func (c *Config) pluginID(kind, name string, table *ast.Table) string {
+	h := fnv.New64a()
+	h.Write([]byte(kind + "." + name))
+	writeTable(h, table)
+	id := fmt.Sprintf("%s.%s:%016x", kind, name, h.Sum64())
+
+	if c.pluginIDs == nil {
+		c.pluginIDs = make(map[string]int)
+	}
+	n := c.pluginIDs[id]
+	c.pluginIDs[id] = n + 1
+	if n > 0 {
+		id = fmt.Sprintf("%s-%d", id, n)
+	}
+	return id
+}
+
+// writeTable writes a canonical representation of the table to w, with keys
+// sorted so that the output does not depend on map iteration order.
+func writeTable(w io.Writer, table *ast.Table) {
+	keys := make([]string, 0, len(table.Fields))
+	for k := range table.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		fmt.Fprintf(w, "%q=", k)
+		switch v := table.Fields[k].(type) {
+		case *ast.KeyValue:
+			fmt.Fprintf(w, "%s;", v.Value.Source())
+		case *ast.Table:
+			io.WriteString(w, "{")
+			writeTable(w, v)
+			io.WriteString(w, "};")
+		case []*ast.Table:
+			io.WriteString(w, "[")
+			for _, t := range v {
+				io.WriteString(w, "{")
+				writeTable(w, t)
+				io.WriteString(w, "}")
+			}
+			io.WriteString(w, "];")
+		}
+	}
+}
+
 // buildAggregator parses Aggregator specific items from the ast.Table,
 // builds the filter and returns a
 // models.AggregatorConfig to be inserted into models.RunningAggregator
@@ -964,6 +1355,26 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 		}
 	}
 
+	if node, ok := tbl.Fields["alias"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				conf.Alias = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				if !models.ValidLogLevel(str.Value) {
+					return nil, fmt.Errorf("invalid log_level %q, must be one of "+
+						"\"debug\", \"info\", \"warn\" or \"error\"", str.Value)
+				}
+				conf.LogLevel = str.Value
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["drop_original"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if b, ok := kv.Value.(*ast.Boolean); ok {
@@ -1012,6 +1423,8 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 	delete(tbl.Fields, "period")
 	delete(tbl.Fields, "delay")
 	delete(tbl.Fields, "drop_original")
+	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	delete(tbl.Fields, "name_prefix")
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
@@ -1030,6 +1443,26 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error) {
 	conf := &models.ProcessorConfig{Name: name}
 
+	if node, ok := tbl.Fields["alias"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				conf.Alias = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				if !models.ValidLogLevel(str.Value) {
+					return nil, fmt.Errorf("invalid log_level %q, must be one of "+
+						"\"debug\", \"info\", \"warn\" or \"error\"", str.Value)
+				}
+				conf.LogLevel = str.Value
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["order"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if b, ok := kv.Value.(*ast.Integer); ok {
@@ -1043,6 +1476,8 @@ func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error
 	}
 
 	delete(tbl.Fields, "order")
+	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	var err error
 	conf.Filter, err = buildFilter(tbl)
 	if err != nil {
@@ -1171,6 +1606,15 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 			}
 		}
 	}
+
+	if node, ok := tbl.Fields["metricpass"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				f.MetricPass = str.Value
+			}
+		}
+	}
+
 	if err := f.Compile(); err != nil {
 		return f, err
 	}
@@ -1185,6 +1629,7 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 	delete(tbl.Fields, "tagpass")
 	delete(tbl.Fields, "tagexclude")
 	delete(tbl.Fields, "taginclude")
+	delete(tbl.Fields, "metricpass")
 	return f, nil
 }
 
@@ -1206,6 +1651,69 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["collection_jitter"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.CollectionJitter = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["gather_timeout"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.GatherTimeout = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["precision"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+				if dur < 0 {
+					return nil, fmt.Errorf("invalid precision %q, must be positive",
+						str.Value)
+				}
+
+				cp.Precision = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["alias"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.Alias = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				if !models.ValidLogLevel(str.Value) {
+					return nil, fmt.Errorf("invalid log_level %q, must be one of "+
+						"\"debug\", \"info\", \"warn\" or \"error\"", str.Value)
+				}
+				cp.LogLevel = str.Value
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["name_prefix"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1242,7 +1750,12 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 	delete(tbl.Fields, "name_prefix")
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
+	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	delete(tbl.Fields, "interval")
+	delete(tbl.Fields, "collection_jitter")
+	delete(tbl.Fields, "gather_timeout")
+	delete(tbl.Fields, "precision")
 	delete(tbl.Fields, "tags")
 	var err error
 	cp.Filter, err = buildFilter(tbl)
@@ -1809,6 +2322,26 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		oc.Filter.NamePass = oc.Filter.FieldPass
 	}
 
+	if node, ok := tbl.Fields["alias"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.Alias = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["log_level"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				if !models.ValidLogLevel(str.Value) {
+					return nil, fmt.Errorf("invalid log_level %q, must be one of "+
+						"\"debug\", \"info\", \"warn\" or \"error\"", str.Value)
+				}
+				oc.LogLevel = str.Value
+			}
+		}
+	}
+
 	if node, ok := tbl.Fields["flush_interval"]; ok {
 		if kv, ok := node.(*ast.KeyValue); ok {
 			if str, ok := kv.Value.(*ast.String); ok {
@@ -1846,9 +2379,23 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["omit_hostname"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if b, ok := kv.Value.(*ast.Boolean); ok {
+				oc.OmitHostname, err = strconv.ParseBool(b.Value)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
 	delete(tbl.Fields, "flush_interval")
 	delete(tbl.Fields, "metric_buffer_limit")
+	delete(tbl.Fields, "alias")
+	delete(tbl.Fields, "log_level")
 	delete(tbl.Fields, "metric_batch_size")
+	delete(tbl.Fields, "omit_hostname")
 
 	return oc, nil
 }
@@ -0,0 +1,142 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/toml/ast"
+)
+
+// currentVersion returns the version deprecations are checked against.
+var currentVersion = internal.Version
+
+// migrateOptions checks the plugin table for the use of a deprecated plugin
+// or deprecated options, logging a warning for each of them.  Options that
+// were replaced are renamed to the replacing option.  An error is returned if
+// the plugin or one of the options has been removed in the running version.
+func migrateOptions(
+	pluginType string,
+	name string,
+	tbl *ast.Table,
+	plugins map[string]telegraf.DeprecationInfo,
+	options map[string]map[string]telegraf.DeprecationInfo,
+) error {
+	plugin := pluginType + "." + name
+
+	if info, ok := plugins[name]; ok {
+		if isRemoved(info) {
+			return fmt.Errorf("plugin %s was removed in version %s", plugin, info.RemovalIn)
+		}
+		log.Printf("W! [config] DeprecationWarning: %s",
+			deprecationMessage("plugin "+plugin, info))
+	}
+
+	for option, info := range options[name] {
+		node, ok := tbl.Fields[option]
+		if !ok {
+			continue
+		}
+
+		if isRemoved(info) {
+			return fmt.Errorf("option %q of plugin %s was removed in version %s",
+				option, plugin, info.RemovalIn)
+		}
+		log.Printf("W! [config] DeprecationWarning: %s",
+			deprecationMessage(fmt.Sprintf("option %q of plugin %s", option, plugin), info))
+
+		if info.ReplacedBy == "" {
+			continue
+		}
+
+		delete(tbl.Fields, option)
+		if _, ok := tbl.Fields[info.ReplacedBy]; ok {
+			log.Printf("W! [config] Option %q of plugin %s is ignored, %q is set",
+				option, plugin, info.ReplacedBy)
+			continue
+		}
+
+		switch n := node.(type) {
+		case *ast.KeyValue:
+			n.Key = info.ReplacedBy
+		case *ast.Table:
+			n.Name = info.ReplacedBy
+		}
+		tbl.Fields[info.ReplacedBy] = node
+	}
+	return nil
+}
+
+// deprecationMessage describes the deprecation of the plugin or option what.
+func deprecationMessage(what string, info telegraf.DeprecationInfo) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s is deprecated since version %s", what, info.Since)
+	if info.RemovalIn != "" {
+		fmt.Fprintf(&buf, " and will be removed in version %s", info.RemovalIn)
+	}
+	if info.ReplacedBy != "" {
+		fmt.Fprintf(&buf, ", use %q instead", info.ReplacedBy)
+	}
+	if info.Notice != "" {
+		fmt.Fprintf(&buf, ": %s", info.Notice)
+	}
+	return buf.String()
+}
+
+// isRemoved returns true if the running version is past the removal of the
+// deprecated plugin or option.
+func isRemoved(info telegraf.DeprecationInfo) bool {
+	if info.RemovalIn == "" {
+		return false
+	}
+	return versionAtLeast(currentVersion(), info.RemovalIn)
+}
+
+// versionAtLeast returns true if version v is at least version min.  Versions
+// that cannot be parsed, such as those of development builds, are never at
+// least any version.
+func versionAtLeast(v, min string) bool {
+	a, ok := parseVersion(v)
+	if !ok {
+		return false
+	}
+	b, ok := parseVersion(min)
+	if !ok {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] > b[i]
+		}
+	}
+	return true
+}
+
+// parseVersion parses the major, minor and patch numbers of a version such
+// as "1.10.0" or "1.10.0-rc1".
+func parseVersion(v string) ([3]int, bool) {
+	var version [3]int
+
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(v, "-~+ "); i >= 0 {
+		v = v[:i]
+	}
+
+	parts := strings.Split(v, ".")
+	if len(parts) > len(version) {
+		return version, false
+	}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return version, false
+		}
+		version[i] = n
+	}
+	return version, true
+}
@@ -0,0 +1,485 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/internal/secret"
+)
+
+// redacted replaces the value of options that look like credentials.
+const redacted = "<redacted>"
+
+// sensitiveKeys are substrings of option names holding credentials.
+var sensitiveKeys = []string{
+	"password", "passwd", "secret", "token", "api_key", "apikey",
+	"access_key", "private_key", "credential", "authorization",
+}
+
+// WriteEffectiveConfig writes the configuration as loaded, including the
+// default values of all plugin options, in TOML.  Options that look like
+// credentials are redacted, secret references are written unresolved.
+//
+// Inputs and outputs are filtered when the config is loaded, aggregators and
+// processors are filtered by aggregatorFilters and processorFilters.
+func WriteEffectiveConfig(
+	w io.Writer,
+	c *Config,
+	aggregatorFilters []string,
+	processorFilters []string,
+) error {
+	var buf bytes.Buffer
+
+	global := &table{}
+	for _, k := range sortedKeys(c.Tags) {
+		global.add(k, strconv.Quote(c.Tags[k]))
+	}
+	writeEffectiveTable(&buf, "global_tags", false, "", global)
+
+	writeEffectiveTable(&buf, "agent", false, "", buildTable(reflect.ValueOf(*c.Agent), 0))
+
+	for _, output := range c.Outputs {
+		t := &table{}
+		addString(t, "alias", output.Config.Alias)
+		addString(t, "log_level", output.Config.LogLevel)
+		interval := c.Agent.FlushInterval.Duration
+		if output.Config.FlushInterval != 0 {
+			interval = output.Config.FlushInterval
+		}
+		t.add("flush_interval", strconv.Quote(interval.String()))
+		t.add("metric_batch_size", strconv.Itoa(output.MetricBatchSize))
+		t.add("metric_buffer_limit", strconv.Itoa(output.MetricBufferLimit))
+		if output.Config.OmitHostname {
+			t.add("omit_hostname", "true")
+		}
+		addFilter(t, output.Config.Filter)
+		t.merge(buildTable(reflect.ValueOf(output.Output), 0))
+		writeEffectiveTable(&buf, "outputs."+output.Name, true, "", t)
+	}
+
+	for _, processor := range c.Processors {
+		if len(processorFilters) > 0 &&
+			!sliceContains(processor.Config.Name, processorFilters) {
+			continue
+		}
+		t := &table{}
+		addString(t, "alias", processor.Config.Alias)
+		addString(t, "log_level", processor.Config.LogLevel)
+		if processor.Config.Order != 0 {
+			t.add("order", strconv.FormatInt(processor.Config.Order, 10))
+		}
+		addFilter(t, processor.Config.Filter)
+		t.merge(buildTable(reflect.ValueOf(processor.Processor), 0))
+		writeEffectiveTable(&buf, "processors."+processor.Config.Name, true, "", t)
+	}
+
+	for _, aggregator := range c.Aggregators {
+		if len(aggregatorFilters) > 0 &&
+			!sliceContains(aggregator.Config.Name, aggregatorFilters) {
+			continue
+		}
+		t := &table{}
+		addString(t, "alias", aggregator.Config.Alias)
+		addString(t, "log_level", aggregator.Config.LogLevel)
+		t.add("period", strconv.Quote(aggregator.Config.Period.String()))
+		t.add("delay", strconv.Quote(aggregator.Config.Delay.String()))
+		t.add("drop_original", strconv.FormatBool(aggregator.Config.DropOriginal))
+		addString(t, "name_override", aggregator.Config.NameOverride)
+		addString(t, "name_prefix", aggregator.Config.MeasurementPrefix)
+		addString(t, "name_suffix", aggregator.Config.MeasurementSuffix)
+		addFilter(t, aggregator.Config.Filter)
+		addTags(t, aggregator.Config.Tags)
+		t.merge(buildTable(reflect.ValueOf(aggregator.Aggregator), 0))
+		writeEffectiveTable(&buf, aggregator.Name(), true, "", t)
+	}
+
+	for _, input := range c.Inputs {
+		t := &table{}
+		addString(t, "alias", input.Config.Alias)
+		addString(t, "log_level", input.Config.LogLevel)
+		interval := c.Agent.Interval.Duration
+		if input.Config.Interval != 0 {
+			interval = input.Config.Interval
+		}
+		t.add("interval", strconv.Quote(interval.String()))
+		if input.Config.CollectionJitter != 0 {
+			t.add("collection_jitter", strconv.Quote(input.Config.CollectionJitter.String()))
+		}
+		if input.Config.GatherTimeout != 0 {
+			t.add("gather_timeout", strconv.Quote(input.Config.GatherTimeout.String()))
+		}
+		if input.Config.Precision != 0 {
+			t.add("precision", strconv.Quote(input.Config.Precision.String()))
+		}
+		addString(t, "name_override", input.Config.NameOverride)
+		addString(t, "name_prefix", input.Config.MeasurementPrefix)
+		addString(t, "name_suffix", input.Config.MeasurementSuffix)
+		addFilter(t, input.Config.Filter)
+		addTags(t, input.Config.Tags)
+		t.merge(buildTable(reflect.ValueOf(input.Input), 0))
+		writeEffectiveTable(&buf, input.Name(), true, "", t)
+	}
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// table is a TOML table with its keys in the order they are written.
+type table struct {
+	keys   []keyValue
+	tables []subTable
+}
+
+type keyValue struct {
+	key   string
+	value string
+}
+
+type subTable struct {
+	name  string
+	array bool
+	table *table
+}
+
+func (t *table) add(key, value string) {
+	t.keys = append(t.keys, keyValue{key: key, value: value})
+}
+
+func (t *table) addTable(name string, array bool, sub *table) {
+	t.tables = append(t.tables, subTable{name: name, array: array, table: sub})
+}
+
+func (t *table) merge(other *table) {
+	t.keys = append(t.keys, other.keys...)
+	t.tables = append(t.tables, other.tables...)
+}
+
+func (t *table) empty() bool {
+	return len(t.keys) == 0 && len(t.tables) == 0
+}
+
+func addString(t *table, key, value string) {
+	if value != "" {
+		t.add(key, strconv.Quote(value))
+	}
+}
+
+func addStrings(t *table, key string, values []string) {
+	if len(values) > 0 {
+		t.add(key, formatStrings(key, values))
+	}
+}
+
+func addFilter(t *table, f models.Filter) {
+	addStrings(t, "namepass", f.NamePass)
+	addStrings(t, "namedrop", f.NameDrop)
+	addStrings(t, "fieldpass", f.FieldPass)
+	addStrings(t, "fielddrop", f.FieldDrop)
+	addStrings(t, "taginclude", f.TagInclude)
+	addStrings(t, "tagexclude", f.TagExclude)
+	addString(t, "metricpass", f.MetricPass)
+	for _, tf := range []struct {
+		name    string
+		filters []models.TagFilter
+	}{{"tagpass", f.TagPass}, {"tagdrop", f.TagDrop}} {
+		if len(tf.filters) == 0 {
+			continue
+		}
+		sub := &table{}
+		for _, f := range tf.filters {
+			sub.add(f.Name, formatStrings(f.Name, f.Filter))
+		}
+		t.addTable(tf.name, false, sub)
+	}
+}
+
+func addTags(t *table, tags map[string]string) {
+	if len(tags) == 0 {
+		return
+	}
+	sub := &table{}
+	for _, k := range sortedKeys(tags) {
+		sub.add(k, strconv.Quote(tags[k]))
+	}
+	t.addTable("tags", false, sub)
+}
+
+// writeEffectiveTable writes the table and its sub-tables under the given path.
+func writeEffectiveTable(buf *bytes.Buffer, path string, array bool, indent string, t *table) {
+	if array {
+		fmt.Fprintf(buf, "%s[[%s]]\n", indent, path)
+	} else {
+		fmt.Fprintf(buf, "%s[%s]\n", indent, path)
+	}
+	for _, kv := range t.keys {
+		fmt.Fprintf(buf, "  %s%s = %s\n", indent, formatKey(kv.key), kv.value)
+	}
+	for _, sub := range t.tables {
+		writeEffectiveTable(buf, path+"."+formatKey(sub.name), sub.array, "  ", sub.table)
+	}
+	if indent == "" {
+		buf.WriteString("\n")
+	}
+}
+
+// maxDepth limits the nesting of tables in case of recursive types.
+const maxDepth = 8
+
+// buildTable returns the TOML table of the exported fields of a struct.
+func buildTable(rv reflect.Value, depth int) *table {
+	t := &table{}
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return t
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct || depth > maxDepth {
+		return t
+	}
+
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		fv := rv.Field(i)
+
+		if field.Anonymous && fv.Kind() == reflect.Struct {
+			if _, ok := formatValue("", fv); !ok {
+				t.merge(buildTable(fv, depth+1))
+				continue
+			}
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+
+		key := field.Tag.Get("toml")
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = snakeCase(field.Name)
+		}
+
+		if value, ok := formatValue(key, fv); ok {
+			t.add(key, value)
+			continue
+		}
+
+		for fv.Kind() == reflect.Ptr && !fv.IsNil() && fv.Elem().Kind() == reflect.Struct {
+			fv = fv.Elem()
+		}
+		switch fv.Kind() {
+		case reflect.Struct:
+			if sub := buildTable(fv, depth+1); !sub.empty() {
+				t.addTable(key, false, sub)
+			}
+		case reflect.Slice:
+			for j := 0; j < fv.Len(); j++ {
+				if sub := buildTable(fv.Index(j), depth+1); !sub.empty() {
+					t.addTable(key, true, sub)
+				}
+			}
+		case reflect.Map:
+			if fv.Type().Key().Kind() != reflect.String {
+				continue
+			}
+			sub := &table{}
+			keys := fv.MapKeys()
+			sort.Slice(keys, func(i, j int) bool {
+				return keys[i].String() < keys[j].String()
+			})
+			for _, k := range keys {
+				// Redact by the name of the entry, ie in a map of headers,
+				// unless the map as a whole holds credentials.
+				entryKey := k.String()
+				if isSensitive(key) {
+					entryKey = key
+				}
+				if value, ok := formatValue(entryKey, fv.MapIndex(k)); ok {
+					sub.add(k.String(), value)
+				}
+			}
+			if !sub.empty() {
+				t.addTable(key, false, sub)
+			}
+		}
+	}
+	return t
+}
+
+// formatValue returns the TOML representation of a scalar value or an array
+// of scalars, the value is redacted if key looks like a credential.
+func formatValue(key string, rv reflect.Value) (string, bool) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return "", false
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return "", false
+	}
+
+	if rv.CanInterface() {
+		switch v := rv.Interface().(type) {
+		case secret.Secret:
+			return strconv.Quote(v.String()), true
+		case internal.Duration:
+			return strconv.Quote(v.Duration.String()), true
+		case internal.Size:
+			return strconv.FormatInt(v.Size, 10), true
+		case time.Duration:
+			return strconv.Quote(v.String()), true
+		case time.Time:
+			return v.Format(time.RFC3339Nano), true
+		}
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		return strconv.Quote(redact(key, rv.String())), true
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		s := strconv.FormatFloat(rv.Float(), 'g', -1, 64)
+		if !strings.ContainsAny(s, ".eEnN") {
+			s += ".0"
+		}
+		return s, true
+	case reflect.Slice, reflect.Array:
+		values := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			value, ok := formatValue(key, rv.Index(i))
+			if !ok {
+				return "", false
+			}
+			values = append(values, value)
+		}
+		if len(values) == 0 && rv.Kind() == reflect.Slice && isTableType(rv.Type().Elem()) {
+			return "", false
+		}
+		return "[" + strings.Join(values, ", ") + "]", true
+	}
+	return "", false
+}
+
+// isTableType returns true if values of the type are written as tables.
+func isTableType(rt reflect.Type) bool {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return rt.Kind() == reflect.Map
+	}
+	_, ok := formatValue("", reflect.Zero(rt))
+	return !ok
+}
+
+func formatStrings(key string, values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, strconv.Quote(redact(key, v)))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// formatKey quotes keys that are not valid bare TOML keys.
+func formatKey(key string) string {
+	for _, r := range key {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			r == '_' || r == '-') {
+			return strconv.Quote(key)
+		}
+	}
+	if key == "" {
+		return `""`
+	}
+	return key
+}
+
+// redact hides the value of credential options and the passwords of URLs.
+func redact(key, value string) string {
+	if value == "" || secret.NewSecret(value).IsReference() {
+		return value
+	}
+
+	if isSensitive(key) {
+		return redacted
+	}
+
+	if strings.Contains(value, "://") {
+		u, err := url.Parse(value)
+		if err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.UserPassword(u.User.Username(), "redacted")
+				return u.String()
+			}
+		}
+	}
+	return value
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, suffix := range []string{"_file", "_path", "_dir", "_directory"} {
+		if strings.HasSuffix(key, suffix) {
+			return false
+		}
+	}
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// snakeCase converts a Go field name to the snake case option name, ie
+// "APIKey" to "api_key" and "ServerURLs" to "server_urls".
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var buf bytes.Buffer
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			switch {
+			case unicode.IsLower(prev) || unicode.IsDigit(prev):
+				buf.WriteByte('_')
+			case i+1 < len(runes) && unicode.IsLower(runes[i+1]) && !isPlural(runes, i+1):
+				buf.WriteByte('_')
+			}
+		}
+		buf.WriteRune(unicode.ToLower(r))
+	}
+	return buf.String()
+}
+
+// isPlural returns true if the rune at i is the "s" ending an acronym, as in
+// "URLs".
+func isPlural(runes []rune, i int) bool {
+	return runes[i] == 's' && (i+1 == len(runes) || unicode.IsUpper(runes[i+1]))
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,220 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/fsnotify.v1"
+)
+
+// pollInterval is how often the poll watcher checks files for changes.
+var pollInterval = 2 * time.Second
+
+// Watcher notifies when the configuration files it watches change.
+//
+// The "notify" method uses filesystem events, the "poll" method periodically
+// compares file modification times and sizes and works on filesystems that
+// don't support notifications, such as some network mounts.
+type Watcher struct {
+	method string
+	paths  []string
+}
+
+// NewWatcher returns a Watcher for the given files and directories.  Only
+// files ending in .conf are considered when watching a directory.
+func NewWatcher(method string, paths ...string) (*Watcher, error) {
+	switch method {
+	case "notify", "poll":
+	default:
+		return nil, fmt.Errorf("invalid watch method %q, must be \"notify\" or \"poll\"", method)
+	}
+
+	w := &Watcher{method: method}
+	for _, path := range paths {
+		// remote configs cannot be watched
+		if path == "" || strings.HasPrefix(path, "http://") ||
+			strings.HasPrefix(path, "https://") {
+			continue
+		}
+		w.paths = append(w.paths, path)
+	}
+	return w, nil
+}
+
+// Watch sends on notify each time a change is detected, until the context
+// is done.  Notifications are dropped if the previous one has not been
+// received yet, so bursts of changes are coalesced.
+func (w *Watcher) Watch(ctx context.Context, notify chan<- struct{}) error {
+	if w.method == "poll" {
+		return w.poll(ctx, notify)
+	}
+	return w.notify(ctx, notify)
+}
+
+func (w *Watcher) notify(ctx context.Context, notify chan<- struct{}) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	// The directories of the files are watched rather than the files
+	// themselves: editors and Kubernetes ConfigMaps replace a file by
+	// renaming a new one over it, which removes the watch of the file.
+	// The subdirectories of the config directories are watched as well,
+	// since LoadDirectory loads their files.
+	dirs := make(map[string]bool)
+	watchDirectory := func(path string) error {
+		return filepath.Walk(path, func(name string, info os.FileInfo, err error) error {
+			if err != nil || info == nil || !info.IsDir() {
+				return nil
+			}
+			if strings.HasPrefix(info.Name(), "..") {
+				// skip Kubernetes mounts, same as LoadDirectory
+				return filepath.SkipDir
+			}
+			if err := watcher.Add(name); err != nil {
+				return err
+			}
+			dirs[filepath.Clean(name)] = true
+			return nil
+		})
+	}
+	for _, path := range w.paths {
+		info, err := os.Stat(path)
+		if err == nil && info.IsDir() {
+			err = watchDirectory(path)
+		} else if err == nil {
+			err = watcher.Add(filepath.Dir(path))
+		}
+		if err != nil {
+			return fmt.Errorf("unable to watch %s: %v", path, err)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event := <-watcher.Events:
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			name := filepath.Clean(event.Name)
+			switch {
+			case w.isWatchedFile(name):
+			case event.Op&fsnotify.Create != 0 && dirs[filepath.Dir(name)]:
+				info, err := os.Stat(name)
+				if err != nil || !info.IsDir() || strings.HasPrefix(info.Name(), "..") {
+					continue
+				}
+				if err := watchDirectory(name); err != nil {
+					log.Printf("E! [config] Unable to watch %s: %v", name, err)
+				}
+			case event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 && dirs[name]:
+				delete(dirs, name)
+			default:
+				continue
+			}
+			log.Printf("D! [config] Detected change to %s", event.Name)
+			signalChange(notify)
+		case err := <-watcher.Errors:
+			log.Printf("E! [config] Error watching config files: %v", err)
+		}
+	}
+}
+
+func (w *Watcher) poll(ctx context.Context, notify chan<- struct{}) error {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	last := w.snapshot()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			current := w.snapshot()
+			if !sameSnapshot(last, current) {
+				signalChange(notify)
+			}
+			last = current
+		}
+	}
+}
+
+// isWatchedFile returns true if the file is one of the watched paths, a
+// .conf file inside a watched directory, or the ..data link Kubernetes
+// replaces when the ConfigMap mounted with a watched path is updated.
+func (w *Watcher) isWatchedFile(name string) bool {
+	for _, path := range w.paths {
+		path = filepath.Clean(path)
+		if name == path {
+			return true
+		}
+		if filepath.Base(name) == "..data" &&
+			(filepath.Dir(name) == path || filepath.Dir(name) == filepath.Dir(path)) {
+			return true
+		}
+		if filepath.Ext(name) == ".conf" {
+			rel, err := filepath.Rel(path, name)
+			if err == nil && !strings.HasPrefix(rel, "..") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// snapshot returns the state of every watched file.
+func (w *Watcher) snapshot() map[string]fileState {
+	files := make(map[string]fileState)
+	for _, path := range w.paths {
+		filepath.Walk(path, func(name string, info os.FileInfo, err error) error {
+			if err != nil || info == nil {
+				return nil
+			}
+			if info.IsDir() {
+				if strings.HasPrefix(info.Name(), "..") {
+					// skip Kubernetes mounts, same as LoadDirectory
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if name == path || filepath.Ext(name) == ".conf" {
+				files[name] = fileState{modTime: info.ModTime(), size: info.Size()}
+			}
+			return nil
+		})
+	}
+	return files
+}
+
+func sameSnapshot(a, b map[string]fileState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for name, state := range a {
+		if other, ok := b[name]; !ok || other != state {
+			return false
+		}
+	}
+	return true
+}
+
+func signalChange(notify chan<- struct{}) {
+	select {
+	case notify <- struct{}{}:
+	default:
+	}
+}
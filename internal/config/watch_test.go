@@ -0,0 +1,89 @@
+package config
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// watch starts watching the paths with the notify method, and returns a
+// function waiting for a notification for up to timeout.
+func watch(t *testing.T, paths ...string) (wait func(timeout time.Duration) bool, stop func()) {
+	w, err := NewWatcher("notify", paths...)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changed := make(chan struct{}, 1)
+	done := make(chan error)
+	go func() {
+		done <- w.Watch(ctx, changed)
+	}()
+	// leave time for the watches to be added
+	time.Sleep(100 * time.Millisecond)
+
+	wait = func(timeout time.Duration) bool {
+		select {
+		case <-changed:
+		case <-time.After(timeout):
+			return false
+		}
+		// drop the notifications of the other events of the change
+		time.Sleep(100 * time.Millisecond)
+		select {
+		case <-changed:
+		default:
+		}
+		return true
+	}
+	stop = func() {
+		cancel()
+		require.NoError(t, <-done)
+	}
+	return wait, stop
+}
+
+func TestWatcher_NotifyRename(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "telegraf.conf")
+	require.NoError(t, ioutil.WriteFile(path, []byte("[agent]\n"), 0644))
+
+	wait, stop := watch(t, path)
+	defer stop()
+
+	// Editors save the file by renaming a new file over it, every change
+	// must be detected and not only the first one.
+	for i := 0; i < 2; i++ {
+		tmp := filepath.Join(dir, ".telegraf.conf.swp")
+		require.NoError(t, ioutil.WriteFile(tmp, []byte("[agent]\n  debug = true\n"), 0644))
+		require.NoError(t, os.Rename(tmp, path))
+		require.True(t, wait(5*time.Second), "rename %d not detected", i+1)
+	}
+}
+
+func TestWatcher_NotifySubdirectory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "watch")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	wait, stop := watch(t, dir)
+	defer stop()
+
+	sub := filepath.Join(dir, "inputs")
+	require.NoError(t, os.Mkdir(sub, 0755))
+	require.True(t, wait(5*time.Second), "subdirectory not detected")
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(sub, "cpu.conf"), []byte("[[inputs.cpu]]\n"), 0644))
+	require.True(t, wait(5*time.Second), "file in subdirectory not detected")
+
+	// Other files are ignored.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(sub, "README"), []byte("configs\n"), 0644))
+	require.False(t, wait(500*time.Millisecond))
+}
@@ -0,0 +1,403 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// node is a node of the parsed expression.  Evaluating a node returns one of
+// nil, bool, float64, string, time.Time or time.Duration.
+type node interface {
+	eval(e *env) (interface{}, error)
+}
+
+type env struct {
+	metric telegraf.Metric
+	now    time.Time
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(e *env) (interface{}, error) {
+	return n.value, nil
+}
+
+type measurementNode struct{}
+
+func (n *measurementNode) eval(e *env) (interface{}, error) {
+	return e.metric.Name(), nil
+}
+
+type timeNode struct{}
+
+func (n *timeNode) eval(e *env) (interface{}, error) {
+	return e.metric.Time(), nil
+}
+
+type nowNode struct{}
+
+func (n *nowNode) eval(e *env) (interface{}, error) {
+	return e.now, nil
+}
+
+// lookupNode looks up the value of a field or tag, preferring the field if
+// both are allowed.  The value is nil if the metric has neither.
+type lookupNode struct {
+	key    string
+	fields bool
+	tags   bool
+}
+
+func (n *lookupNode) eval(e *env) (interface{}, error) {
+	if n.fields {
+		if v, ok := e.metric.GetField(n.key); ok {
+			return fieldValue(v), nil
+		}
+	}
+	if n.tags {
+		if v, ok := e.metric.GetTag(n.key); ok {
+			return v, nil
+		}
+	}
+	return nil, nil
+}
+
+func fieldValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case int64:
+		return float64(v)
+	case uint64:
+		return float64(v)
+	case float64, string, bool:
+		return v
+	}
+	return nil
+}
+
+type existsNode struct {
+	lookup *lookupNode
+}
+
+func (n *existsNode) eval(e *env) (interface{}, error) {
+	v, err := n.lookup.eval(e)
+	return v != nil, err
+}
+
+type logicalNode struct {
+	op          string
+	left, right node
+}
+
+func (n *logicalNode) eval(e *env) (interface{}, error) {
+	left, err := evalBool(e, n.left)
+	if err != nil {
+		return nil, err
+	}
+	// Short circuit once the result is known.
+	if n.op == "&&" && !left || n.op == "||" && left {
+		return left, nil
+	}
+	return evalBool(e, n.right)
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) eval(e *env) (interface{}, error) {
+	v, err := evalBool(e, n.operand)
+	return !v, err
+}
+
+// evalBool evaluates a node used as a condition, where a missing value is
+// false.
+func evalBool(e *env, n node) (bool, error) {
+	v, err := n.eval(e)
+	if err != nil {
+		return false, err
+	}
+	switch v := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	}
+	return false, fmt.Errorf("expected boolean, got %s", typeName(v))
+}
+
+type matchNode struct {
+	negate bool
+	left   node
+	re     *regexp.Regexp
+}
+
+func (n *matchNode) eval(e *env) (interface{}, error) {
+	v, err := n.left.eval(e)
+	if err != nil {
+		return nil, err
+	}
+
+	var s string
+	switch v := v.(type) {
+	case nil:
+		// A missing value never matches.
+		return n.negate, nil
+	case string:
+		s = v
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		s = strconv.FormatBool(v)
+	default:
+		return nil, fmt.Errorf("cannot match %s against a regular expression", typeName(v))
+	}
+	return n.re.MatchString(s) != n.negate, nil
+}
+
+type compareNode struct {
+	op          string
+	left, right node
+}
+
+func (n *compareNode) eval(e *env) (interface{}, error) {
+	left, err := n.left.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(e)
+	if err != nil {
+		return nil, err
+	}
+
+	if left == nil || right == nil {
+		// Only equality is defined for missing values.
+		switch n.op {
+		case "==":
+			return left == nil && right == nil, nil
+		case "!=":
+			return left != nil || right != nil, nil
+		}
+		return false, nil
+	}
+
+	// Tags are strings, allow comparing them to numbers.
+	if s, ok := left.(string); ok {
+		if _, ok := right.(float64); ok {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				left = f
+			}
+		}
+	}
+	if s, ok := right.(string); ok {
+		if _, ok := left.(float64); ok {
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				right = f
+			}
+		}
+	}
+
+	c, ok := compare(left, right)
+	if !ok {
+		switch n.op {
+		case "==":
+			return false, nil
+		case "!=":
+			return true, nil
+		}
+		return nil, fmt.Errorf("cannot compare %s and %s", typeName(left), typeName(right))
+	}
+
+	switch n.op {
+	case "==":
+		return c == 0, nil
+	case "!=":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	case ">=":
+		return c >= 0, nil
+	}
+	return nil, fmt.Errorf("unknown operator %q", n.op)
+}
+
+// compare returns -1, 0 or 1 if a is less, equal or greater than b.  It
+// returns false if the values are not comparable.  Booleans only compare
+// equal or not equal.
+func compare(a, b interface{}) (int, bool) {
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			return compareFloat(a, b), true
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			switch {
+			case a < b:
+				return -1, true
+			case a > b:
+				return 1, true
+			}
+			return 0, true
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			switch {
+			case a.Before(b):
+				return -1, true
+			case a.After(b):
+				return 1, true
+			}
+			return 0, true
+		}
+	case time.Duration:
+		if b, ok := b.(time.Duration); ok {
+			return compareFloat(float64(a), float64(b)), true
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			if a == b {
+				return 0, true
+			}
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+type arithmeticNode struct {
+	op          string
+	left, right node
+}
+
+func (n *arithmeticNode) eval(e *env) (interface{}, error) {
+	left, err := n.left.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	right, err := n.right.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	switch a := left.(type) {
+	case float64:
+		switch b := right.(type) {
+		case float64:
+			switch n.op {
+			case "+":
+				return a + b, nil
+			case "-":
+				return a - b, nil
+			case "*":
+				return a * b, nil
+			case "/":
+				return a / b, nil
+			}
+		case time.Duration:
+			if n.op == "*" {
+				return time.Duration(a * float64(b)), nil
+			}
+		}
+	case string:
+		if b, ok := right.(string); ok && n.op == "+" {
+			return a + b, nil
+		}
+	case time.Time:
+		switch b := right.(type) {
+		case time.Duration:
+			switch n.op {
+			case "+":
+				return a.Add(b), nil
+			case "-":
+				return a.Add(-b), nil
+			}
+		case time.Time:
+			if n.op == "-" {
+				return a.Sub(b), nil
+			}
+		}
+	case time.Duration:
+		switch b := right.(type) {
+		case time.Duration:
+			switch n.op {
+			case "+":
+				return a + b, nil
+			case "-":
+				return a - b, nil
+			}
+		case float64:
+			switch n.op {
+			case "*":
+				return time.Duration(float64(a) * b), nil
+			case "/":
+				return time.Duration(float64(a) / b), nil
+			}
+		case time.Time:
+			if n.op == "+" {
+				return b.Add(a), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("invalid operation %s %s %s", typeName(left), n.op, typeName(right))
+}
+
+type negateNode struct {
+	operand node
+}
+
+func (n *negateNode) eval(e *env) (interface{}, error) {
+	v, err := n.operand.eval(e)
+	if err != nil {
+		return nil, err
+	}
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case float64:
+		return -v, nil
+	case time.Duration:
+		return -v, nil
+	}
+	return nil, fmt.Errorf("cannot negate %s", typeName(v))
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case time.Time:
+		return "time"
+	case time.Duration:
+		return "duration"
+	}
+	return fmt.Sprintf("%T", v)
+}
@@ -0,0 +1,59 @@
+// Package expr implements the expressions used to select metrics with the
+// metricpass filter.
+//
+// An expression is a condition over the measurement name, tags, fields and
+// time of a metric, for example:
+//
+//	usage_idle < 10 and (host =~ '^web-' or tags.env == "prod")
+//
+// Identifiers refer to the field with that name, or to the tag if there is no
+// such field.  Use tags.key or fields.key, or tags["key"] and fields["key"]
+// for keys that are not valid identifiers, to only look at one of them.  The
+// measurement and time identifiers refer to the measurement name and the
+// time of the metric.
+//
+// Values are numbers, strings, booleans, times and durations, such as 10m or
+// 1h30m.  A tag or field that does not exist is null: it does not compare
+// with any value except null and is false when used as a condition.  The
+// exists(key) function returns if a tag or field exists and now() returns the
+// current time.
+//
+// Operators, from lowest to highest precedence, are `or` or `||`, `and` or
+// `&&`, `not` or `!`, the comparisons `==`, `!=`, `<`, `<=`, `>`, `>=`, the
+// regular expression matches `=~` and `!~` which require a string on the
+// right side, `+` and `-`, and `*` and `/`.
+package expr
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Expression is a compiled expression.
+type Expression struct {
+	src  string
+	root node
+}
+
+// Compile parses the expression.
+func Compile(src string) (*Expression, error) {
+	root, err := parse(src)
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{src: src, root: root}, nil
+}
+
+// Select returns true if the expression is true for the metric.  An error is
+// returned if the expression cannot be evaluated for the metric, such as when
+// comparing a string field to a number, or if it does not evaluate to a
+// boolean.
+func (e *Expression) Select(metric telegraf.Metric) (bool, error) {
+	return evalBool(&env{metric: metric, now: time.Now()}, e.root)
+}
+
+// String returns the source of the expression.
+func (e *Expression) String() string {
+	return e.src
+}
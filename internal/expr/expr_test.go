@@ -0,0 +1,135 @@
+package expr
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/require"
+)
+
+func testMetric(t *testing.T, tm time.Time) telegraf.Metric {
+	m, err := metric.New("cpu",
+		map[string]string{
+			"host": "web-01",
+			"cpu":  "cpu0",
+			"code": "200",
+			"a-b":  "dash",
+		},
+		map[string]interface{}{
+			"usage_idle": 5.5,
+			"usage_user": int64(91),
+			"count":      uint64(3),
+			"state":      "running",
+			"ok":         true,
+			"cpu":        "field",
+		},
+		tm)
+	require.NoError(t, err)
+	return m
+}
+
+func TestSelect(t *testing.T) {
+	now := time.Now()
+	m := testMetric(t, now.Add(-5*time.Minute))
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`usage_user > 90`, true},
+		{`usage_user > 90 AND host =~ 'web-.*'`, true},
+		{`usage_user > 90 and host =~ '^db-'`, false},
+		{`usage_user > 95 || host == "web-01"`, true},
+		{`not (usage_idle < 10)`, false},
+		{`!ok`, false},
+		{`ok && count == 3`, true},
+		{`usage_idle >= 5.5 && usage_idle <= 5.5`, true},
+		{`usage_user - usage_idle * 2 == 80`, true},
+		{`-usage_idle < 0`, true},
+		{`measurement == "cpu"`, true},
+		{`measurement != 'mem'`, true},
+		{`cpu == "field"`, true},
+		{`tags.cpu == "cpu0"`, true},
+		{`fields.cpu == "field"`, true},
+		{`fields.host == null`, true},
+		{`tags["a-b"] == 'dash'`, true},
+		{`tags.code == 200`, true},
+		{`tags.code >= 500`, false},
+		{`missing > 1`, false},
+		{`missing != 1`, true},
+		{`missing == null`, true},
+		{`missing =~ '.*'`, false},
+		{`missing !~ '.*'`, true},
+		{`missing`, false},
+		{`exists(missing)`, false},
+		{`exists(tags.host) and not exists(fields.host)`, true},
+		{`state !~ "^stop"`, true},
+		{`state == 3`, false},
+		{`state != 3`, true},
+		{`usage_user =~ '^9'`, true},
+		{`time > now() - 10m`, true},
+		{`time < now() - 1h30m`, false},
+		{`now() - time >= 5m`, true},
+		{`now() - time < 2 * 5m`, true},
+		{`host + "." + cpu == "web-01.field"`, true},
+		{`state == 'it\'s'`, false},
+		{`1e2 == 100`, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			e, err := Compile(tt.expr)
+			require.NoError(t, err)
+			ok, err := e.Select(m)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, ok)
+		})
+	}
+}
+
+func TestSelect_Errors(t *testing.T) {
+	m := testMetric(t, time.Now())
+
+	for _, src := range []string{
+		`state > 3`,
+		`host`,
+		`usage_idle`,
+		`state - 1 == 0`,
+		`time > 5`,
+		`not state`,
+	} {
+		t.Run(src, func(t *testing.T) {
+			e, err := Compile(src)
+			require.NoError(t, err)
+			_, err = e.Select(m)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestCompile_Errors(t *testing.T) {
+	for _, src := range []string{
+		``,
+		`usage_idle >`,
+		`(usage_idle > 1`,
+		`usage_idle > 1)`,
+		`host =~ host`,
+		`host =~ '('`,
+		`host == 'unterminated`,
+		`5xyz > 1`,
+		`unknown(host)`,
+		`now(1)`,
+		`exists(1)`,
+		`tags.`,
+		`tags[1]`,
+		`host # 1`,
+		`and`,
+	} {
+		t.Run(src, func(t *testing.T) {
+			_, err := Compile(src)
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenDuration
+	tokenOperator
+)
+
+type token struct {
+	kind tokenKind
+	pos  int
+	text string
+
+	// str, num and dur hold the value of string, number and duration
+	// literals.
+	str string
+	num float64
+	dur time.Duration
+}
+
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// operators lists the operators, with the longer ones first so that they are
+// matched before their prefixes.
+var operators = []string{
+	"==", "!=", "<=", ">=", "=~", "!~", "&&", "||",
+	"<", ">", "!", "+", "-", "*", "/", "(", ")", "[", "]", ".", ",",
+}
+
+// lex splits the expression into tokens.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	i := 0
+	for i < len(src) {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '_' || unicode.IsLetter(r):
+			j := i + size
+			for j < len(src) {
+				r, size := utf8.DecodeRuneInString(src[j:])
+				if r != '_' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+					break
+				}
+				j += size
+			}
+			tokens = append(tokens, token{kind: tokenIdent, pos: i, text: src[i:j]})
+			i = j
+		case r >= '0' && r <= '9':
+			tok, err := lexNumber(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i += len(tok.text)
+		case r == '"' || r == '\'':
+			tok, err := lexString(src, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, tok)
+			i += len(tok.text)
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(src[i:], o) {
+					op = o
+					break
+				}
+			}
+			if op == "" {
+				return nil, fmt.Errorf("unexpected character %q at position %d", r, i)
+			}
+			tokens = append(tokens, token{kind: tokenOperator, pos: i, text: op})
+			i += len(op)
+		}
+	}
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+// lexNumber lexes a number, or a duration if the number is followed by a
+// unit, starting at position i.
+func lexNumber(src string, i int) (token, error) {
+	j := i
+	for j < len(src) && isNumberChar(src, j) {
+		j++
+	}
+	num := src[i:j]
+
+	k := j
+	for k < len(src) && (isLetter(src[k]) || src[k] == 0xc2 || src[k] == 0xb5) {
+		k++
+	}
+	if k > j {
+		// A duration may consist of several number and unit pairs.
+		for k < len(src) && isNumberChar(src, k) {
+			k++
+			for k < len(src) && (isLetter(src[k]) || src[k] == 0xc2 || src[k] == 0xb5) {
+				k++
+			}
+		}
+		d, err := time.ParseDuration(src[i:k])
+		if err != nil {
+			return token{}, fmt.Errorf("invalid duration %q at position %d", src[i:k], i)
+		}
+		return token{kind: tokenDuration, pos: i, text: src[i:k], dur: d}, nil
+	}
+
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return token{}, fmt.Errorf("invalid number %q at position %d", num, i)
+	}
+	return token{kind: tokenNumber, pos: i, text: num, num: n}, nil
+}
+
+func isNumberChar(src string, i int) bool {
+	c := src[i]
+	switch {
+	case c >= '0' && c <= '9', c == '.':
+		return true
+	case (c == 'e' || c == 'E') && i+1 < len(src):
+		// An exponent, but not the start of a unit.
+		n := src[i+1]
+		return n >= '0' && n <= '9' || n == '+' || n == '-'
+	case (c == '+' || c == '-') && i > 0:
+		return src[i-1] == 'e' || src[i-1] == 'E'
+	}
+	return false
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// lexString lexes a single or double quoted string starting at position i.
+// Backslash escapes the quote character and the backslash itself, all other
+// characters are taken as is so that regular expressions need no additional
+// escaping.
+func lexString(src string, i int) (token, error) {
+	quote := src[i]
+	var buf []byte
+	for j := i + 1; j < len(src); j++ {
+		c := src[j]
+		switch {
+		case c == '\\' && j+1 < len(src) && (src[j+1] == quote || src[j+1] == '\\'):
+			buf = append(buf, src[j+1])
+			j++
+		case c == quote:
+			return token{kind: tokenString, pos: i, text: src[i : j+1], str: string(buf)}, nil
+		default:
+			buf = append(buf, c)
+		}
+	}
+	return token{}, fmt.Errorf("unterminated string at position %d", i)
+}
@@ -0,0 +1,331 @@
+package expr
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// parser is a recursive descent parser over the tokens of an expression.
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is one of the operators or keywords,
+// returning the normalized operator.
+func (p *parser) accept(ops ...string) (string, bool) {
+	tok := p.peek()
+	for _, op := range ops {
+		switch tok.kind {
+		case tokenOperator:
+			if tok.text == op {
+				p.next()
+				return op, true
+			}
+		case tokenIdent:
+			if keyword, ok := keywords[strings.ToLower(tok.text)]; ok && keyword == op {
+				p.next()
+				return op, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (p *parser) expect(op string) error {
+	if _, ok := p.accept(op); !ok {
+		return p.unexpected()
+	}
+	return nil
+}
+
+func (p *parser) unexpected() error {
+	tok := p.peek()
+	return fmt.Errorf("unexpected %s at position %d", tok, tok.pos)
+}
+
+// keywords maps the keyword operators to the equivalent symbolic operator.
+var keywords = map[string]string{
+	"and": "&&",
+	"or":  "||",
+	"not": "!",
+}
+
+func parse(src string) (node, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenEOF {
+		return nil, p.unexpected()
+	}
+	return n, nil
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("||"); !ok {
+			return left, nil
+		}
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "||", left: left, right: right}
+	}
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("&&"); !ok {
+			return left, nil
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &logicalNode{op: "&&", left: left, right: right}
+	}
+}
+
+func (p *parser) parseNot() (node, error) {
+	if _, ok := p.accept("!"); ok {
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+
+	if op, ok := p.accept("=~", "!~"); ok {
+		tok := p.next()
+		if tok.kind != tokenString {
+			return nil, fmt.Errorf("expected regular expression string at position %d", tok.pos)
+		}
+		re, err := regexp.Compile(tok.str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression at position %d: %v", tok.pos, err)
+		}
+		return &matchNode{negate: op == "!~", left: left, re: re}, nil
+	}
+
+	if op, ok := p.accept("==", "!=", "<=", ">=", "<", ">"); ok {
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &compareNode{op: op, left: left, right: right}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (node, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("+", "-")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseMultiplicative() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept("*", "/")
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &arithmeticNode{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &negateNode{operand: operand}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokenNumber:
+		p.next()
+		return &literalNode{value: tok.num}, nil
+	case tokenDuration:
+		p.next()
+		return &literalNode{value: tok.dur}, nil
+	case tokenString:
+		p.next()
+		return &literalNode{value: tok.str}, nil
+	case tokenOperator:
+		if tok.text != "(" {
+			return nil, p.unexpected()
+		}
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case tokenIdent:
+		return p.parseIdent()
+	}
+	return nil, p.unexpected()
+}
+
+func (p *parser) parseIdent() (node, error) {
+	tok := p.next()
+	name := tok.text
+
+	switch strings.ToLower(name) {
+	case "true":
+		return &literalNode{value: true}, nil
+	case "false":
+		return &literalNode{value: false}, nil
+	case "null":
+		return &literalNode{value: nil}, nil
+	case "and", "or", "not":
+		return nil, fmt.Errorf("unexpected %s at position %d", tok, tok.pos)
+	}
+
+	if _, ok := p.accept("("); ok {
+		return p.parseCall(tok)
+	}
+
+	switch name {
+	case "measurement":
+		return &measurementNode{}, nil
+	case "time":
+		return &timeNode{}, nil
+	case "tags", "fields":
+		key, ok, err := p.parseSelector()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return &lookupNode{key: key, tags: name == "tags", fields: name == "fields"}, nil
+		}
+	}
+	return &lookupNode{key: name, tags: true, fields: true}, nil
+}
+
+// parseSelector parses the key following the tags and fields identifiers,
+// either as .key or ["key"].
+func (p *parser) parseSelector() (string, bool, error) {
+	if _, ok := p.accept("."); ok {
+		tok := p.next()
+		if tok.kind != tokenIdent {
+			return "", false, fmt.Errorf("expected key at position %d", tok.pos)
+		}
+		return tok.text, true, nil
+	}
+
+	if _, ok := p.accept("["); ok {
+		tok := p.next()
+		if tok.kind != tokenString {
+			return "", false, fmt.Errorf("expected key string at position %d", tok.pos)
+		}
+		if err := p.expect("]"); err != nil {
+			return "", false, err
+		}
+		return tok.str, true, nil
+	}
+	return "", false, nil
+}
+
+func (p *parser) parseCall(fn token) (node, error) {
+	var args []node
+	if _, ok := p.accept(")"); !ok {
+		for {
+			arg, err := p.parseOr()
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+			if _, ok := p.accept(","); ok {
+				continue
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			break
+		}
+	}
+
+	switch fn.text {
+	case "now":
+		if len(args) != 0 {
+			return nil, fmt.Errorf("now() takes no arguments")
+		}
+		return &nowNode{}, nil
+	case "exists":
+		if len(args) != 1 {
+			return nil, fmt.Errorf("exists() takes one argument")
+		}
+		lookup, ok := args[0].(*lookupNode)
+		if !ok {
+			return nil, fmt.Errorf("exists() argument must be a tag or field")
+		}
+		return &existsNode{lookup: lookup}, nil
+	}
+	return nil, fmt.Errorf("unknown function %q at position %d", fn.text, fn.pos)
+}
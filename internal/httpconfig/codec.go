@@ -0,0 +1,110 @@
+package httpconfig
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Codec encodes request bodies with a content encoding.
+type Codec interface {
+	Encode(data []byte) ([]byte, error)
+}
+
+// CodecFunc is a function used as a Codec.
+type CodecFunc func(data []byte) ([]byte, error)
+
+// Encode encodes the data, see Codec.
+func (f CodecFunc) Encode(data []byte) ([]byte, error) {
+	return f(data)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		"gzip":   CodecFunc(gzipEncode),
+		"snappy": CodecFunc(snappyEncode),
+		"zstd":   CodecFunc(zstdEncode),
+	}
+
+	// zstdEncoder is created on first use and shared by all requests,
+	// EncodeAll may be called concurrently.
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdErr     error
+)
+
+// AddCodec registers the codec of a content encoding so that it can be
+// selected with the content_encoding option, replacing any codec registered
+// with the same name.  It is meant to be called from init functions.
+func AddCodec(encoding string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[encoding] = codec
+}
+
+// Encodings returns the names of the registered content encodings.
+func Encodings() []string {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	encodings := make([]string, 0, len(codecs))
+	for encoding := range codecs {
+		encodings = append(encodings, encoding)
+	}
+	sort.Strings(encodings)
+	return encodings
+}
+
+// encoder encodes request bodies with a content encoding.
+type encoder struct {
+	name  string
+	codec Codec
+}
+
+// newEncoder returns the encoder for the content encoding, or nil if bodies
+// are sent as is.
+func newEncoder(encoding string) (*encoder, error) {
+	if encoding == "" || encoding == "identity" {
+		return nil, nil
+	}
+
+	codecsMu.RLock()
+	codec, ok := codecs[encoding]
+	codecsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported content_encoding %q, expected identity or one of %v",
+			encoding, Encodings())
+	}
+	return &encoder{name: encoding, codec: codec}, nil
+}
+
+func gzipEncode(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func snappyEncode(data []byte) ([]byte, error) {
+	return snappy.Encode(nil, data), nil
+}
+
+func zstdEncode(data []byte) ([]byte, error) {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil)
+	})
+	if zstdErr != nil {
+		return nil, zstdErr
+	}
+	return zstdEncoder.EncodeAll(data, nil), nil
+}
@@ -0,0 +1,223 @@
+// Package httpconfig provides the standard HTTP client config of plugins
+// using HTTP, so that they share the same options and behavior.
+package httpconfig
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/secret"
+	"github.com/influxdata/telegraf/internal/tls"
+	"golang.org/x/net/http2"
+)
+
+const (
+	defaultTimeout         = 5 * time.Second
+	defaultIdleConnTimeout = 90 * time.Second
+	defaultMaxIdleConns    = 100
+)
+
+// HTTPClientConfig represents the standard HTTP client config.
+type HTTPClientConfig struct {
+	Timeout             internal.Duration `toml:"timeout"`
+	IdleConnTimeout     internal.Duration `toml:"idle_conn_timeout"`
+	MaxIdleConns        int               `toml:"max_idle_conns"`
+	MaxIdleConnsPerHost int               `toml:"max_idle_conns_per_host"`
+
+	// MaxConnectionAge closes the connections once they are older, between
+	// requests, so that their replacements resolve the host again and follow
+	// the changes of its DNS records.  0 keeps the connections until they
+	// are idle for IdleConnTimeout.
+	MaxConnectionAge internal.Duration `toml:"max_connection_age"`
+
+	// EnableHTTP2 negotiates HTTP/2 with the TLS servers supporting it, so
+	// that parallel requests share a single connection.
+	EnableHTTP2 bool `toml:"enable_http2"`
+
+	// HTTPProxy is the proxy used for all requests.  With UseSystemProxy
+	// the proxy is taken from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY
+	// environment variables instead.  If neither is set, requests are sent
+	// directly.
+	HTTPProxy      string `toml:"http_proxy"`
+	UseSystemProxy bool   `toml:"use_system_proxy"`
+
+	// ContentEncoding is the encoding of request bodies, identity or one of
+	// the encodings registered with AddCodec, by default gzip, snappy and zstd.
+	ContentEncoding string `toml:"content_encoding"`
+
+	tls.ClientConfig
+}
+
+// AuthConfig represents the standard HTTP authentication config.  At most one
+// of basic authentication with the username and password, the bearer token or
+// the OAuth2 client credentials grant with the client id, client secret and
+// token URL may be set.
+type AuthConfig struct {
+	Username    secret.Secret `toml:"username"`
+	Password    secret.Secret `toml:"password"`
+	BearerToken secret.Secret `toml:"bearer_token"`
+
+	ClientID     string        `toml:"client_id"`
+	ClientSecret secret.Secret `toml:"client_secret"`
+	TokenURL     string        `toml:"token_url"`
+	Scopes       []string      `toml:"scopes"`
+}
+
+// CreateClient returns a client for the config.  Requests are authenticated
+// with auth, if not nil, unless they already have an Authorization header, and
+// their body encoded with the content encoding.
+func (c *HTTPClientConfig) CreateClient(auth *AuthConfig) (*http.Client, error) {
+	tlsCfg, err := c.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	proxy, err := c.proxy()
+	if err != nil {
+		return nil, err
+	}
+
+	encoder, err := newEncoder(c.ContentEncoding)
+	if err != nil {
+		return nil, err
+	}
+
+	if auth != nil && auth.isEmpty() {
+		auth = nil
+	}
+	if auth != nil {
+		if err := auth.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	timeout := c.Timeout.Duration
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	idleConnTimeout := c.IdleConnTimeout.Duration
+	if idleConnTimeout == 0 {
+		idleConnTimeout = defaultIdleConnTimeout
+	}
+	maxIdleConns := c.MaxIdleConns
+	if maxIdleConns == 0 {
+		maxIdleConns = defaultMaxIdleConns
+	}
+
+	dial := (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	var tracker *connTracker
+	if c.MaxConnectionAge.Duration > 0 {
+		tracker = newConnTracker(c.MaxConnectionAge.Duration, dial)
+		dial = tracker.DialContext
+	}
+
+	base := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dial,
+		TLSClientConfig:       tlsCfg,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+		IdleConnTimeout:       idleConnTimeout,
+		MaxIdleConns:          maxIdleConns,
+		MaxIdleConnsPerHost:   c.MaxIdleConnsPerHost,
+	}
+	if c.EnableHTTP2 {
+		if err := http2.ConfigureTransport(base); err != nil {
+			return nil, err
+		}
+	}
+
+	var rt http.RoundTripper = base
+	if tracker != nil {
+		rt = &maxAgeTransport{base: base, tracker: tracker}
+	}
+	if auth != nil || encoder != nil {
+		t := &transport{base: rt, auth: auth, encoder: encoder}
+		if auth != nil && auth.isOAuth2() {
+			t.tokens = newTokenSource(auth, &http.Client{
+				Transport: rt,
+				Timeout:   timeout,
+			})
+		}
+		rt = t
+	}
+
+	return &http.Client{
+		Transport: rt,
+		Timeout:   timeout,
+	}, nil
+}
+
+func (c *HTTPClientConfig) proxy() (func(*http.Request) (*url.URL, error), error) {
+	if c.UseSystemProxy {
+		if c.HTTPProxy != "" {
+			return nil, fmt.Errorf("only one of http_proxy and use_system_proxy can be set")
+		}
+		return http.ProxyFromEnvironment, nil
+	}
+	if c.HTTPProxy == "" {
+		return nil, nil
+	}
+
+	u, err := url.Parse(c.HTTPProxy)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing http_proxy %q: %v", c.HTTPProxy, err)
+	}
+	return http.ProxyURL(u), nil
+}
+
+func (a *AuthConfig) isEmpty() bool {
+	return a.Username.IsEmpty() && a.Password.IsEmpty() && a.BearerToken.IsEmpty() &&
+		!a.isOAuth2()
+}
+
+func (a *AuthConfig) isOAuth2() bool {
+	return a.ClientID != "" || !a.ClientSecret.IsEmpty() || a.TokenURL != ""
+}
+
+func (a *AuthConfig) validate() error {
+	var methods int
+	for _, set := range []bool{!a.Username.IsEmpty(), !a.BearerToken.IsEmpty(), a.isOAuth2()} {
+		if set {
+			methods++
+		}
+	}
+	if methods > 1 {
+		return fmt.Errorf("only one of username, bearer_token and client_id can be set")
+	}
+	if a.isOAuth2() && (a.ClientID == "" || a.ClientSecret.IsEmpty() || a.TokenURL == "") {
+		return fmt.Errorf("client_id, client_secret and token_url are required for OAuth2")
+	}
+	return nil
+}
+
+// authorize sets the Authorization header of the request.  The secrets are
+// read for every request so that rotated credentials are used.
+func (a *AuthConfig) authorize(req *http.Request) error {
+	if !a.BearerToken.IsEmpty() {
+		token, err := a.BearerToken.Get()
+		if err != nil {
+			return fmt.Errorf("unable to read bearer_token: %v", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+
+	username, err := a.Username.Get()
+	if err != nil {
+		return fmt.Errorf("unable to read username: %v", err)
+	}
+	password, err := a.Password.Get()
+	if err != nil {
+		return fmt.Errorf("unable to read password: %v", err)
+	}
+	req.SetBasicAuth(username, password)
+	return nil
+}
@@ -0,0 +1,368 @@
+package httpconfig
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/secret"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+// request is a request received by the test server.
+type request struct {
+	header http.Header
+	body   []byte
+}
+
+func newServer(t *testing.T) (*httptest.Server, <-chan request) {
+	requests := make(chan request, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests <- request{header: r.Header, body: body}
+		w.WriteHeader(http.StatusOK)
+	}))
+	return ts, requests
+}
+
+func post(t *testing.T, client *http.Client, url string, header http.Header) {
+	req, err := http.NewRequest("POST", url, bytes.NewBufferString("metrics"))
+	require.NoError(t, err)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestCreateClient_Defaults(t *testing.T) {
+	c := &HTTPClientConfig{}
+	client, err := c.CreateClient(nil)
+	require.NoError(t, err)
+	require.Equal(t, defaultTimeout, client.Timeout)
+
+	tr, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	require.Equal(t, defaultIdleConnTimeout, tr.IdleConnTimeout)
+	require.Equal(t, defaultMaxIdleConns, tr.MaxIdleConns)
+	require.Nil(t, tr.TLSClientConfig)
+	require.Nil(t, tr.Proxy)
+}
+
+func TestCreateClient_Options(t *testing.T) {
+	c := &HTTPClientConfig{
+		Timeout:             internal.Duration{Duration: time.Second},
+		IdleConnTimeout:     internal.Duration{Duration: time.Minute},
+		MaxIdleConns:        10,
+		MaxIdleConnsPerHost: 5,
+		HTTPProxy:           "http://proxy.example.com:3128",
+	}
+	client, err := c.CreateClient(nil)
+	require.NoError(t, err)
+	require.Equal(t, time.Second, client.Timeout)
+
+	tr, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	require.Equal(t, time.Minute, tr.IdleConnTimeout)
+	require.Equal(t, 10, tr.MaxIdleConns)
+	require.Equal(t, 5, tr.MaxIdleConnsPerHost)
+
+	req, err := http.NewRequest("GET", "http://example.com", nil)
+	require.NoError(t, err)
+	proxy, err := tr.Proxy(req)
+	require.NoError(t, err)
+	require.Equal(t, "http://proxy.example.com:3128", proxy.String())
+}
+
+func TestCreateClient_HTTP2(t *testing.T) {
+	c := &HTTPClientConfig{EnableHTTP2: true}
+	client, err := c.CreateClient(nil)
+	require.NoError(t, err)
+
+	tr, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	require.NotNil(t, tr.TLSClientConfig)
+	require.Contains(t, tr.TLSClientConfig.NextProtos, "h2")
+}
+
+func TestClient_MaxConnectionAge(t *testing.T) {
+	addrs := make(chan string, 3)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		addrs <- r.RemoteAddr
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	c := &HTTPClientConfig{MaxConnectionAge: internal.Duration{Duration: 100 * time.Millisecond}}
+	client, err := c.CreateClient(nil)
+	require.NoError(t, err)
+
+	post(t, client, ts.URL, nil)
+	first := <-addrs
+	post(t, client, ts.URL, nil)
+	require.Equal(t, first, <-addrs)
+
+	// The connection is replaced once older than the max age.
+	time.Sleep(150 * time.Millisecond)
+	post(t, client, ts.URL, nil)
+	require.NotEqual(t, first, <-addrs)
+}
+
+func TestCreateClient_SystemProxy(t *testing.T) {
+	c := &HTTPClientConfig{UseSystemProxy: true}
+	client, err := c.CreateClient(nil)
+	require.NoError(t, err)
+
+	tr, ok := client.Transport.(*http.Transport)
+	require.True(t, ok)
+	require.NotNil(t, tr.Proxy)
+}
+
+func TestCreateClient_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config HTTPClientConfig
+		auth   *AuthConfig
+	}{
+		{
+			name:   "invalid proxy",
+			config: HTTPClientConfig{HTTPProxy: "http://[::1"},
+		},
+		{
+			name: "proxy url and system proxy",
+			config: HTTPClientConfig{
+				HTTPProxy:      "http://proxy.example.com:3128",
+				UseSystemProxy: true,
+			},
+		},
+		{
+			name:   "invalid content encoding",
+			config: HTTPClientConfig{ContentEncoding: "deflate"},
+		},
+		{
+			name: "username and client id",
+			auth: &AuthConfig{
+				Username:     secret.NewSecret("user"),
+				ClientID:     "client",
+				ClientSecret: secret.NewSecret("secret"),
+				TokenURL:     "http://localhost/token",
+			},
+		},
+		{
+			name: "missing token url",
+			auth: &AuthConfig{
+				ClientID:     "client",
+				ClientSecret: secret.NewSecret("secret"),
+			},
+		},
+		{
+			name: "username and bearer token",
+			auth: &AuthConfig{
+				Username:    secret.NewSecret("user"),
+				BearerToken: secret.NewSecret("token"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.config.CreateClient(tt.auth)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestClient_BasicAuth(t *testing.T) {
+	ts, requests := newServer(t)
+	defer ts.Close()
+
+	c := &HTTPClientConfig{}
+	client, err := c.CreateClient(&AuthConfig{
+		Username: secret.NewSecret("user"),
+		Password: secret.NewSecret("pass"),
+	})
+	require.NoError(t, err)
+
+	post(t, client, ts.URL, nil)
+	r := <-requests
+	req := &http.Request{Header: r.header}
+	username, password, ok := req.BasicAuth()
+	require.True(t, ok)
+	require.Equal(t, "user", username)
+	require.Equal(t, "pass", password)
+	require.Equal(t, "metrics", string(r.body))
+}
+
+func TestClient_BearerToken(t *testing.T) {
+	ts, requests := newServer(t)
+	defer ts.Close()
+
+	c := &HTTPClientConfig{}
+	client, err := c.CreateClient(&AuthConfig{
+		BearerToken: secret.NewSecret("token"),
+	})
+	require.NoError(t, err)
+
+	post(t, client, ts.URL, nil)
+	r := <-requests
+	require.Equal(t, "Bearer token", r.header.Get("Authorization"))
+
+	// An Authorization header set by the plugin is kept.
+	post(t, client, ts.URL, http.Header{"Authorization": []string{"Custom"}})
+	r = <-requests
+	require.Equal(t, "Custom", r.header.Get("Authorization"))
+}
+
+func TestClient_OAuth2(t *testing.T) {
+	ts, requests := newServer(t)
+	defer ts.Close()
+
+	var tokens int
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseForm())
+		require.Equal(t, "client_credentials", r.PostForm.Get("grant_type"))
+		require.Equal(t, "metrics:write", r.PostForm.Get("scope"))
+		clientID, clientSecret, ok := r.BasicAuth()
+		require.True(t, ok)
+		require.Equal(t, "client", clientID)
+		require.Equal(t, "secret", clientSecret)
+
+		tokens++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token%d", "token_type": "bearer", "expires_in": 3600}`, tokens)
+	}))
+	defer tokenServer.Close()
+
+	c := &HTTPClientConfig{}
+	client, err := c.CreateClient(&AuthConfig{
+		ClientID:     "client",
+		ClientSecret: secret.NewSecret("secret"),
+		TokenURL:     tokenServer.URL,
+		Scopes:       []string{"metrics:write"},
+	})
+	require.NoError(t, err)
+
+	// The token is reused until it expires.
+	for i := 0; i < 2; i++ {
+		post(t, client, ts.URL, nil)
+		r := <-requests
+		require.Equal(t, "Bearer token1", r.header.Get("Authorization"))
+	}
+	require.Equal(t, 1, tokens)
+}
+
+func TestClient_OAuth2RejectedToken(t *testing.T) {
+	var authorizations []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		if r.Header.Get("Authorization") == "Bearer token1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer ts.Close()
+
+	var tokens int
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tokens++
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token%d", "token_type": "bearer", "expires_in": 3600}`, tokens)
+	}))
+	defer tokenServer.Close()
+
+	c := &HTTPClientConfig{}
+	client, err := c.CreateClient(&AuthConfig{
+		ClientID:     "client",
+		ClientSecret: secret.NewSecret("secret"),
+		TokenURL:     tokenServer.URL,
+	})
+	require.NoError(t, err)
+
+	// The revoked token is dropped once rejected, the next request gets a
+	// new token.
+	resp, err := client.Get(ts.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	post(t, client, ts.URL, nil)
+	post(t, client, ts.URL, nil)
+	require.Equal(t, []string{"Bearer token1", "Bearer token2", "Bearer token2"}, authorizations)
+	require.Equal(t, 2, tokens)
+}
+
+func TestClient_ContentEncoding(t *testing.T) {
+	ts, requests := newServer(t)
+	defer ts.Close()
+
+	c := &HTTPClientConfig{ContentEncoding: "gzip"}
+	client, err := c.CreateClient(nil)
+	require.NoError(t, err)
+
+	post(t, client, ts.URL, nil)
+	r := <-requests
+	require.Equal(t, "gzip", r.header.Get("Content-Encoding"))
+	gz, err := gzip.NewReader(bytes.NewReader(r.body))
+	require.NoError(t, err)
+	body, err := ioutil.ReadAll(gz)
+	require.NoError(t, err)
+	require.Equal(t, "metrics", string(body))
+
+	c = &HTTPClientConfig{ContentEncoding: "snappy"}
+	client, err = c.CreateClient(nil)
+	require.NoError(t, err)
+
+	post(t, client, ts.URL, nil)
+	r = <-requests
+	require.Equal(t, "snappy", r.header.Get("Content-Encoding"))
+	body, err = snappy.Decode(nil, r.body)
+	require.NoError(t, err)
+	require.Equal(t, "metrics", string(body))
+
+	c = &HTTPClientConfig{ContentEncoding: "zstd"}
+	client, err = c.CreateClient(nil)
+	require.NoError(t, err)
+
+	post(t, client, ts.URL, nil)
+	r = <-requests
+	require.Equal(t, "zstd", r.header.Get("Content-Encoding"))
+	dec, err := zstd.NewReader(nil)
+	require.NoError(t, err)
+	defer dec.Close()
+	body, err = dec.DecodeAll(r.body, nil)
+	require.NoError(t, err)
+	require.Equal(t, "metrics", string(body))
+}
+
+func TestClient_AddCodec(t *testing.T) {
+	ts, requests := newServer(t)
+	defer ts.Close()
+
+	AddCodec("reverse", CodecFunc(func(data []byte) ([]byte, error) {
+		encoded := make([]byte, len(data))
+		for i, b := range data {
+			encoded[len(data)-1-i] = b
+		}
+		return encoded, nil
+	}))
+	require.Contains(t, Encodings(), "reverse")
+
+	c := &HTTPClientConfig{ContentEncoding: "reverse"}
+	client, err := c.CreateClient(nil)
+	require.NoError(t, err)
+
+	post(t, client, ts.URL, nil)
+	r := <-requests
+	require.Equal(t, "reverse", r.header.Get("Content-Encoding"))
+	require.Equal(t, "scirtem", string(r.body))
+}
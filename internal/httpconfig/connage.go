@@ -0,0 +1,132 @@
+package httpconfig
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// dialFunc is the signature of net.Dialer.DialContext.
+type dialFunc func(ctx context.Context, network, address string) (net.Conn, error)
+
+// connTracker keeps the connections dialed with the time they were dialed,
+// so that those older than maxAge can be closed and the host resolved again
+// for their replacements.
+type connTracker struct {
+	maxAge time.Duration
+	dial   dialFunc
+
+	mu       sync.Mutex
+	conns    map[*trackedConn]time.Time
+	inFlight int
+}
+
+func newConnTracker(maxAge time.Duration, dial dialFunc) *connTracker {
+	return &connTracker{
+		maxAge: maxAge,
+		dial:   dial,
+		conns:  make(map[*trackedConn]time.Time),
+	}
+}
+
+// DialContext dials the address and tracks the connection until it is
+// closed.
+func (t *connTracker) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	conn, err := t.dial(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	c := &trackedConn{Conn: conn, tracker: t}
+	t.mu.Lock()
+	t.conns[c] = time.Now()
+	t.mu.Unlock()
+	return c, nil
+}
+
+// begin starts a request.  If no other request is in flight, so that no
+// response is being read from them, the connections older than maxAge are
+// closed first and idle is called to drop them from the idle connections.
+func (t *connTracker) begin(now time.Time, idle func()) {
+	t.mu.Lock()
+	var expired []*trackedConn
+	if t.inFlight == 0 {
+		for c, dialed := range t.conns {
+			if now.Sub(dialed) >= t.maxAge {
+				expired = append(expired, c)
+			}
+		}
+	}
+	t.inFlight++
+	t.mu.Unlock()
+
+	if len(expired) == 0 {
+		return
+	}
+	idle()
+	for _, c := range expired {
+		c.Close()
+	}
+}
+
+// end ends a request once its response was read.
+func (t *connTracker) end() {
+	t.mu.Lock()
+	t.inFlight--
+	t.mu.Unlock()
+}
+
+func (t *connTracker) forget(c *trackedConn) {
+	t.mu.Lock()
+	delete(t.conns, c)
+	t.mu.Unlock()
+}
+
+// trackedConn is a connection of a connTracker.
+type trackedConn struct {
+	net.Conn
+	tracker *connTracker
+	once    sync.Once
+}
+
+// Close closes the connection and stops its tracking.
+func (c *trackedConn) Close() error {
+	c.once.Do(func() { c.tracker.forget(c) })
+	return c.Conn.Close()
+}
+
+// maxAgeTransport closes the connections of the base transport older than
+// the max age of the tracker between requests.  The base transport dials
+// with the tracker.
+type maxAgeTransport struct {
+	base    *http.Transport
+	tracker *connTracker
+}
+
+// RoundTrip sends the request, see http.RoundTripper.  The request is in
+// flight until its response body is closed.
+func (t *maxAgeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.tracker.begin(time.Now(), t.base.CloseIdleConnections)
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		t.tracker.end()
+		return nil, err
+	}
+	resp.Body = &trackedBody{ReadCloser: resp.Body, end: t.tracker.end}
+	return resp, nil
+}
+
+// trackedBody ends the request of the response once closed.
+type trackedBody struct {
+	io.ReadCloser
+	end  func()
+	once sync.Once
+}
+
+func (b *trackedBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.end)
+	return err
+}
@@ -0,0 +1,75 @@
+package httpconfig
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// tokenSource fetches tokens with the OAuth2 client credentials grant.  The
+// client secret is read for every token so that a rotated secret is used when
+// the token is refreshed.
+type tokenSource struct {
+	auth   *AuthConfig
+	client *http.Client
+}
+
+func newTokenSource(auth *AuthConfig, client *http.Client) *cachedTokens {
+	return &cachedTokens{source: &tokenSource{auth: auth, client: client}}
+}
+
+// Token returns a new token, see oauth2.TokenSource.
+func (s *tokenSource) Token() (*oauth2.Token, error) {
+	clientSecret, err := s.auth.ClientSecret.Get()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read client_secret: %v", err)
+	}
+
+	config := clientcredentials.Config{
+		ClientID:     s.auth.ClientID,
+		ClientSecret: clientSecret,
+		TokenURL:     s.auth.TokenURL,
+		Scopes:       s.auth.Scopes,
+	}
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, s.client)
+	return config.Token(ctx)
+}
+
+// cachedTokens reuses the token of the source until it expires, as
+// oauth2.ReuseTokenSource does, or until the server rejects it.
+type cachedTokens struct {
+	source oauth2.TokenSource
+
+	mu    sync.Mutex
+	token *oauth2.Token
+}
+
+// Token returns the cached token, or a new token of the source if it is not
+// valid anymore, see oauth2.TokenSource.
+func (c *cachedTokens) Token() (*oauth2.Token, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token.Valid() {
+		return c.token, nil
+	}
+	token, err := c.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	c.token = token
+	return token, nil
+}
+
+// reject drops the cached token if it is the token of the Authorization
+// header, so that the next request gets a new token.
+func (c *cachedTokens) reject(authorization string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.token != nil && c.token.Type()+" "+c.token.AccessToken == authorization {
+		c.token = nil
+	}
+}
@@ -0,0 +1,85 @@
+package httpconfig
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+)
+
+// transport adds the authentication and content encoding to the requests
+// sent with the base transport.
+type transport struct {
+	base    http.RoundTripper
+	auth    *AuthConfig
+	tokens  *cachedTokens
+	encoder *encoder
+}
+
+// RoundTrip sends the request, see http.RoundTripper.  As required by
+// http.RoundTripper, the request is not modified and a copy is sent instead.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	r := req.WithContext(req.Context())
+	r.Header = make(http.Header, len(req.Header))
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+
+	authorized := false
+	if t.auth != nil && r.Header.Get("Authorization") == "" {
+		if err := t.authorize(r); err != nil {
+			closeBody(req)
+			return nil, err
+		}
+		authorized = true
+	}
+
+	if t.encoder != nil && r.Body != nil && r.Header.Get("Content-Encoding") == "" {
+		data, err := ioutil.ReadAll(r.Body)
+		closeBody(req)
+		if err != nil {
+			return nil, err
+		}
+		data, err = t.encoder.codec.Encode(data)
+		if err != nil {
+			return nil, err
+		}
+
+		r.Body = ioutil.NopCloser(bytes.NewReader(data))
+		r.GetBody = func() (io.ReadCloser, error) {
+			return ioutil.NopCloser(bytes.NewReader(data)), nil
+		}
+		r.ContentLength = int64(len(data))
+		r.Header.Set("Content-Encoding", t.encoder.name)
+	}
+
+	resp, err := t.base.RoundTrip(r)
+	// A token rejected before it expires, ie revoked, is replaced by the
+	// next request.
+	if err == nil && authorized && t.tokens != nil && resp.StatusCode == http.StatusUnauthorized {
+		t.tokens.reject(r.Header.Get("Authorization"))
+	}
+	return resp, err
+}
+
+// authorize sets the Authorization header of the request, with a token of
+// the OAuth2 token source if set.
+func (t *transport) authorize(req *http.Request) error {
+	if t.tokens == nil {
+		return t.auth.authorize(req)
+	}
+
+	token, err := t.tokens.Token()
+	if err != nil {
+		return fmt.Errorf("unable to get OAuth2 token: %v", err)
+	}
+	token.SetAuthHeader(req)
+	return nil
+}
+
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
@@ -0,0 +1,45 @@
+package metadata
+
+import (
+	"context"
+)
+
+// Azure reads the compute metadata of the Azure Instance Metadata Service.
+type Azure struct {
+	// Endpoint is the address of the metadata service.
+	Endpoint string
+}
+
+func (p *Azure) Name() string {
+	return "azure"
+}
+
+func (p *Azure) Tags(ctx context.Context) (map[string]string, error) {
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = "http://169.254.169.254"
+	}
+
+	var compute struct {
+		Location          string `json:"location"`
+		ResourceGroupName string `json:"resourceGroupName"`
+		SubscriptionID    string `json:"subscriptionId"`
+		VMID              string `json:"vmId"`
+		VMSize            string `json:"vmSize"`
+		Zone              string `json:"zone"`
+	}
+	err := getJSON(ctx, endpoint+"/metadata/instance/compute?api-version=2019-06-01&format=json",
+		map[string]string{"Metadata": "true"}, &compute)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[string]string{"cloud_provider": "azure"}
+	addTag(tags, "subscription_id", compute.SubscriptionID)
+	addTag(tags, "resource_group", compute.ResourceGroupName)
+	addTag(tags, "instance_id", compute.VMID)
+	addTag(tags, "instance_type", compute.VMSize)
+	addTag(tags, "region", compute.Location)
+	addTag(tags, "zone", compute.Zone)
+	return tags, nil
+}
@@ -0,0 +1,53 @@
+package metadata
+
+import (
+	"context"
+)
+
+// EC2 reads the instance identity document of the EC2 instance metadata
+// service.
+type EC2 struct {
+	// Endpoint is the address of the metadata service.
+	Endpoint string
+}
+
+func (p *EC2) Name() string {
+	return "ec2"
+}
+
+func (p *EC2) Tags(ctx context.Context) (map[string]string, error) {
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = "http://169.254.169.254"
+	}
+
+	// Instances requiring IMDSv2 only answer requests carrying a session
+	// token; when the token cannot be obtained fall back to IMDSv1.
+	header := map[string]string{}
+	token, err := get(ctx, "PUT", endpoint+"/latest/api/token", map[string]string{
+		"X-aws-ec2-metadata-token-ttl-seconds": "60",
+	})
+	if err == nil {
+		header["X-aws-ec2-metadata-token"] = string(token)
+	}
+
+	var doc struct {
+		AccountID        string `json:"accountId"`
+		AvailabilityZone string `json:"availabilityZone"`
+		InstanceID       string `json:"instanceId"`
+		InstanceType     string `json:"instanceType"`
+		Region           string `json:"region"`
+	}
+	err = getJSON(ctx, endpoint+"/latest/dynamic/instance-identity/document", header, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[string]string{"cloud_provider": "aws"}
+	addTag(tags, "account_id", doc.AccountID)
+	addTag(tags, "instance_id", doc.InstanceID)
+	addTag(tags, "instance_type", doc.InstanceType)
+	addTag(tags, "region", doc.Region)
+	addTag(tags, "zone", doc.AvailabilityZone)
+	return tags, nil
+}
@@ -0,0 +1,38 @@
+package metadata
+
+import (
+	"context"
+	"io/ioutil"
+
+	"github.com/influxdata/toml"
+)
+
+// File reads static tags from a TOML file of string values, such as:
+//
+//	datacenter = "dc1"
+//	rack = "r12"
+type File struct {
+	Path string
+}
+
+func (p *File) Name() string {
+	return "file"
+}
+
+func (p *File) Tags(ctx context.Context) (map[string]string, error) {
+	data, err := ioutil.ReadFile(p.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	tbl, err := toml.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+
+	tags := make(map[string]string)
+	if err := toml.UnmarshalTable(tbl, tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
@@ -0,0 +1,58 @@
+package metadata
+
+import (
+	"context"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// GCP reads the instance metadata of the Google Compute Engine metadata
+// server.
+type GCP struct {
+	// Endpoint is the address of the metadata server.
+	Endpoint string
+}
+
+func (p *GCP) Name() string {
+	return "gcp"
+}
+
+func (p *GCP) Tags(ctx context.Context) (map[string]string, error) {
+	endpoint := p.Endpoint
+	if endpoint == "" {
+		endpoint = "http://metadata.google.internal"
+	}
+	header := map[string]string{"Metadata-Flavor": "Google"}
+
+	var instance struct {
+		ID          uint64 `json:"id"`
+		MachineType string `json:"machineType"`
+		Zone        string `json:"zone"`
+	}
+	err := getJSON(ctx, endpoint+"/computeMetadata/v1/instance/?recursive=true", header, &instance)
+	if err != nil {
+		return nil, err
+	}
+
+	project, err := get(ctx, "GET", endpoint+"/computeMetadata/v1/project/project-id", header)
+	if err != nil {
+		return nil, err
+	}
+
+	// The zone and machine type are given as resource paths such as
+	// "projects/123/zones/us-central1-a".
+	zone := path.Base(instance.Zone)
+	region := zone
+	if i := strings.LastIndex(zone, "-"); i > 0 {
+		region = zone[:i]
+	}
+
+	tags := map[string]string{"cloud_provider": "gcp"}
+	addTag(tags, "project_id", string(project))
+	addTag(tags, "instance_id", strconv.FormatUint(instance.ID, 10))
+	addTag(tags, "instance_type", path.Base(instance.MachineType))
+	addTag(tags, "region", region)
+	addTag(tags, "zone", zone)
+	return tags, nil
+}
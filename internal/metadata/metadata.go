@@ -0,0 +1,99 @@
+// Package metadata discovers tags describing the host from cloud metadata
+// services and static files.
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Provider returns the tags of a metadata source.
+type Provider interface {
+	// Name returns the name of the provider as used in the config.
+	Name() string
+
+	// Tags returns the tags describing the host.
+	Tags(ctx context.Context) (map[string]string, error)
+}
+
+// providers holds the constructors of the cloud metadata providers by name.
+var providers = map[string]func() Provider{
+	"azure": func() Provider { return &Azure{} },
+	"ec2":   func() Provider { return &EC2{} },
+	"gcp":   func() Provider { return &GCP{} },
+}
+
+// NewProvider returns the cloud metadata provider with the given name.
+func NewProvider(name string) (Provider, error) {
+	creator, ok := providers[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown metadata provider %q, available providers are %v",
+			name, Names())
+	}
+	return creator(), nil
+}
+
+// Names returns the names of the cloud metadata providers.
+func Names() []string {
+	var names []string
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// client is used for all requests to the metadata services.  The services are
+// link-local, so proxies configured in the environment are not used.
+var client = &http.Client{
+	Transport: &http.Transport{},
+	Timeout:   5 * time.Second,
+}
+
+// get requests the url with the given headers and returns the response body.
+func get(ctx context.Context, method, url string, header map[string]string) ([]byte, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned HTTP status %s", url, resp.Status)
+	}
+	return body, nil
+}
+
+// getJSON requests the url and decodes the JSON response into v.
+func getJSON(ctx context.Context, url string, header map[string]string, v interface{}) error {
+	body, err := get(ctx, "GET", url, header)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(body, v)
+}
+
+// addTag sets the tag if the value is not empty.
+func addTag(tags map[string]string, key, value string) {
+	if value != "" {
+		tags[key] = value
+	}
+}
@@ -0,0 +1,136 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEC2(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latest/api/token":
+			assert.Equal(t, "PUT", r.Method)
+			fmt.Fprint(w, "token")
+		case "/latest/dynamic/instance-identity/document":
+			if r.Header.Get("X-aws-ec2-metadata-token") != "token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			fmt.Fprint(w, `{
+				"accountId": "123456789012",
+				"availabilityZone": "us-east-1a",
+				"instanceId": "i-0123456789abcdef0",
+				"instanceType": "m5.large",
+				"region": "us-east-1"
+			}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tags, err := (&EC2{Endpoint: ts.URL}).Tags(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"cloud_provider": "aws",
+		"account_id":     "123456789012",
+		"instance_id":    "i-0123456789abcdef0",
+		"instance_type":  "m5.large",
+		"region":         "us-east-1",
+		"zone":           "us-east-1a",
+	}, tags)
+}
+
+func TestGCP(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/computeMetadata/v1/instance/":
+			fmt.Fprint(w, `{
+				"id": 4520031799277581759,
+				"machineType": "projects/123/machineTypes/n1-standard-1",
+				"zone": "projects/123/zones/us-central1-a"
+			}`)
+		case "/computeMetadata/v1/project/project-id":
+			fmt.Fprint(w, "my-project")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	tags, err := (&GCP{Endpoint: ts.URL}).Tags(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"cloud_provider": "gcp",
+		"project_id":     "my-project",
+		"instance_id":    "4520031799277581759",
+		"instance_type":  "n1-standard-1",
+		"region":         "us-central1",
+		"zone":           "us-central1-a",
+	}, tags)
+}
+
+func TestAzure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata") != "true" || r.URL.Path != "/metadata/instance/compute" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprint(w, `{
+			"location": "westeurope",
+			"resourceGroupName": "telegraf",
+			"subscriptionId": "8d10da13-8125-4ba9-a717-bf7490507b3d",
+			"vmId": "02aab8a4-74ef-476e-8182-f6d2ba4166a6",
+			"vmSize": "Standard_A3",
+			"zone": ""
+		}`)
+	}))
+	defer ts.Close()
+
+	tags, err := (&Azure{Endpoint: ts.URL}).Tags(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"cloud_provider":  "azure",
+		"subscription_id": "8d10da13-8125-4ba9-a717-bf7490507b3d",
+		"resource_group":  "telegraf",
+		"instance_id":     "02aab8a4-74ef-476e-8182-f6d2ba4166a6",
+		"instance_type":   "Standard_A3",
+		"region":          "westeurope",
+	}, tags)
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "tags.toml")
+	err = ioutil.WriteFile(path, []byte("datacenter = \"dc1\"\nrack = \"r12\"\n"), 0644)
+	require.NoError(t, err)
+
+	tags, err := (&File{Path: path}).Tags(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"datacenter": "dc1", "rack": "r12"}, tags)
+}
+
+func TestNewProvider(t *testing.T) {
+	p, err := NewProvider("ec2")
+	require.NoError(t, err)
+	assert.Equal(t, "ec2", p.Name())
+
+	_, err = NewProvider("openstack")
+	assert.Error(t, err)
+}
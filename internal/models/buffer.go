@@ -31,7 +31,8 @@ type Buffer struct {
 }
 
 // NewBuffer returns a new empty Buffer with the given capacity.
-func NewBuffer(name string, capacity int) *Buffer {
+func NewBuffer(name string, alias string, capacity int) *Buffer {
+	tags := pluginTags("output", name, alias)
 	b := &Buffer{
 		buf:   make([]telegraf.Metric, capacity),
 		first: 0,
@@ -42,17 +43,17 @@ func NewBuffer(name string, capacity int) *Buffer {
 		MetricsAdded: selfstat.Register(
 			"write",
 			"metrics_added",
-			map[string]string{"output": name},
+			tags,
 		),
 		MetricsWritten: selfstat.Register(
 			"write",
 			"metrics_written",
-			map[string]string{"output": name},
+			tags,
 		),
 		MetricsDropped: selfstat.Register(
 			"write",
 			"metrics_dropped",
-			map[string]string{"output": name},
+			tags,
 		),
 	}
 	return b
@@ -200,6 +201,26 @@ func (b *Buffer) Reject(batch []telegraf.Metric) {
 	b.resetBatch()
 }
 
+// Drain removes and returns all metrics in the buffer, oldest first.  Any
+// outstanding batch is discarded.
+func (b *Buffer) Drain() []telegraf.Metric {
+	b.Lock()
+	defer b.Unlock()
+
+	out := make([]telegraf.Metric, 0, b.size)
+	for i := 0; i < b.size; i++ {
+		idx := (b.first + i) % b.cap
+		out = append(out, b.buf[idx])
+		b.buf[idx] = nil
+	}
+
+	b.first = 0
+	b.last = 0
+	b.size = 0
+	b.resetBatch()
+	return out
+}
+
 func (b *Buffer) resetBatch() {
 	b.batchFirst = 0
 	b.batchLast = 0
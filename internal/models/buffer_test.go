@@ -44,7 +44,7 @@ func Metric() telegraf.Metric {
 }
 
 func BenchmarkAddMetrics(b *testing.B) {
-	buf := NewBuffer("test", 10000)
+	buf := NewBuffer("test", "", 10000)
 	m := Metric()
 	for n := 0; n < b.N; n++ {
 		buf.Add(m)
@@ -59,14 +59,14 @@ func setup(b *Buffer) *Buffer {
 }
 
 func TestBuffer_LenEmpty(t *testing.T) {
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 
 	require.Equal(t, 0, b.Len())
 }
 
 func TestBuffer_LenOne(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(m)
 
 	require.Equal(t, 1, b.Len())
@@ -74,7 +74,7 @@ func TestBuffer_LenOne(t *testing.T) {
 
 func TestBuffer_LenFull(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(m, m, m, m, m)
 
 	require.Equal(t, 5, b.Len())
@@ -82,7 +82,7 @@ func TestBuffer_LenFull(t *testing.T) {
 
 func TestBuffer_LenOverfill(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	setup(b)
 	b.Add(m, m, m, m, m, m)
 
@@ -90,14 +90,14 @@ func TestBuffer_LenOverfill(t *testing.T) {
 }
 
 func TestBuffer_BatchLenZero(t *testing.T) {
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	batch := b.Batch(0)
 
 	require.Len(t, batch, 0)
 }
 
 func TestBuffer_BatchLenBufferEmpty(t *testing.T) {
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	batch := b.Batch(2)
 
 	require.Len(t, batch, 0)
@@ -105,7 +105,7 @@ func TestBuffer_BatchLenBufferEmpty(t *testing.T) {
 
 func TestBuffer_BatchLenUnderfill(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(m)
 	batch := b.Batch(2)
 
@@ -114,7 +114,7 @@ func TestBuffer_BatchLenUnderfill(t *testing.T) {
 
 func TestBuffer_BatchLenFill(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(m, m, m)
 	batch := b.Batch(2)
 	require.Len(t, batch, 2)
@@ -122,7 +122,7 @@ func TestBuffer_BatchLenFill(t *testing.T) {
 
 func TestBuffer_BatchLenExact(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(m, m)
 	batch := b.Batch(2)
 	require.Len(t, batch, 2)
@@ -130,7 +130,7 @@ func TestBuffer_BatchLenExact(t *testing.T) {
 
 func TestBuffer_BatchLenLargerThanBuffer(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(m, m, m, m, m)
 	batch := b.Batch(6)
 	require.Len(t, batch, 5)
@@ -138,7 +138,7 @@ func TestBuffer_BatchLenLargerThanBuffer(t *testing.T) {
 
 func TestBuffer_BatchWrap(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(m, m, m, m, m)
 	batch := b.Batch(2)
 	b.Accept(batch)
@@ -149,7 +149,7 @@ func TestBuffer_BatchWrap(t *testing.T) {
 
 func TestBuffer_AddDropsOverwrittenMetrics(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 
 	b.Add(m, m, m, m, m)
 	b.Add(m, m, m, m, m)
@@ -160,7 +160,7 @@ func TestBuffer_AddDropsOverwrittenMetrics(t *testing.T) {
 
 func TestBuffer_AcceptRemovesBatch(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(m, m, m)
 	batch := b.Batch(2)
 	b.Accept(batch)
@@ -169,7 +169,7 @@ func TestBuffer_AcceptRemovesBatch(t *testing.T) {
 
 func TestBuffer_RejectLeavesBatch(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(m, m, m)
 	batch := b.Batch(2)
 	b.Reject(batch)
@@ -178,7 +178,7 @@ func TestBuffer_RejectLeavesBatch(t *testing.T) {
 
 func TestBuffer_AcceptWritesOverwrittenBatch(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 
 	b.Add(m, m, m, m, m)
 	batch := b.Batch(5)
@@ -191,7 +191,7 @@ func TestBuffer_AcceptWritesOverwrittenBatch(t *testing.T) {
 
 func TestBuffer_BatchRejectDropsOverwrittenBatch(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 
 	b.Add(m, m, m, m, m)
 	batch := b.Batch(5)
@@ -204,7 +204,7 @@ func TestBuffer_BatchRejectDropsOverwrittenBatch(t *testing.T) {
 
 func TestBuffer_MetricsOverwriteBatchAccept(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 
 	b.Add(m, m, m, m, m)
 	batch := b.Batch(3)
@@ -216,7 +216,7 @@ func TestBuffer_MetricsOverwriteBatchAccept(t *testing.T) {
 
 func TestBuffer_MetricsOverwriteBatchReject(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 
 	b.Add(m, m, m, m, m)
 	batch := b.Batch(3)
@@ -228,7 +228,7 @@ func TestBuffer_MetricsOverwriteBatchReject(t *testing.T) {
 
 func TestBuffer_MetricsBatchAcceptRemoved(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 
 	b.Add(m, m, m, m, m)
 	batch := b.Batch(3)
@@ -240,7 +240,7 @@ func TestBuffer_MetricsBatchAcceptRemoved(t *testing.T) {
 
 func TestBuffer_WrapWithBatch(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 
 	b.Add(m, m, m)
 	b.Batch(3)
@@ -251,7 +251,7 @@ func TestBuffer_WrapWithBatch(t *testing.T) {
 
 func TestBuffer_BatchNotRemoved(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(m, m, m, m, m)
 	b.Batch(2)
 	require.Equal(t, 5, b.Len())
@@ -259,7 +259,7 @@ func TestBuffer_BatchNotRemoved(t *testing.T) {
 
 func TestBuffer_BatchRejectAcceptNoop(t *testing.T) {
 	m := Metric()
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(m, m, m, m, m)
 	batch := b.Batch(2)
 	b.Reject(batch)
@@ -275,7 +275,7 @@ func TestBuffer_AcceptCallsMetricAccept(t *testing.T) {
 			accept++
 		},
 	}
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(mm, mm, mm)
 	batch := b.Batch(2)
 	b.Accept(batch)
@@ -290,7 +290,7 @@ func TestBuffer_AddCallsMetricRejectWhenNoBatch(t *testing.T) {
 			reject++
 		},
 	}
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	setup(b)
 	b.Add(mm, mm, mm, mm, mm)
 	b.Add(mm, mm)
@@ -305,7 +305,7 @@ func TestBuffer_AddCallsMetricRejectWhenNotInBatch(t *testing.T) {
 			reject++
 		},
 	}
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	setup(b)
 	b.Add(mm, mm, mm, mm, mm)
 	batch := b.Batch(2)
@@ -325,7 +325,7 @@ func TestBuffer_RejectCallsMetricRejectWithOverwritten(t *testing.T) {
 			reject++
 		},
 	}
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(mm, mm, mm, mm, mm)
 	batch := b.Batch(5)
 	b.Add(mm, mm)
@@ -342,7 +342,7 @@ func TestBuffer_AddOverwriteAndReject(t *testing.T) {
 			reject++
 		},
 	}
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(mm, mm, mm, mm, mm)
 	batch := b.Batch(5)
 	b.Add(mm, mm, mm, mm, mm)
@@ -366,7 +366,7 @@ func TestBuffer_AddOverwriteAndRejectOffset(t *testing.T) {
 			accept++
 		},
 	}
-	b := setup(NewBuffer("test", 5))
+	b := setup(NewBuffer("test", "", 5))
 	b.Add(mm, mm, mm)
 	b.Add(mm, mm, mm, mm)
 	require.Equal(t, 2, reject)
@@ -383,3 +383,21 @@ func TestBuffer_AddOverwriteAndRejectOffset(t *testing.T) {
 	require.Equal(t, 13, reject)
 	require.Equal(t, 5, accept)
 }
+
+func TestBuffer_DrainWrapped(t *testing.T) {
+	b := setup(NewBuffer("test", "", 5))
+	for i := 0; i < 7; i++ {
+		m := Metric()
+		m.SetTime(time.Unix(int64(i), 0))
+		b.Add(m)
+	}
+	b.Batch(2)
+
+	drained := b.Drain()
+	require.Len(t, drained, 5)
+	for i, m := range drained {
+		require.Equal(t, time.Unix(int64(i+2), 0), m.Time())
+	}
+	require.Equal(t, 0, b.Len())
+	require.Len(t, b.Batch(5), 0)
+}
@@ -2,11 +2,18 @@ package models
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal/expr"
+	"github.com/influxdata/telegraf/logger"
 )
 
+// metricPassLog logs the errors evaluating metricpass expressions, which may
+// happen for every metric.
+var metricPassLog = logger.RateLimited(NewLogger("metricpass", ""), time.Minute)
+
 // TagFilter is the name of a tag, and the values on which to filter
 type TagFilter struct {
 	Name   string
@@ -34,6 +41,9 @@ type Filter struct {
 	TagInclude []string
 	tagInclude filter.Filter
 
+	MetricPass string
+	metricPass *expr.Expression
+
 	isActive bool
 }
 
@@ -46,7 +56,8 @@ func (f *Filter) Compile() error {
 		len(f.TagInclude) == 0 &&
 		len(f.TagExclude) == 0 &&
 		len(f.TagPass) == 0 &&
-		len(f.TagDrop) == 0 {
+		len(f.TagDrop) == 0 &&
+		f.MetricPass == "" {
 		return nil
 	}
 
@@ -91,11 +102,19 @@ func (f *Filter) Compile() error {
 			return fmt.Errorf("Error compiling 'tagpass', %s", err)
 		}
 	}
+
+	if f.MetricPass != "" {
+		f.metricPass, err = expr.Compile(f.MetricPass)
+		if err != nil {
+			return fmt.Errorf("Error compiling 'metricpass', %s", err)
+		}
+	}
 	return nil
 }
 
 // Select returns true if the metric matches according to the
-// namepass/namedrop and tagpass/tagdrop filters.  The metric is not modified.
+// namepass/namedrop, tagpass/tagdrop and metricpass filters.  The metric is
+// not modified.
 func (f *Filter) Select(metric telegraf.Metric) bool {
 	if !f.isActive {
 		return true
@@ -109,6 +128,10 @@ func (f *Filter) Select(metric telegraf.Metric) bool {
 		return false
 	}
 
+	if !f.shouldMetricPass(metric) {
+		return false
+	}
+
 	return true
 }
 
@@ -219,6 +242,23 @@ func (f *Filter) shouldTagsPass(tags []*telegraf.Tag) bool {
 	return true
 }
 
+// shouldMetricPass returns true if the metric should pass, false if should
+// drop based on the metricpass expression.  Metrics for which the expression
+// cannot be evaluated are dropped.
+func (f *Filter) shouldMetricPass(metric telegraf.Metric) bool {
+	if f.metricPass == nil {
+		return true
+	}
+
+	ok, err := f.metricPass.Select(metric)
+	if err != nil {
+		metricPassLog.Errorf("Error evaluating %q on metric %s: %v",
+			f.metricPass, metric.Name(), err)
+		return false
+	}
+	return ok
+}
+
 // filterFields removes fields according to fieldpass/fielddrop.
 func (f *Filter) filterFields(metric telegraf.Metric) {
 	filterKeys := []string{}
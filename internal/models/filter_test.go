@@ -523,3 +523,35 @@ func BenchmarkFilter(b *testing.B) {
 		})
 	}
 }
+
+func TestFilter_MetricPass(t *testing.T) {
+	f := Filter{
+		MetricPass: `usage_user > 90 and host =~ '^web-'`,
+	}
+	require.NoError(t, f.Compile())
+	require.True(t, f.IsActive())
+
+	var tests = []struct {
+		tags     map[string]string
+		fields   map[string]interface{}
+		expected bool
+	}{
+		{map[string]string{"host": "web-01"}, map[string]interface{}{"usage_user": 95.0}, true},
+		{map[string]string{"host": "web-01"}, map[string]interface{}{"usage_user": 50.0}, false},
+		{map[string]string{"host": "db-01"}, map[string]interface{}{"usage_user": 95.0}, false},
+		{map[string]string{"host": "web-01"}, map[string]interface{}{"usage_idle": 5.0}, false},
+		{map[string]string{"host": "web-01"}, map[string]interface{}{"usage_user": "high"}, false},
+	}
+	for _, tt := range tests {
+		m, err := metric.New("cpu", tt.tags, tt.fields, time.Now())
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, f.Select(m))
+	}
+}
+
+func TestFilter_MetricPassInvalid(t *testing.T) {
+	f := Filter{
+		MetricPass: `usage_user >`,
+	}
+	require.Error(t, f.Compile())
+}
@@ -0,0 +1,169 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/logger"
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// logName returns the name used to identify a plugin instance in logs.
+func logName(pluginType, name, alias string) string {
+	if alias == "" {
+		return pluginType + "." + name
+	}
+	return pluginType + "." + name + "::" + alias
+}
+
+// pluginTags returns the tags identifying a plugin instance in its internal
+// metrics.
+func pluginTags(pluginType, name, alias string) map[string]string {
+	tags := map[string]string{pluginType: name}
+	if alias != "" {
+		tags["alias"] = alias
+	}
+	return tags
+}
+
+const (
+	levelDebug = iota + 1
+	levelInfo
+	levelWarn
+	levelError
+)
+
+var logLevels = map[string]int{
+	"debug": levelDebug,
+	"info":  levelInfo,
+	"warn":  levelWarn,
+	"error": levelError,
+}
+
+// ValidLogLevel returns true if level is a valid plugin log_level.
+func ValidLogLevel(level string) bool {
+	_, ok := logLevels[level]
+	return ok || level == ""
+}
+
+// Logger is the telegraf.Logger given to plugins.  Messages are prefixed with
+// the level and the name of the plugin instance, and filtered by the log
+// level of the plugin if one is set, or by the agent log level otherwise.
+type Logger struct {
+	name  string
+	level int
+}
+
+// NewLogger returns a Logger for the plugin instance with the given log name
+// and log level.  An empty level uses the agent log level.
+func NewLogger(name string, level string) *Logger {
+	return &Logger{name: name, level: logLevels[level]}
+}
+
+func (l *Logger) print(level int, prefix string, msg string) {
+	line := prefix + " [" + l.name + "] " + msg
+	if l.level == 0 {
+		log.Print(line)
+		return
+	}
+	if level >= l.level {
+		logger.PrintUnfiltered(line)
+	}
+}
+
+// Errorf logs an error message, patterned after log.Printf.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.print(levelError, "E!", fmt.Sprintf(format, args...))
+}
+
+// Error logs an error message, patterned after log.Print.
+func (l *Logger) Error(args ...interface{}) {
+	l.print(levelError, "E!", fmt.Sprint(args...))
+}
+
+// Warnf logs a warning message, patterned after log.Printf.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.print(levelWarn, "W!", fmt.Sprintf(format, args...))
+}
+
+// Warn logs a warning message, patterned after log.Print.
+func (l *Logger) Warn(args ...interface{}) {
+	l.print(levelWarn, "W!", fmt.Sprint(args...))
+}
+
+// Infof logs an information message, patterned after log.Printf.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.print(levelInfo, "I!", fmt.Sprintf(format, args...))
+}
+
+// Info logs an information message, patterned after log.Print.
+func (l *Logger) Info(args ...interface{}) {
+	l.print(levelInfo, "I!", fmt.Sprint(args...))
+}
+
+// Debugf logs a debug message, patterned after log.Printf.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.print(levelDebug, "D!", fmt.Sprintf(format, args...))
+}
+
+// Debug logs a debug message, patterned after log.Print.
+func (l *Logger) Debug(args ...interface{}) {
+	l.print(levelDebug, "D!", fmt.Sprint(args...))
+}
+
+var loggerType = reflect.TypeOf((*telegraf.Logger)(nil)).Elem()
+
+// SetLoggerOnPlugin sets the Log field of the plugin to log, if the plugin
+// has a Log field of type telegraf.Logger.
+func SetLoggerOnPlugin(plugin interface{}, log telegraf.Logger) {
+	v := reflect.ValueOf(plugin)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return
+	}
+
+	field := v.Elem().FieldByName("Log")
+	if !field.IsValid() || !field.CanSet() || field.Type() != loggerType {
+		return
+	}
+	field.Set(reflect.ValueOf(log))
+}
+
+var pluginStatsType = reflect.TypeOf((*selfstat.PluginStats)(nil))
+
+// SetStatsOnPlugin sets the Stats field of the plugin to a registry for the
+// internal metrics of the plugin instance, if the plugin has a Stats field of
+// type *selfstat.PluginStats.
+func SetStatsOnPlugin(plugin interface{}, pluginType, name, alias string) {
+	field := pluginStatsField(plugin)
+	if !field.IsValid() {
+		return
+	}
+	stats := selfstat.NewPluginStats(name, pluginTags(pluginType, name, alias))
+	field.Set(reflect.ValueOf(stats))
+}
+
+// UnregisterPluginStats removes the internal metrics registered by the plugin
+// from the selfstat registry.  It is called when a plugin instance is
+// unloaded.
+func UnregisterPluginStats(plugin interface{}) {
+	field := pluginStatsField(plugin)
+	if !field.IsValid() || field.IsNil() {
+		return
+	}
+	field.Interface().(*selfstat.PluginStats).Unregister()
+}
+
+func pluginStatsField(plugin interface{}) reflect.Value {
+	v := reflect.ValueOf(plugin)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+
+	field := v.Elem().FieldByName("Stats")
+	if !field.IsValid() || !field.CanSet() || field.Type() != pluginStatsType {
+		return reflect.Value{}
+	}
+	return field
+}
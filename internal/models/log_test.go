@@ -0,0 +1,73 @@
+package models
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/selfstat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type pluginWithLog struct {
+	Log telegraf.Logger
+}
+
+type pluginWithoutLog struct {
+	Log string
+}
+
+func TestSetLoggerOnPlugin(t *testing.T) {
+	l := NewLogger("inputs.test", "")
+
+	p := &pluginWithLog{}
+	SetLoggerOnPlugin(p, l)
+	assert.Equal(t, l, p.Log)
+
+	// fields of another type are left alone
+	q := &pluginWithoutLog{}
+	SetLoggerOnPlugin(q, l)
+	assert.Equal(t, "", q.Log)
+}
+
+func TestLoggerPrefix(t *testing.T) {
+	buf := bytes.NewBuffer(nil)
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stderr)
+
+	NewLogger("inputs.test::foo", "").Errorf("failed %d times", 3)
+	assert.Contains(t, buf.String(), "E! [inputs.test::foo] failed 3 times")
+}
+
+func TestValidLogLevel(t *testing.T) {
+	assert.True(t, ValidLogLevel(""))
+	assert.True(t, ValidLogLevel("debug"))
+	assert.False(t, ValidLogLevel("verbose"))
+}
+
+type pluginWithStats struct {
+	Stats *selfstat.PluginStats
+}
+
+func TestSetStatsOnPlugin(t *testing.T) {
+	p := &pluginWithStats{}
+	SetStatsOnPlugin(p, "input", "test", "foo")
+	require.NotNil(t, p.Stats)
+
+	s := p.Stats.Register("requests", nil)
+	assert.Equal(t, "internal_test", s.Name())
+	assert.Equal(t, map[string]string{"input": "test", "alias": "foo"}, s.Tags())
+
+	UnregisterPluginStats(p)
+	for _, m := range selfstat.Metrics() {
+		assert.NotEqual(t, "internal_test", m.Name())
+	}
+
+	// plugins without the field are left alone
+	q := &pluginWithLog{}
+	SetStatsOnPlugin(q, "input", "test", "")
+	UnregisterPluginStats(q)
+}
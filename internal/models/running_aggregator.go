@@ -12,6 +12,7 @@ type RunningAggregator struct {
 	sync.Mutex
 	Aggregator  telegraf.Aggregator
 	Config      *AggregatorConfig
+	ID          string
 	periodStart time.Time
 	periodEnd   time.Time
 
@@ -25,28 +26,29 @@ func NewRunningAggregator(
 	aggregator telegraf.Aggregator,
 	config *AggregatorConfig,
 ) *RunningAggregator {
+	tags := pluginTags("aggregator", config.Name, config.Alias)
 	return &RunningAggregator{
 		Aggregator: aggregator,
 		Config:     config,
 		MetricsPushed: selfstat.Register(
 			"aggregate",
 			"metrics_pushed",
-			map[string]string{"aggregator": config.Name},
+			tags,
 		),
 		MetricsFiltered: selfstat.Register(
 			"aggregate",
 			"metrics_filtered",
-			map[string]string{"aggregator": config.Name},
+			tags,
 		),
 		MetricsDropped: selfstat.Register(
 			"aggregate",
 			"metrics_dropped",
-			map[string]string{"aggregator": config.Name},
+			tags,
 		),
 		PushTime: selfstat.Register(
 			"aggregate",
 			"push_time_ns",
-			map[string]string{"aggregator": config.Name},
+			tags,
 		),
 	}
 }
@@ -54,6 +56,8 @@ func NewRunningAggregator(
 // AggregatorConfig is the common config for all aggregators.
 type AggregatorConfig struct {
 	Name         string
+	Alias        string
+	LogLevel     string
 	DropOriginal bool
 	Period       time.Duration
 	Delay        time.Duration
@@ -69,6 +73,11 @@ func (r *RunningAggregator) Name() string {
 	return "aggregators." + r.Config.Name
 }
 
+// LogName returns the name of the aggregator instance as used in logs.
+func (r *RunningAggregator) LogName() string {
+	return logName("aggregators", r.Config.Name, r.Config.Alias)
+}
+
 func (r *RunningAggregator) Period() time.Duration {
 	return r.Config.Period
 }
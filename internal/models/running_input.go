@@ -12,6 +12,7 @@ var GlobalMetricsGathered = selfstat.Register("agent", "metrics_gathered", map[s
 type RunningInput struct {
 	Input  telegraf.Input
 	Config *InputConfig
+	ID     string
 
 	defaultTags map[string]string
 
@@ -20,18 +21,19 @@ type RunningInput struct {
 }
 
 func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
+	tags := pluginTags("input", config.Name, config.Alias)
 	return &RunningInput{
 		Input:  input,
 		Config: config,
 		MetricsGathered: selfstat.Register(
 			"gather",
 			"metrics_gathered",
-			map[string]string{"input": config.Name},
+			tags,
 		),
 		GatherTime: selfstat.RegisterTiming(
 			"gather",
 			"gather_time_ns",
-			map[string]string{"input": config.Name},
+			tags,
 		),
 	}
 }
@@ -39,8 +41,21 @@ func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
 // InputConfig is the common config for all inputs.
 type InputConfig struct {
 	Name     string
+	Alias    string
+	LogLevel string
 	Interval time.Duration
 
+	// CollectionJitter overrides the agent collection_jitter when set.
+	CollectionJitter time.Duration
+
+	// GatherTimeout is the time after which a gather is abandoned, zero
+	// waits for the gather to complete.
+	GatherTimeout time.Duration
+
+	// Precision rounds the timestamps of all metrics of the input, including
+	// those of service inputs, when set.
+	Precision time.Duration
+
 	NameOverride      string
 	MeasurementPrefix string
 	MeasurementSuffix string
@@ -52,6 +67,11 @@ func (r *RunningInput) Name() string {
 	return "inputs." + r.Config.Name
 }
 
+// LogName returns the name of the input instance as used in logs.
+func (r *RunningInput) LogName() string {
+	return logName("inputs", r.Config.Name, r.Config.Alias)
+}
+
 func (r *RunningInput) metricFiltered(metric telegraf.Metric) {
 	metric.Drop()
 }
@@ -76,6 +96,10 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 		r.Config.Tags,
 		r.defaultTags)
 
+	if r.Config.Precision > 0 {
+		m.SetTime(m.Time().Round(r.Config.Precision))
+	}
+
 	r.MetricsGathered.Incr(1)
 	GlobalMetricsGathered.Incr(1)
 	return m
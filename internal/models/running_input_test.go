@@ -28,6 +28,42 @@ func TestMakeMetricNoFields(t *testing.T) {
 	assert.Nil(t, m)
 }
 
+func TestRunningInputAlias(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name: "TestRunningInput",
+	})
+	assert.Equal(t, "inputs.TestRunningInput", ri.LogName())
+
+	aliased := NewRunningInput(&testInput{}, &InputConfig{
+		Name:  "TestRunningInput",
+		Alias: "foo",
+	})
+	assert.Equal(t, "inputs.TestRunningInput::foo", aliased.LogName())
+	assert.Equal(t, map[string]string{"input": "TestRunningInput", "alias": "foo"},
+		aliased.MetricsGathered.Tags())
+
+	// instances with different aliases have separate internal metrics
+	ri.MetricsGathered.Incr(1)
+	assert.NotEqual(t, ri.MetricsGathered.Get(), aliased.MetricsGathered.Get())
+}
+
+func TestMakeMetricPrecision(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:      "TestRunningInput",
+		Precision: time.Second,
+	})
+
+	m, err := metric.New("RITest",
+		map[string]string{},
+		map[string]interface{}{"value": int64(101)},
+		time.Unix(41, int64(600*time.Millisecond)),
+		telegraf.Untyped)
+	require.NoError(t, err)
+	m = ri.MakeMetric(m)
+
+	assert.Equal(t, time.Unix(42, 0), m.Time())
+}
+
 // nil fields should get dropped
 func TestMakeMetricNilFields(t *testing.T) {
 	now := time.Now()
@@ -19,12 +19,17 @@ const (
 
 // OutputConfig containing name and filter
 type OutputConfig struct {
-	Name   string
-	Filter Filter
+	Name     string
+	Alias    string
+	LogLevel string
+	Filter   Filter
 
 	FlushInterval     time.Duration
 	MetricBufferLimit int
 	MetricBatchSize   int
+
+	// OmitHostname removes the host tag from the metrics of the output.
+	OmitHostname bool
 }
 
 // RunningOutput contains the output configuration
@@ -32,6 +37,7 @@ type RunningOutput struct {
 	Name              string
 	Output            telegraf.Output
 	Config            *OutputConfig
+	ID                string
 	MetricBufferLimit int
 	MetricBatchSize   int
 
@@ -67,10 +73,11 @@ func NewRunningOutput(
 	if batchSize == 0 {
 		batchSize = DEFAULT_METRIC_BATCH_SIZE
 	}
+	tags := pluginTags("output", name, conf.Alias)
 	ro := &RunningOutput{
 		Name:              name,
 		batch:             make([]telegraf.Metric, 0, batchSize),
-		buffer:            NewBuffer(name, bufferLimit),
+		buffer:            NewBuffer(name, conf.Alias, bufferLimit),
 		BatchReady:        make(chan time.Time, 1),
 		Output:            output,
 		Config:            conf,
@@ -79,22 +86,22 @@ func NewRunningOutput(
 		MetricsFiltered: selfstat.Register(
 			"write",
 			"metrics_filtered",
-			map[string]string{"output": name},
+			tags,
 		),
 		BufferSize: selfstat.Register(
 			"write",
 			"buffer_size",
-			map[string]string{"output": name},
+			tags,
 		),
 		BufferLimit: selfstat.Register(
 			"write",
 			"buffer_limit",
-			map[string]string{"output": name},
+			tags,
 		),
 		WriteTime: selfstat.RegisterTiming(
 			"write",
 			"write_time_ns",
-			map[string]string{"output": name},
+			tags,
 		),
 	}
 
@@ -122,6 +129,10 @@ func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
 		return
 	}
 
+	if ro.Config.OmitHostname {
+		metric.RemoveTag("host")
+	}
+
 	if output, ok := ro.Output.(telegraf.AggregatingOutput); ok {
 		ro.aggMutex.Lock()
 		output.Add(metric)
@@ -213,14 +224,47 @@ func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
 	ro.WriteTime.Incr(elapsed.Nanoseconds())
 
 	if err == nil {
-		log.Printf("D! [outputs.%s] wrote batch of %d metrics in %s\n",
-			ro.Name, len(metrics), elapsed)
+		log.Printf("D! [%s] wrote batch of %d metrics in %s\n",
+			ro.LogName(), len(metrics), elapsed)
 	}
 	return err
 }
 
+// Drain removes and returns all metrics that have not been written to the
+// output.  The metrics of a write still in progress are included.
+func (ro *RunningOutput) Drain() []telegraf.Metric {
+	ro.batchMutex.Lock()
+	ro.addBatchToBuffer()
+	ro.batchMutex.Unlock()
+
+	metrics := ro.buffer.Drain()
+	ro.BufferSize.Set(0)
+	return metrics
+}
+
+// BufferLength returns the number of metrics waiting to be written to the
+// output.
+func (ro *RunningOutput) BufferLength() int {
+	ro.batchMutex.Lock()
+	n := len(ro.batch)
+	ro.batchMutex.Unlock()
+	return n + ro.buffer.Len()
+}
+
+// Restore adds metrics that were buffered by a previous run to the buffer.
+// The metrics have already been filtered and are added as is.
+func (ro *RunningOutput) Restore(metrics []telegraf.Metric) {
+	ro.buffer.Add(metrics...)
+	ro.BufferSize.Set(int64(ro.buffer.Len()))
+}
+
 func (ro *RunningOutput) LogBufferStatus() {
 	nBuffer := ro.buffer.Len()
-	log.Printf("D! [outputs.%s] buffer fullness: %d / %d metrics. ",
-		ro.Name, nBuffer, ro.MetricBufferLimit)
+	log.Printf("D! [%s] buffer fullness: %d / %d metrics. ",
+		ro.LogName(), nBuffer, ro.MetricBufferLimit)
+}
+
+// LogName returns the name of the output instance as used in logs.
+func (ro *RunningOutput) LogName() string {
+	return logName("outputs", ro.Name, ro.Config.Alias)
 }
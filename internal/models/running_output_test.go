@@ -167,6 +167,27 @@ func TestRunningOutput_TagExcludeMatch(t *testing.T) {
 	assert.Len(t, m.Metrics()[0].Tags(), 0)
 }
 
+// Test that the host tag is removed when omit_hostname is set
+func TestRunningOutput_OmitHostname(t *testing.T) {
+	conf := &OutputConfig{
+		OmitHostname: true,
+	}
+	assert.NoError(t, conf.Filter.Compile())
+
+	m := &mockOutput{}
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+
+	metric := testutil.TestMetric(101, "metric1")
+	metric.AddTag("host", "localhost")
+	ro.AddMetric(metric)
+
+	err := ro.Write()
+	assert.NoError(t, err)
+	assert.Len(t, m.Metrics(), 1)
+	assert.False(t, m.Metrics()[0].HasTag("host"))
+	assert.True(t, m.Metrics()[0].HasTag("tag1"))
+}
+
 // Test that tags are properly Excluded
 func TestRunningOutput_TagExcludeNoMatch(t *testing.T) {
 	conf := &OutputConfig{
@@ -12,6 +12,7 @@ type RunningProcessor struct {
 	sync.Mutex
 	Processor telegraf.Processor
 	Config    *ProcessorConfig
+	ID        string
 }
 
 type RunningProcessors []*RunningProcessor
@@ -22,9 +23,16 @@ func (rp RunningProcessors) Less(i, j int) bool { return rp[i].Config.Order < rp
 
 // FilterConfig containing a name and filter
 type ProcessorConfig struct {
-	Name   string
-	Order  int64
-	Filter Filter
+	Name     string
+	Alias    string
+	LogLevel string
+	Order    int64
+	Filter   Filter
+}
+
+// LogName returns the name of the processor instance as used in logs.
+func (rp *RunningProcessor) LogName() string {
+	return logName("processors", rp.Config.Name, rp.Config.Alias)
 }
 
 func (rp *RunningProcessor) metricFiltered(metric telegraf.Metric) {
@@ -0,0 +1,165 @@
+// Package rotate provides a file writer that rotates the file by size and age.
+package rotate
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileWriter writes to a file that is rotated when writing would make it
+// larger than the maximum size, or once it is older than the rotation
+// interval.  The rotated files are renamed to <name>.<unix nanoseconds><ext>
+// in the same directory, of which at most maxArchives are kept.
+type FileWriter struct {
+	filename    string
+	interval    time.Duration
+	maxSize     int64
+	maxArchives int
+
+	mu      sync.Mutex
+	current *os.File
+	size    int64
+	expire  time.Time
+}
+
+// NewFileWriter opens the file for appending, creating it if needed.  An
+// interval or maxSize of zero disables rotation by age or size, and a
+// negative maxArchives keeps all rotated files.
+func NewFileWriter(filename string, interval time.Duration, maxSize int64, maxArchives int) (*FileWriter, error) {
+	w := &FileWriter{
+		filename:    filename,
+		interval:    interval,
+		maxSize:     maxSize,
+		maxArchives: maxArchives,
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Write writes p to the file, rotating the file first if needed.
+func (w *FileWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.current == nil {
+		return 0, os.ErrClosed
+	}
+
+	if w.shouldRotate(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.current.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// Close closes the file.
+func (w *FileWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.current == nil {
+		return nil
+	}
+	err := w.current.Close()
+	w.current = nil
+	return err
+}
+
+func (w *FileWriter) open() error {
+	f, err := os.OpenFile(w.filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.current = f
+	w.size = info.Size()
+	if w.interval > 0 {
+		w.expire = time.Now().Add(w.interval)
+	}
+	return nil
+}
+
+func (w *FileWriter) shouldRotate(n int) bool {
+	if w.maxSize > 0 && w.size > 0 && w.size+int64(n) > w.maxSize {
+		return true
+	}
+	return w.interval > 0 && !time.Now().Before(w.expire)
+}
+
+func (w *FileWriter) rotate() error {
+	if err := w.current.Close(); err != nil {
+		return err
+	}
+	w.current = nil
+
+	ext := filepath.Ext(w.filename)
+	base := strings.TrimSuffix(w.filename, ext)
+	archive := fmt.Sprintf("%s.%d%s", base, time.Now().UnixNano(), ext)
+	if err := os.Rename(w.filename, archive); err != nil {
+		return err
+	}
+
+	if err := w.open(); err != nil {
+		return err
+	}
+	return w.purgeArchives()
+}
+
+// purgeArchives removes the oldest rotated files above maxArchives.
+func (w *FileWriter) purgeArchives() error {
+	if w.maxArchives < 0 {
+		return nil
+	}
+
+	ext := filepath.Ext(w.filename)
+	base := strings.TrimSuffix(filepath.Base(w.filename), ext)
+	re := regexp.MustCompile("^" + regexp.QuoteMeta(base) + `\.(\d+)` + regexp.QuoteMeta(ext) + "$")
+
+	dir := filepath.Dir(w.filename)
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	names, err := d.Readdirnames(-1)
+	d.Close()
+	if err != nil {
+		return err
+	}
+
+	var archives []string
+	for _, name := range names {
+		if re.MatchString(name) {
+			archives = append(archives, name)
+		}
+	}
+	if len(archives) <= w.maxArchives {
+		return nil
+	}
+
+	// The timestamps have the same number of digits, so sorting the names
+	// sorts the archives from oldest to newest.
+	sort.Strings(archives)
+	for _, name := range archives[:len(archives)-w.maxArchives] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
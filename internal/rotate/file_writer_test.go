@@ -0,0 +1,106 @@
+package rotate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func archives(t *testing.T, dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "test.*.log"))
+	require.NoError(t, err)
+	return files
+}
+
+func TestFileWriter_NoRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "test.log")
+	w, err := NewFileWriter(filename, 0, 0, 5)
+	require.NoError(t, err)
+	defer w.Close()
+
+	for i := 0; i < 10; i++ {
+		_, err = w.Write([]byte("Hello World\n"))
+		require.NoError(t, err)
+	}
+	require.Empty(t, archives(t, dir))
+}
+
+func TestFileWriter_RotateBySize(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "test.log")
+	w, err := NewFileWriter(filename, 0, 20, -1)
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("Hello World\n"))
+	require.NoError(t, err)
+	require.Empty(t, archives(t, dir))
+
+	_, err = w.Write([]byte("Hello World\n"))
+	require.NoError(t, err)
+	require.Len(t, archives(t, dir), 1)
+
+	buf, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	require.Equal(t, "Hello World\n", string(buf))
+}
+
+func TestFileWriter_RotateByTime(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "test.log")
+	w, err := NewFileWriter(filename, 10*time.Millisecond, 0, -1)
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("Hello World\n"))
+	require.NoError(t, err)
+	time.Sleep(20 * time.Millisecond)
+	_, err = w.Write([]byte("Hello World\n"))
+	require.NoError(t, err)
+	require.Len(t, archives(t, dir), 1)
+}
+
+func TestFileWriter_MaxArchives(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "test.log")
+	w, err := NewFileWriter(filename, 0, 10, 2)
+	require.NoError(t, err)
+	defer w.Close()
+
+	for i := 0; i < 5; i++ {
+		_, err = w.Write([]byte("Hello World\n"))
+		require.NoError(t, err)
+	}
+	require.Len(t, archives(t, dir), 2)
+}
+
+func TestFileWriter_Closed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rotate")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	w, err := NewFileWriter(filepath.Join(dir, "test.log"), 0, 0, 5)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	require.NoError(t, w.Close())
+
+	_, err = w.Write([]byte("Hello World\n"))
+	require.Error(t, err)
+}
@@ -0,0 +1,41 @@
+package secret
+
+import (
+	"fmt"
+	"os"
+)
+
+// Env reads secrets from environment variables.
+type Env struct {
+	Prefix string `toml:"prefix"`
+}
+
+var envSampleConfig = `
+  ## Unique identifier of the store, secrets are referenced as @{id:key}.
+  id = "env"
+
+  ## Prefix prepended to the key to form the variable name, ie with a prefix
+  ## of "TELEGRAF_" the secret @{env:PASSWORD} is read from the variable
+  ## TELEGRAF_PASSWORD.
+  # prefix = ""
+`
+
+func (e *Env) SampleConfig() string {
+	return envSampleConfig
+}
+
+func (e *Env) Description() string {
+	return "Read secrets from environment variables"
+}
+
+func (e *Env) Get(key string) (string, error) {
+	value, ok := os.LookupEnv(e.Prefix + key)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", e.Prefix+key)
+	}
+	return value, nil
+}
+
+func init() {
+	Add("env", func() Store { return &Env{} })
+}
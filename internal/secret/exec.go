@@ -0,0 +1,64 @@
+package secret
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// Exec reads secrets from the output of a command.
+type Exec struct {
+	Command []string          `toml:"command"`
+	Timeout internal.Duration `toml:"timeout"`
+}
+
+var execSampleConfig = `
+  ## Unique identifier of the store, secrets are referenced as @{id:key}.
+  id = "exec"
+
+  ## Command to run, the key is passed as the last argument and the secret is
+  ## read from standard output.  Trailing newlines are removed.
+  command = ["/usr/local/bin/get-secret"]
+
+  ## Timeout for the command to complete.
+  # timeout = "5s"
+`
+
+func (e *Exec) SampleConfig() string {
+	return execSampleConfig
+}
+
+func (e *Exec) Description() string {
+	return "Read secrets from the output of a command"
+}
+
+func (e *Exec) Get(key string) (string, error) {
+	if len(e.Command) == 0 {
+		return "", errors.New("no command configured")
+	}
+
+	args := append(append([]string{}, e.Command[1:]...), key)
+	cmd := exec.Command(e.Command[0], args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	timeout := e.Timeout.Duration
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	if err := internal.RunTimeout(cmd, timeout); err != nil {
+		return "", fmt.Errorf("%s: %v: %s", e.Command[0], err,
+			strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+func init() {
+	Add("exec", func() Store { return &Exec{} })
+}
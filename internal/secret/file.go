@@ -0,0 +1,51 @@
+package secret
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+// File reads secrets from files in a directory, one secret per file, as
+// mounted by Docker and Kubernetes secrets.
+type File struct {
+	Directory string `toml:"directory"`
+}
+
+var fileSampleConfig = `
+  ## Unique identifier of the store, secrets are referenced as @{id:key}.
+  id = "file"
+
+  ## Directory containing the secrets, the secret @{file:password} is read
+  ## from the file "password" in this directory.  Trailing newlines are
+  ## removed.
+  directory = "/run/secrets"
+`
+
+func (f *File) SampleConfig() string {
+	return fileSampleConfig
+}
+
+func (f *File) Description() string {
+	return "Read secrets from files in a directory"
+}
+
+func (f *File) Get(key string) (string, error) {
+	if f.Directory == "" {
+		return "", errors.New("no directory configured")
+	}
+	if key != filepath.Base(key) || key == "." || key == ".." {
+		return "", errors.New("key must be a file name")
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(f.Directory, key))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+func init() {
+	Add("file", func() Store { return &File{} })
+}
@@ -0,0 +1,217 @@
+// Package secret implements secret stores that plugin configurations can
+// reference instead of containing credentials in plaintext.
+//
+// A reference has the form @{store:key}, where store is the id of a
+// configured secret store and key is looked up in that store.  References
+// are resolved each time the secret is used, values are cached for the
+// store's refresh interval.
+package secret
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Store looks up secrets by key.
+type Store interface {
+	// SampleConfig returns the default configuration of the store.
+	SampleConfig() string
+
+	// Description returns a one-sentence description of the store.
+	Description() string
+
+	// Get returns the secret for key.
+	Get(key string) (string, error)
+}
+
+// Creator returns a new, unconfigured Store.
+type Creator func() Store
+
+// Stores contains the available secret store types, by name.
+var Stores = map[string]Creator{}
+
+// Add makes a secret store type available.
+func Add(name string, creator Creator) {
+	Stores[name] = creator
+}
+
+// StoreConfig contains the settings common to all secret stores.
+type StoreConfig struct {
+	// ID is the name used to reference the store, ie @{id:key}.
+	ID string
+
+	// RefreshInterval is how long values are cached before they are read
+	// again from the store.  Zero caches values until Refresh is called.
+	RefreshInterval time.Duration
+}
+
+type cached struct {
+	value   string
+	expires time.Time
+}
+
+type registeredStore struct {
+	sync.Mutex
+	store  Store
+	config StoreConfig
+	cache  map[string]cached
+}
+
+var (
+	mu     sync.RWMutex
+	stores = map[string]*registeredStore{}
+)
+
+// Register makes the store available to references by its id, replacing any
+// store previously registered with the same id.
+func Register(config StoreConfig, store Store) {
+	mu.Lock()
+	defer mu.Unlock()
+	stores[config.ID] = &registeredStore{
+		store:  store,
+		config: config,
+		cache:  make(map[string]cached),
+	}
+}
+
+// Refresh drops all cached values so that they are read again from their
+// stores on next use.
+func Refresh() {
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, rs := range stores {
+		rs.Lock()
+		rs.cache = make(map[string]cached)
+		rs.Unlock()
+	}
+}
+
+// Get returns the secret key from the store with the given id.
+func Get(id, key string) (string, error) {
+	mu.RLock()
+	rs, ok := stores[id]
+	mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("unknown secret store %q", id)
+	}
+
+	rs.Lock()
+	defer rs.Unlock()
+
+	now := time.Now()
+	if c, ok := rs.cache[key]; ok {
+		if c.expires.IsZero() || now.Before(c.expires) {
+			return c.value, nil
+		}
+	}
+
+	value, err := rs.store.Get(key)
+	if err != nil {
+		return "", fmt.Errorf("secret %q in store %q: %v", key, id, err)
+	}
+
+	c := cached{value: value}
+	if rs.config.RefreshInterval > 0 {
+		c.expires = now.Add(rs.config.RefreshInterval)
+	}
+	rs.cache[key] = c
+	return value, nil
+}
+
+var referenceRe = regexp.MustCompile(`@\{([^:{}]+):([^{}]+)\}`)
+
+// Resolve returns s with all secret references replaced by their values.
+func Resolve(s string) (string, error) {
+	var err error
+	resolved := referenceRe.ReplaceAllStringFunc(s, func(ref string) string {
+		if err != nil {
+			return ""
+		}
+		m := referenceRe.FindStringSubmatch(ref)
+		var value string
+		value, err = Get(m[1], m[2])
+		return value
+	})
+	if err != nil {
+		return "", err
+	}
+	return resolved, nil
+}
+
+// Secret is a configuration value that may contain secret references.  The
+// references are resolved when the value is used instead of when the
+// configuration is loaded, so that secrets are not kept in memory longer
+// than needed and changes in the stores are picked up.
+type Secret struct {
+	raw string
+	// file is the file the value is read from instead of raw, see
+	// NewFileSecret.
+	file string
+}
+
+// NewSecret returns a Secret for the given value.
+func NewSecret(s string) Secret {
+	return Secret{raw: s}
+}
+
+// NewFileSecret returns a Secret whose value is the content of the file, as
+// mounted by Docker and Kubernetes secrets.  The file is read each time the
+// value is used, trailing newlines are removed and references are not
+// resolved.
+func NewFileSecret(filename string) Secret {
+	return Secret{file: filename}
+}
+
+// UnmarshalTOML parses a Secret from a TOML string.
+func (s *Secret) UnmarshalTOML(b []byte) error {
+	uq, err := strconv.Unquote(string(b))
+	if err != nil {
+		// literal strings use single quotes
+		if len(b) < 2 || b[0] != '\'' || b[len(b)-1] != '\'' {
+			return fmt.Errorf("invalid secret %s, must be a string", b)
+		}
+		uq = string(b[1 : len(b)-1])
+	}
+	s.raw = uq
+	return nil
+}
+
+// Get returns the value with all secret references resolved.
+func (s Secret) Get() (string, error) {
+	if s.file != "" {
+		data, err := ioutil.ReadFile(s.file)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	return Resolve(s.raw)
+}
+
+// IsEmpty returns true if no value is set.
+func (s Secret) IsEmpty() bool {
+	return s.raw == "" && s.file == ""
+}
+
+// IsReference returns true if the value contains secret references.
+func (s Secret) IsReference() bool {
+	return referenceRe.MatchString(s.raw)
+}
+
+// String returns the value with any plaintext secret redacted, so that it
+// is safe to log.  References are returned unresolved, and the secrets of
+// files as the name of their file.
+func (s Secret) String() string {
+	if s.file != "" {
+		return "<file " + s.file + ">"
+	}
+	if s.raw == "" || s.IsReference() {
+		return s.raw
+	}
+	return "<redacted>"
+}
@@ -0,0 +1,135 @@
+package secret
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type countingStore struct {
+	values map[string]string
+	reads  int
+}
+
+func (s *countingStore) SampleConfig() string { return "" }
+func (s *countingStore) Description() string  { return "" }
+func (s *countingStore) Get(key string) (string, error) {
+	s.reads++
+	return s.values[key], nil
+}
+
+func TestResolve(t *testing.T) {
+	Register(StoreConfig{ID: "test"}, &countingStore{
+		values: map[string]string{"user": "alice", "password": "hunter2"},
+	})
+
+	s, err := Resolve("@{test:user}:@{test:password}@localhost")
+	require.NoError(t, err)
+	assert.Equal(t, "alice:hunter2@localhost", s)
+
+	s, err = Resolve("no secrets here")
+	require.NoError(t, err)
+	assert.Equal(t, "no secrets here", s)
+
+	_, err = Resolve("@{missing:user}")
+	assert.Error(t, err)
+}
+
+func TestGetCachesUntilRefresh(t *testing.T) {
+	store := &countingStore{values: map[string]string{"key": "a"}}
+	Register(StoreConfig{ID: "cached"}, store)
+
+	for i := 0; i < 3; i++ {
+		v, err := Get("cached", "key")
+		require.NoError(t, err)
+		assert.Equal(t, "a", v)
+	}
+	assert.Equal(t, 1, store.reads)
+
+	store.values["key"] = "b"
+	Refresh()
+	v, err := Get("cached", "key")
+	require.NoError(t, err)
+	assert.Equal(t, "b", v)
+	assert.Equal(t, 2, store.reads)
+}
+
+func TestGetRefreshInterval(t *testing.T) {
+	store := &countingStore{values: map[string]string{"key": "a"}}
+	Register(StoreConfig{ID: "expiring", RefreshInterval: time.Nanosecond}, store)
+
+	_, err := Get("expiring", "key")
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond)
+	_, err = Get("expiring", "key")
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.reads)
+}
+
+func TestSecret(t *testing.T) {
+	os.Setenv("TELEGRAF_TEST_SECRET", "hunter2")
+	defer os.Unsetenv("TELEGRAF_TEST_SECRET")
+	Register(StoreConfig{ID: "env"}, &Env{Prefix: "TELEGRAF_TEST_"})
+
+	var s Secret
+	require.NoError(t, s.UnmarshalTOML([]byte(`"@{env:SECRET}"`)))
+	assert.True(t, s.IsReference())
+	assert.Equal(t, "@{env:SECRET}", s.String())
+	v, err := s.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", v)
+
+	s = NewSecret("plaintext")
+	assert.Equal(t, "<redacted>", s.String())
+	v, err = s.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "plaintext", v)
+}
+
+func TestFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "password"), []byte("hunter2\n"), 0600)
+	require.NoError(t, err)
+
+	f := &File{Directory: dir}
+	v, err := f.Get("password")
+	require.NoError(t, err)
+	assert.Equal(t, "hunter2", v)
+
+	_, err = f.Get("../password")
+	assert.Error(t, err)
+}
+
+func TestFileSecret(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "api_key")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("@{env:KEY}\r\n"), 0600))
+
+	s := NewFileSecret(filename)
+	assert.False(t, s.IsEmpty())
+	assert.False(t, s.IsReference())
+	assert.Equal(t, "<file "+filename+">", s.String())
+	v, err := s.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "@{env:KEY}", v)
+
+	// The file is read again on each use.
+	require.NoError(t, ioutil.WriteFile(filename, []byte("rotated\n"), 0600))
+	v, err = s.Get()
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", v)
+
+	_, err = NewFileSecret(filepath.Join(dir, "missing")).Get()
+	assert.Error(t, err)
+}
@@ -0,0 +1,185 @@
+package secret
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+)
+
+// Vault reads secrets from a HashiCorp Vault KV secrets engine.
+type Vault struct {
+	Address   string            `toml:"address"`
+	Token     string            `toml:"token"`
+	TokenFile string            `toml:"token_file"`
+	Mount     string            `toml:"mount"`
+	KVVersion int               `toml:"kv_version"`
+	Timeout   internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	once   sync.Once
+	client *http.Client
+	err    error
+}
+
+var vaultSampleConfig = `
+  ## Unique identifier of the store, secrets are referenced as @{id:key}.
+  id = "vault"
+
+  ## Address of the Vault server.
+  address = "https://127.0.0.1:8200"
+
+  ## Token used to authenticate, if token_file is set the token is read from
+  ## the file on each request, allowing the token to be renewed by an agent
+  ## such as Vault Agent.  Defaults to the VAULT_TOKEN environment variable.
+  # token = ""
+  # token_file = ""
+
+  ## Mount path and version of the KV secrets engine.  Keys are given as
+  ## "path#field", ie @{vault:telegraf/cmp#api_key}; the field defaults to
+  ## "value".
+  # mount = "secret"
+  # kv_version = 2
+
+  ## Timeout for HTTP requests.
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+func (v *Vault) SampleConfig() string {
+	return vaultSampleConfig
+}
+
+func (v *Vault) Description() string {
+	return "Read secrets from a HashiCorp Vault KV secrets engine"
+}
+
+func (v *Vault) Get(key string) (string, error) {
+	v.once.Do(func() {
+		v.client, v.err = v.createClient()
+	})
+	if v.err != nil {
+		return "", v.err
+	}
+
+	path, field := key, "value"
+	if i := strings.LastIndex(key, "#"); i >= 0 {
+		path, field = key[:i], key[i+1:]
+	}
+
+	mount := strings.Trim(v.Mount, "/")
+	if mount == "" {
+		mount = "secret"
+	}
+	url := strings.TrimRight(v.Address, "/") + "/v1/" + mount + "/"
+	if v.KVVersion != 1 {
+		url += "data/"
+	}
+	url += strings.Trim(path, "/")
+
+	token, err := v.token()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", token)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned HTTP status %s", resp.Status)
+	}
+
+	var secret struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &secret); err != nil {
+		return "", err
+	}
+
+	data := secret.Data
+	if v.KVVersion != 1 {
+		// KV version 2 nests the secret inside the version metadata.
+		data, _ = data["data"].(map[string]interface{})
+	}
+	value, ok := data[field]
+	if !ok {
+		return "", fmt.Errorf("field %q not found", field)
+	}
+	s, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("field %q is not a string", field)
+	}
+	return s, nil
+}
+
+func (v *Vault) token() (string, error) {
+	if v.TokenFile != "" {
+		data, err := ioutil.ReadFile(v.TokenFile)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(string(data)), nil
+	}
+	if v.Token != "" {
+		return v.Token, nil
+	}
+	if token := os.Getenv("VAULT_TOKEN"); token != "" {
+		return token, nil
+	}
+	return "", errors.New("no token configured")
+}
+
+func (v *Vault) createClient() (*http.Client, error) {
+	if v.Address == "" {
+		return nil, errors.New("no address configured")
+	}
+
+	tlsCfg, err := v.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := v.Timeout.Duration
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: timeout,
+	}, nil
+}
+
+func init() {
+	Add("vault", func() Store { return &Vault{KVVersion: 2} })
+}
@@ -0,0 +1,104 @@
+package tls
+
+import (
+	"crypto/tls"
+	"fmt"
+)
+
+var tlsVersionMap = map[string]uint16{
+	"TLS10": tls.VersionTLS10,
+	"TLS11": tls.VersionTLS11,
+	"TLS12": tls.VersionTLS12,
+}
+
+var tlsCipherMap = map[string]uint16{
+	"TLS_RSA_WITH_RC4_128_SHA":                tls.TLS_RSA_WITH_RC4_128_SHA,
+	"TLS_RSA_WITH_3DES_EDE_CBC_SHA":           tls.TLS_RSA_WITH_3DES_EDE_CBC_SHA,
+	"TLS_RSA_WITH_AES_128_CBC_SHA":            tls.TLS_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_RSA_WITH_AES_256_CBC_SHA":            tls.TLS_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_RSA_WITH_AES_128_CBC_SHA256":         tls.TLS_RSA_WITH_AES_128_CBC_SHA256,
+	"TLS_RSA_WITH_AES_128_GCM_SHA256":         tls.TLS_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_RSA_WITH_AES_256_GCM_SHA384":         tls.TLS_RSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_ECDSA_WITH_RC4_128_SHA":        tls.TLS_ECDHE_ECDSA_WITH_RC4_128_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA":    tls.TLS_ECDHE_ECDSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_RC4_128_SHA":          tls.TLS_ECDHE_RSA_WITH_RC4_128_SHA,
+	"TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA":     tls.TLS_ECDHE_RSA_WITH_3DES_EDE_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA,
+	"TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA":      tls.TLS_ECDHE_RSA_WITH_AES_256_CBC_SHA,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_CBC_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_CBC_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256":   tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256": tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384":   tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384": tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	"TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305":    tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+	"TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305":  tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+}
+
+// ParseCiphers returns the cipher suite IDs for the cipher suite names, such
+// as "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256".
+func ParseCiphers(ciphers []string) ([]uint16, error) {
+	suites := make([]uint16, 0, len(ciphers))
+	for _, cipher := range ciphers {
+		v, ok := tlsCipherMap[cipher]
+		if !ok {
+			return nil, fmt.Errorf("unsupported cipher suite %q", cipher)
+		}
+		suites = append(suites, v)
+	}
+	return suites, nil
+}
+
+// ParseTLSVersion returns the TLS version number for the version name, one of
+// "TLS10", "TLS11" or "TLS12".
+func ParseTLSVersion(version string) (uint16, error) {
+	if v, ok := tlsVersionMap[version]; ok {
+		return v, nil
+	}
+	return 0, fmt.Errorf("unsupported TLS version %q", version)
+}
+
+// tlsOptions are the options common to the client and server configs.
+type tlsOptions struct {
+	minVersion   string
+	maxVersion   string
+	cipherSuites []string
+}
+
+func (o tlsOptions) isSet() bool {
+	return o.minVersion != "" || o.maxVersion != "" || len(o.cipherSuites) != 0
+}
+
+// apply sets the versions and cipher suites on the config.
+func (o tlsOptions) apply(config *tls.Config) error {
+	if o.minVersion != "" {
+		v, err := ParseTLSVersion(o.minVersion)
+		if err != nil {
+			return fmt.Errorf("could not parse tls_min_version: %v", err)
+		}
+		config.MinVersion = v
+	}
+
+	if o.maxVersion != "" {
+		v, err := ParseTLSVersion(o.maxVersion)
+		if err != nil {
+			return fmt.Errorf("could not parse tls_max_version: %v", err)
+		}
+		config.MaxVersion = v
+	}
+
+	if config.MinVersion != 0 && config.MaxVersion != 0 && config.MinVersion > config.MaxVersion {
+		return fmt.Errorf("tls_min_version %s is greater than tls_max_version %s",
+			o.minVersion, o.maxVersion)
+	}
+
+	if len(o.cipherSuites) != 0 {
+		suites, err := ParseCiphers(o.cipherSuites)
+		if err != nil {
+			return fmt.Errorf("could not parse tls_cipher_suites: %v", err)
+		}
+		config.CipherSuites = suites
+	}
+	return nil
+}
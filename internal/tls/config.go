@@ -14,6 +14,10 @@ type ClientConfig struct {
 	TLSKey             string `toml:"tls_key"`
 	InsecureSkipVerify bool   `toml:"insecure_skip_verify"`
 
+	TLSMinVersion   string   `toml:"tls_min_version"`
+	TLSMaxVersion   string   `toml:"tls_max_version"`
+	TLSCipherSuites []string `toml:"tls_cipher_suites"`
+
 	// Deprecated in 1.7; use TLS variables above
 	SSLCA   string `toml:"ssl_ca"`
 	SSLCert string `toml:"ssl_cert"`
@@ -25,6 +29,10 @@ type ServerConfig struct {
 	TLSCert           string   `toml:"tls_cert"`
 	TLSKey            string   `toml:"tls_key"`
 	TLSAllowedCACerts []string `toml:"tls_allowed_cacerts"`
+
+	TLSMinVersion   string   `toml:"tls_min_version"`
+	TLSMaxVersion   string   `toml:"tls_max_version"`
+	TLSCipherSuites []string `toml:"tls_cipher_suites"`
 }
 
 // TLSConfig returns a tls.Config, may be nil without error if TLS is not
@@ -45,7 +53,12 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 	// want TLS, this will require using another option to determine.  In the
 	// case of an HTTP plugin, you could use `https`.  Other plugins may need
 	// the dedicated option `TLSEnable`.
-	if c.TLSCA == "" && c.TLSKey == "" && c.TLSCert == "" && !c.InsecureSkipVerify {
+	opts := tlsOptions{
+		minVersion:   c.TLSMinVersion,
+		maxVersion:   c.TLSMaxVersion,
+		cipherSuites: c.TLSCipherSuites,
+	}
+	if c.TLSCA == "" && c.TLSKey == "" && c.TLSCert == "" && !c.InsecureSkipVerify && !opts.isSet() {
 		return nil, nil
 	}
 
@@ -54,6 +67,10 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 		Renegotiation:      tls.RenegotiateNever,
 	}
 
+	if err := opts.apply(tlsConfig); err != nil {
+		return nil, err
+	}
+
 	if c.TLSCA != "" {
 		pool, err := makeCertPool([]string{c.TLSCA})
 		if err != nil {
@@ -63,10 +80,14 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 	}
 
 	if c.TLSCert != "" && c.TLSKey != "" {
-		err := loadCertificate(tlsConfig, c.TLSCert, c.TLSKey)
+		reloader, err := newCertificateReloader(c.TLSCert, c.TLSKey)
 		if err != nil {
 			return nil, err
 		}
+		// Certificates is still set for the plugins passing the certificate
+		// on to their clients, handshakes use GetClientCertificate.
+		tlsConfig.Certificates = []tls.Certificate{*reloader.cert}
+		tlsConfig.GetClientCertificate = reloader.GetClientCertificate
 	}
 
 	return tlsConfig, nil
@@ -75,12 +96,21 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 // TLSConfig returns a tls.Config, may be nil without error if TLS is not
 // configured.
 func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
-	if c.TLSCert == "" && c.TLSKey == "" && len(c.TLSAllowedCACerts) == 0 {
+	opts := tlsOptions{
+		minVersion:   c.TLSMinVersion,
+		maxVersion:   c.TLSMaxVersion,
+		cipherSuites: c.TLSCipherSuites,
+	}
+	if c.TLSCert == "" && c.TLSKey == "" && len(c.TLSAllowedCACerts) == 0 && !opts.isSet() {
 		return nil, nil
 	}
 
 	tlsConfig := &tls.Config{}
 
+	if err := opts.apply(tlsConfig); err != nil {
+		return nil, err
+	}
+
 	if len(c.TLSAllowedCACerts) != 0 {
 		pool, err := makeCertPool(c.TLSAllowedCACerts)
 		if err != nil {
@@ -1,6 +1,7 @@
 package tls_test
 
 import (
+	cryptotls "crypto/tls"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -78,6 +79,39 @@ func TestClientConfig(t *testing.T) {
 			expNil: false,
 			expErr: false,
 		},
+		{
+			name: "versions and cipher suites",
+			client: tls.ClientConfig{
+				TLSMinVersion:   "TLS11",
+				TLSMaxVersion:   "TLS12",
+				TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+			},
+		},
+		{
+			name: "invalid min version",
+			client: tls.ClientConfig{
+				TLSMinVersion: "SSL30",
+			},
+			expNil: true,
+			expErr: true,
+		},
+		{
+			name: "min version greater than max version",
+			client: tls.ClientConfig{
+				TLSMinVersion: "TLS12",
+				TLSMaxVersion: "TLS10",
+			},
+			expNil: true,
+			expErr: true,
+		},
+		{
+			name: "invalid cipher suite",
+			client: tls.ClientConfig{
+				TLSCipherSuites: []string{"TLS_NULL"},
+			},
+			expNil: true,
+			expErr: true,
+		},
 		{
 			name: "support deprecated ssl field names",
 			client: tls.ClientConfig{
@@ -186,6 +220,22 @@ func TestServerConfig(t *testing.T) {
 	}
 }
 
+func TestClientConfig_Versions(t *testing.T) {
+	client := tls.ClientConfig{
+		TLSCA:           pki.CACertPath(),
+		TLSMinVersion:   "TLS11",
+		TLSMaxVersion:   "TLS12",
+		TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+	}
+
+	tlsConfig, err := client.TLSConfig()
+	require.NoError(t, err)
+	require.False(t, tlsConfig.InsecureSkipVerify)
+	require.Equal(t, uint16(cryptotls.VersionTLS11), tlsConfig.MinVersion)
+	require.Equal(t, uint16(cryptotls.VersionTLS12), tlsConfig.MaxVersion)
+	require.Equal(t, []uint16{cryptotls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256}, tlsConfig.CipherSuites)
+}
+
 func TestConnect(t *testing.T) {
 	clientConfig := tls.ClientConfig{
 		TLSCA:   pki.CACertPath(),
@@ -0,0 +1,96 @@
+package tls
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+type fileVersion struct {
+	modTime time.Time
+	size    int64
+}
+
+func statFile(filename string) (fileVersion, error) {
+	info, err := os.Stat(filename)
+	if err != nil {
+		return fileVersion{}, err
+	}
+	return fileVersion{modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// certificateReloader provides a client certificate loaded from a certificate
+// and key file, which is loaded again when one of the files changes so that
+// renewed certificates are used without a restart.
+type certificateReloader struct {
+	certFile string
+	keyFile  string
+
+	mu          sync.Mutex
+	cert        *tls.Certificate
+	certVersion fileVersion
+	keyVersion  fileVersion
+}
+
+func newCertificateReloader(certFile, keyFile string) (*certificateReloader, error) {
+	r := &certificateReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *certificateReloader) load() error {
+	certVersion, err := statFile(r.certFile)
+	if err != nil {
+		return fmt.Errorf("could not load keypair %s:%s: %v", r.certFile, r.keyFile, err)
+	}
+	keyVersion, err := statFile(r.keyFile)
+	if err != nil {
+		return fmt.Errorf("could not load keypair %s:%s: %v", r.certFile, r.keyFile, err)
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("could not load keypair %s:%s: %v", r.certFile, r.keyFile, err)
+	}
+
+	r.cert = &cert
+	r.certVersion = certVersion
+	r.keyVersion = keyVersion
+	return nil
+}
+
+// changed returns true if one of the files changed since it was loaded.
+func (r *certificateReloader) changed() bool {
+	certVersion, err := statFile(r.certFile)
+	if err != nil {
+		return false
+	}
+	keyVersion, err := statFile(r.keyFile)
+	if err != nil {
+		return false
+	}
+	return certVersion != r.certVersion || keyVersion != r.keyVersion
+}
+
+// GetClientCertificate returns the client certificate, see
+// tls.Config.GetClientCertificate.  If the files changed but cannot be
+// loaded, for example because only one of them has been replaced yet, the
+// previous certificate is returned.
+func (r *certificateReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.changed() {
+		if err := r.load(); err != nil {
+			log.Printf("E! [tls] Error reloading client certificate, using the previous certificate: %v", err)
+		} else {
+			log.Printf("I! [tls] Reloaded client certificate %s", r.certFile)
+		}
+	}
+	return r.cert, nil
+}
@@ -0,0 +1,67 @@
+package tls
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func copyFile(t *testing.T, src, dst string) {
+	buf, err := ioutil.ReadFile(src)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(dst, buf, 0600))
+}
+
+// pkiPath returns the path of a file of the test PKI, testutil cannot be
+// imported here as it depends on this package.
+func pkiPath(name string) string {
+	return filepath.Join("..", "..", "testutil", "pki", name)
+}
+
+func TestCertificateReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+	copyFile(t, pkiPath("clientcert.pem"), certFile)
+	copyFile(t, pkiPath("clientkey.pem"), keyFile)
+
+	r, err := newCertificateReloader(certFile, keyFile)
+	require.NoError(t, err)
+	cert, err := r.GetClientCertificate(nil)
+	require.NoError(t, err)
+	first := cert.Certificate[0]
+
+	// Unchanged files are not loaded again.
+	cert, err = r.GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(first, cert.Certificate[0]))
+
+	// Only the certificate has been replaced, the previous pair is used.
+	future := time.Now().Add(time.Hour)
+	copyFile(t, pkiPath("servercert.pem"), certFile)
+	require.NoError(t, os.Chtimes(certFile, future, future))
+	cert, err = r.GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.True(t, bytes.Equal(first, cert.Certificate[0]))
+
+	copyFile(t, pkiPath("serverkey.pem"), keyFile)
+	require.NoError(t, os.Chtimes(keyFile, future, future))
+	cert, err = r.GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.False(t, bytes.Equal(first, cert.Certificate[0]))
+}
+
+func TestCertificateReloader_Invalid(t *testing.T) {
+	_, err := newCertificateReloader(pkiPath("clientkey.pem"), pkiPath("clientkey.pem"))
+	require.Error(t, err)
+	_, err = newCertificateReloader("/nonexistent/cert.pem", pkiPath("clientkey.pem"))
+	require.Error(t, err)
+}
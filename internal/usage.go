@@ -11,14 +11,22 @@ Usage:
 The commands & flags are:
 
   config              print out full sample configuration to stdout
+  config effective    print the configuration as loaded from --config and
+                      --config-directory, including defaults, with credentials
+                      redacted
+  plugins [--json]    print the available plugins; with --json, print every
+                      plugin with its description and sample config as JSON
   version             print the version to stdout
 
   --aggregator-filter <filter>   filter the aggregators to enable, separator is :
+  --cmp-check-coverage           gather metrics, print which have a translation in the
+                                 cmp output, and exit; nothing is sent to CMP
   --config <file>                configuration file to load
   --config-directory <directory> directory containing additional *.conf files
   --debug                        turn on debug logging
   --input-filter <filter>        filter the inputs to enable, separator is :
   --input-list                   print available input plugins.
+  --once                         gather metrics once, write them to the outputs, and exit
   --output-filter <filter>       filter the outputs to enable, separator is :
   --output-list                  print available output plugins.
   --pidfile <file>               file to write our pid to
@@ -28,8 +36,12 @@ The commands & flags are:
   --sample-config                print out full sample configuration
   --test                         gather metrics, print them out, and exit;
                                  processors, aggregators, and outputs are not run
+  --test-wait <seconds>          time in seconds to run service inputs in --test or
+                                 --once mode; by default they are not run in --test mode
   --usage <plugin>               print usage for a plugin, ie, 'telegraf --usage mysql'
   --version                      display the version and exit
+  --watch-config <method>        reload the config when its files change, method is
+                                 "notify" or "poll"
 
 Examples:
 
@@ -39,9 +51,22 @@ Examples:
   # generate config with only cpu input & influxdb output plugins defined
   telegraf --input-filter cpu --output-filter influxdb config
 
+  # print the configuration of the cpu input as telegraf sees it
+  telegraf --config telegraf.conf --input-filter cpu config effective
+
   # run a single telegraf collection, outputing metrics to stdout
   telegraf --config telegraf.conf --test
 
+  # run a single telegraf collection, waiting 10s for service inputs such
+  # as mqtt_consumer, outputing metrics to stdout
+  telegraf --config telegraf.conf --input-filter mqtt_consumer --test --test-wait 10
+
+  # check which metrics of the mysql input the cmp output translates
+  telegraf --config telegraf.conf --input-filter mysql --cmp-check-coverage
+
+  # run a single telegraf collection and write the metrics to the outputs
+  telegraf --config telegraf.conf --once
+
   # run telegraf with all plugins defined in config file
   telegraf --config telegraf.conf
 
@@ -0,0 +1,38 @@
+package logger
+
+import (
+	"bytes"
+
+	"golang.org/x/sys/windows/svc/eventlog"
+)
+
+// eventLogWriter writes the error and warning lines to the Windows Event Log.
+type eventLogWriter struct {
+	log *eventlog.Log
+}
+
+func (w *eventLogWriter) Write(b []byte) (int, error) {
+	line := bytes.TrimSpace(b)
+	switch {
+	case bytes.HasPrefix(line, []byte("E!")):
+		return len(b), w.log.Error(1, string(line))
+	case bytes.HasPrefix(line, []byte("W!")):
+		return len(b), w.log.Warning(1, string(line))
+	}
+	return len(b), nil
+}
+
+// SetupEventLog writes the error and warning messages to the Windows Event
+// Log in addition to the log output, using the event source registered when
+// the service was installed.
+func SetupEventLog(source string) error {
+	l, err := eventlog.Open(source)
+	if err != nil {
+		return err
+	}
+
+	eventLogMu.Lock()
+	eventLog = &eventLogWriter{log: l}
+	eventLogMu.Unlock()
+	return nil
+}
@@ -0,0 +1,185 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// entry is a parsed log line of the form "E! [source] message".
+type entry struct {
+	level   byte
+	source  string
+	message string
+	fields  map[string]interface{}
+}
+
+func parseEntry(b []byte) entry {
+	e := entry{level: 'I'}
+	if prefixRegex.Match(b) {
+		e.level = b[0]
+		b = bytes.TrimPrefix(b[2:], []byte(" "))
+	}
+	b = bytes.TrimRight(b, "\n")
+
+	if len(b) > 0 && b[0] == '[' {
+		if i := bytes.Index(b, []byte("] ")); i > 0 {
+			e.source = string(b[1:i])
+			b = b[i+2:]
+		}
+	}
+	e.message = string(b)
+	return e
+}
+
+var levelNames = map[byte]string{
+	'D': "debug",
+	'I': "info",
+	'W': "warn",
+	'E': "error",
+}
+
+// jsonEntry is the JSON representation of a log line.
+type jsonEntry struct {
+	Time    string                 `json:"time"`
+	Level   string                 `json:"level"`
+	Source  string                 `json:"source,omitempty"`
+	Alias   string                 `json:"alias,omitempty"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
+}
+
+// formatter writes the log lines as text or JSON lines.  The lines must
+// already have a level prefix.
+type formatter struct {
+	writer  io.Writer
+	json    bool
+	limiter *sourceLimiter
+}
+
+func (f *formatter) Write(b []byte) (int, error) {
+	now := time.Now().UTC()
+	if f.limiter == nil && !f.json {
+		return len(b), f.writeText(now, b)
+	}
+
+	e := parseEntry(b)
+	if f.limiter != nil {
+		ok, suppressed := f.limiter.allow(e.source, now)
+		if !ok {
+			return len(b), nil
+		}
+		if suppressed > 0 {
+			summary := entry{
+				level:   'W',
+				source:  e.source,
+				message: fmt.Sprintf("Suppressed %d log messages", suppressed),
+				fields:  map[string]interface{}{"suppressed": suppressed},
+			}
+			if err := f.writeEntry(now, summary, nil); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return len(b), f.writeEntry(now, e, b)
+}
+
+// writeEntry writes the entry, the raw line is written as is in the text
+// format if not nil.
+func (f *formatter) writeEntry(now time.Time, e entry, raw []byte) error {
+	if f.json {
+		return f.writeJSON(now, e)
+	}
+	if raw == nil {
+		line := string(e.level) + "! "
+		if e.source != "" {
+			line += "[" + e.source + "] "
+		}
+		raw = []byte(line + e.message + "\n")
+	}
+	return f.writeText(now, raw)
+}
+
+func (f *formatter) writeText(now time.Time, b []byte) error {
+	line := append([]byte(now.Format(time.RFC3339)+" "), b...)
+	_, err := f.writer.Write(line)
+	return err
+}
+
+func (f *formatter) writeJSON(now time.Time, e entry) error {
+	je := jsonEntry{
+		Time:    now.Format(time.RFC3339Nano),
+		Level:   levelNames[e.level],
+		Source:  e.source,
+		Message: e.message,
+		Fields:  e.fields,
+	}
+	if i := strings.Index(e.source, "::"); i >= 0 {
+		je.Source = e.source[:i]
+		je.Alias = e.source[i+2:]
+	}
+
+	line, err := json.Marshal(je)
+	if err != nil {
+		return err
+	}
+	_, err = f.writer.Write(append(line, '\n'))
+	return err
+}
+
+// sourceLimiter limits the number of lines written by each source in an
+// interval.
+type sourceLimiter struct {
+	limit    int
+	interval time.Duration
+
+	mu      sync.Mutex
+	sources map[string]*sourceWindow
+}
+
+type sourceWindow struct {
+	start      time.Time
+	count      int
+	suppressed int
+}
+
+func newSourceLimiter(limit int, interval time.Duration) *sourceLimiter {
+	return &sourceLimiter{
+		limit:    limit,
+		interval: interval,
+		sources:  make(map[string]*sourceWindow),
+	}
+}
+
+// allow returns if a line of the source should be written, along with the
+// number of lines suppressed in the previous interval when the first line of
+// a new interval is written.
+func (l *sourceLimiter) allow(source string, now time.Time) (bool, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w, ok := l.sources[source]
+	if !ok {
+		w = &sourceWindow{start: now}
+		l.sources[source] = w
+	}
+
+	var suppressed int
+	if now.Sub(w.start) >= l.interval {
+		suppressed = w.suppressed
+		w.start = now
+		w.count = 0
+		w.suppressed = 0
+	}
+
+	w.count++
+	if w.count > l.limit {
+		w.suppressed++
+		return false, 0
+	}
+	return true, suppressed
+}
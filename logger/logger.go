@@ -5,65 +5,139 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"sync"
 	"time"
 
+	"github.com/influxdata/telegraf/internal/rotate"
 	"github.com/influxdata/wlog"
 )
 
 var prefixRegex = regexp.MustCompile("^[DIWE]!")
 
+var (
+	// unfiltered writes to the log output without applying the log level.
+	unfiltered   io.Writer = &telegrafLog{writer: &formatter{writer: os.Stderr}}
+	unfilteredMu sync.Mutex
+)
+
+var (
+	// logFile is the log file opened by SetupLogging, it is closed when
+	// logging is set up again.
+	logFile   io.Closer
+	logFileMu sync.Mutex
+)
+
+var (
+	// eventLog additionally receives all log lines before the log level is
+	// applied, it is set when running as a Windows service.
+	eventLog   io.Writer
+	eventLogMu sync.Mutex
+)
+
 // newTelegrafWriter returns a logging-wrapped writer.
 func newTelegrafWriter(w io.Writer) io.Writer {
 	return &telegrafLog{
-		writer: wlog.NewWriter(w),
+		writer: wlog.NewWriter(&formatter{writer: w}),
 	}
 }
 
+// telegrafLog adds the default level to lines without a level prefix.
 type telegrafLog struct {
 	writer io.Writer
 }
 
 func (t *telegrafLog) Write(b []byte) (n int, err error) {
-	var line []byte
+	eventLogMu.Lock()
+	if eventLog != nil {
+		eventLog.Write(b)
+	}
+	eventLogMu.Unlock()
+
 	if !prefixRegex.Match(b) {
-		line = append([]byte(time.Now().UTC().Format(time.RFC3339)+" I! "), b...)
-	} else {
-		line = append([]byte(time.Now().UTC().Format(time.RFC3339)+" "), b...)
+		b = append([]byte("I! "), b...)
 	}
-	return t.writer.Write(line)
+	return t.writer.Write(b)
+}
+
+// LogConfig contains the log settings.
+type LogConfig struct {
+	// Debug sets the log level to DEBUG.
+	Debug bool
+	// Quiet sets the log level to ERROR.
+	Quiet bool
+	// Logfile directs the logging output to a file.  Empty string is
+	// interpreted as stderr.  If there is an error opening the file the
+	// logger will fallback to stderr.
+	Logfile string
+	// Format is the format of the log lines, text or json.
+	Format string
+
+	// RotationInterval and RotationMaxSize rotate the log file once it is
+	// older or larger, zero disables the rotation.  RotationMaxArchives is
+	// the number of rotated files kept, -1 keeps all of them.
+	RotationInterval    time.Duration
+	RotationMaxSize     int64
+	RotationMaxArchives int
+
+	// RateLimit is the maximum number of lines written by each source, the
+	// agent or a plugin, per RateLimitInterval.  Zero disables the limit.
+	RateLimit         int
+	RateLimitInterval time.Duration
 }
 
 // SetupLogging configures the logging output.
-//   debug   will set the log level to DEBUG
-//   quiet   will set the log level to ERROR
-//   logfile will direct the logging output to a file. Empty string is
-//           interpreted as stderr. If there is an error opening the file the
-//           logger will fallback to stderr.
-func SetupLogging(debug, quiet bool, logfile string) {
+func SetupLogging(config LogConfig) {
 	log.SetFlags(0)
-	if debug {
+	if config.Debug {
 		wlog.SetLevel(wlog.DEBUG)
 	}
-	if quiet {
+	if config.Quiet {
 		wlog.SetLevel(wlog.ERROR)
 	}
 
-	var oFile *os.File
-	if logfile != "" {
-		if _, err := os.Stat(logfile); os.IsNotExist(err) {
-			if oFile, err = os.Create(logfile); err != nil {
-				log.Printf("E! Unable to create %s (%s), using stderr", logfile, err)
-				oFile = os.Stderr
-			}
+	var out io.Writer = os.Stderr
+	var file io.Closer
+	if config.Logfile != "" {
+		w, err := rotate.NewFileWriter(config.Logfile, config.RotationInterval,
+			config.RotationMaxSize, config.RotationMaxArchives)
+		if err != nil {
+			log.Printf("E! Unable to open %s (%s), using stderr", config.Logfile, err)
 		} else {
-			if oFile, err = os.OpenFile(logfile, os.O_APPEND|os.O_WRONLY, os.ModeAppend); err != nil {
-				log.Printf("E! Unable to append to %s (%s), using stderr", logfile, err)
-				oFile = os.Stderr
-			}
+			out = w
+			file = w
 		}
-	} else {
-		oFile = os.Stderr
 	}
 
-	log.SetOutput(newTelegrafWriter(oFile))
+	f := &formatter{writer: out}
+	switch config.Format {
+	case "", "text":
+	case "json":
+		f.json = true
+	default:
+		log.Printf("E! Unknown log format %q, using text", config.Format)
+	}
+	if config.RateLimit > 0 {
+		f.limiter = newSourceLimiter(config.RateLimit, config.RateLimitInterval)
+	}
+
+	log.SetOutput(&telegrafLog{writer: wlog.NewWriter(f)})
+
+	unfilteredMu.Lock()
+	unfiltered = &telegrafLog{writer: f}
+	unfilteredMu.Unlock()
+
+	logFileMu.Lock()
+	if logFile != nil {
+		logFile.Close()
+	}
+	logFile = file
+	logFileMu.Unlock()
+}
+
+// PrintUnfiltered writes the line to the log regardless of the configured
+// log level.  It is used for plugins that have their own log level.
+func PrintUnfiltered(line string) {
+	unfilteredMu.Lock()
+	defer unfilteredMu.Unlock()
+	unfiltered.Write([]byte(line + "\n"))
 }
@@ -2,12 +2,16 @@ package logger
 
 import (
 	"bytes"
+	"encoding/json"
 	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestWriteLogToFile(t *testing.T) {
@@ -15,7 +19,7 @@ func TestWriteLogToFile(t *testing.T) {
 	assert.NoError(t, err)
 	defer func() { os.Remove(tmpfile.Name()) }()
 
-	SetupLogging(false, false, tmpfile.Name())
+	SetupLogging(LogConfig{Logfile: tmpfile.Name()})
 	log.Printf("I! TEST")
 	log.Printf("D! TEST") // <- should be ignored
 
@@ -29,7 +33,7 @@ func TestDebugWriteLogToFile(t *testing.T) {
 	assert.NoError(t, err)
 	defer func() { os.Remove(tmpfile.Name()) }()
 
-	SetupLogging(true, false, tmpfile.Name())
+	SetupLogging(LogConfig{Debug: true, Logfile: tmpfile.Name()})
 	log.Printf("D! TEST")
 
 	f, err := ioutil.ReadFile(tmpfile.Name())
@@ -42,7 +46,7 @@ func TestErrorWriteLogToFile(t *testing.T) {
 	assert.NoError(t, err)
 	defer func() { os.Remove(tmpfile.Name()) }()
 
-	SetupLogging(false, true, tmpfile.Name())
+	SetupLogging(LogConfig{Quiet: true, Logfile: tmpfile.Name()})
 	log.Printf("E! TEST")
 	log.Printf("I! TEST") // <- should be ignored
 
@@ -56,7 +60,7 @@ func TestAddDefaultLogLevel(t *testing.T) {
 	assert.NoError(t, err)
 	defer func() { os.Remove(tmpfile.Name()) }()
 
-	SetupLogging(true, false, tmpfile.Name())
+	SetupLogging(LogConfig{Debug: true, Logfile: tmpfile.Name()})
 	log.Printf("TEST")
 
 	f, err := ioutil.ReadFile(tmpfile.Name())
@@ -64,6 +68,59 @@ func TestAddDefaultLogLevel(t *testing.T) {
 	assert.Equal(t, f[19:], []byte("Z I! TEST\n"))
 }
 
+func TestWriteLogJSON(t *testing.T) {
+	var buf bytes.Buffer
+	w := &telegrafLog{writer: &formatter{writer: &buf, json: true}}
+
+	_, err := w.Write([]byte("E! [outputs.cmp::primary] API call failed\n"))
+	require.NoError(t, err)
+	_, err = w.Write([]byte("message without level\n"))
+	require.NoError(t, err)
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	require.Len(t, lines, 2)
+
+	var e map[string]interface{}
+	require.NoError(t, json.Unmarshal(lines[0], &e))
+	require.NotEmpty(t, e["time"])
+	delete(e, "time")
+	require.Equal(t, map[string]interface{}{
+		"level":   "error",
+		"source":  "outputs.cmp",
+		"alias":   "primary",
+		"message": "API call failed",
+	}, e)
+
+	e = nil
+	require.NoError(t, json.Unmarshal(lines[1], &e))
+	delete(e, "time")
+	require.Equal(t, map[string]interface{}{
+		"level":   "info",
+		"message": "message without level",
+	}, e)
+}
+
+func TestWriteLogRateLimit(t *testing.T) {
+	var buf bytes.Buffer
+	f := &formatter{writer: &buf, limiter: newSourceLimiter(2, time.Hour)}
+	w := &telegrafLog{writer: f}
+
+	for i := 0; i < 5; i++ {
+		w.Write([]byte("D! [outputs.cmp] Skip metric\n"))
+	}
+	w.Write([]byte("I! [agent] Starting\n"))
+	require.Equal(t, 3, bytes.Count(buf.Bytes(), []byte("\n")))
+
+	// The suppressed lines are reported in the next interval.
+	buf.Reset()
+	f.limiter.sources["outputs.cmp"].start = time.Now().Add(-2 * time.Hour)
+	w.Write([]byte("D! [outputs.cmp] Skip metric\n"))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Equal(t, "Z W! [outputs.cmp] Suppressed 3 log messages", lines[0][19:])
+	require.Equal(t, "Z D! [outputs.cmp] Skip metric", lines[1][19:])
+}
+
 func BenchmarkTelegrafLogWrite(b *testing.B) {
 	var msg = []byte("test")
 	var buf bytes.Buffer
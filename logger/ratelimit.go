@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+// RateLimited returns a Logger that writes each message at most once per
+// interval.  Messages are identified by their level and format string, or by
+// their text for the non-formatting methods, so that a message repeated for
+// many different values is still limited.  The number of suppressed messages
+// is added to the next message written.
+func RateLimited(log telegraf.Logger, interval time.Duration) telegraf.Logger {
+	return &rateLimited{
+		log:      log,
+		interval: interval,
+		seen:     make(map[string]*limitState),
+	}
+}
+
+type limitState struct {
+	last       time.Time
+	suppressed int
+}
+
+type rateLimited struct {
+	sync.Mutex
+	log      telegraf.Logger
+	interval time.Duration
+	seen     map[string]*limitState
+}
+
+// allow returns if the message with the given key should be written, along
+// with the number of messages suppressed since it was last written.
+func (r *rateLimited) allow(key string) (bool, int) {
+	r.Lock()
+	defer r.Unlock()
+
+	now := time.Now()
+	state, ok := r.seen[key]
+	if !ok {
+		r.seen[key] = &limitState{last: now}
+		return true, 0
+	}
+	if now.Sub(state.last) < r.interval {
+		state.suppressed++
+		return false, 0
+	}
+
+	suppressed := state.suppressed
+	state.last = now
+	state.suppressed = 0
+	return true, suppressed
+}
+
+func (r *rateLimited) logf(key string, print func(string, ...interface{}),
+	format string, args ...interface{}) {
+	ok, suppressed := r.allow(key)
+	if !ok {
+		return
+	}
+	if suppressed > 0 {
+		format += fmt.Sprintf(" (%d similar messages suppressed)", suppressed)
+	}
+	print(format, args...)
+}
+
+func (r *rateLimited) Errorf(format string, args ...interface{}) {
+	r.logf("E!"+format, r.log.Errorf, format, args...)
+}
+
+func (r *rateLimited) Error(args ...interface{}) {
+	msg := fmt.Sprint(args...)
+	r.logf("E "+msg, r.log.Errorf, "%s", msg)
+}
+
+func (r *rateLimited) Warnf(format string, args ...interface{}) {
+	r.logf("W!"+format, r.log.Warnf, format, args...)
+}
+
+func (r *rateLimited) Warn(args ...interface{}) {
+	msg := fmt.Sprint(args...)
+	r.logf("W "+msg, r.log.Warnf, "%s", msg)
+}
+
+func (r *rateLimited) Infof(format string, args ...interface{}) {
+	r.logf("I!"+format, r.log.Infof, format, args...)
+}
+
+func (r *rateLimited) Info(args ...interface{}) {
+	msg := fmt.Sprint(args...)
+	r.logf("I "+msg, r.log.Infof, "%s", msg)
+}
+
+func (r *rateLimited) Debugf(format string, args ...interface{}) {
+	r.logf("D!"+format, r.log.Debugf, format, args...)
+}
+
+func (r *rateLimited) Debug(args ...interface{}) {
+	msg := fmt.Sprint(args...)
+	r.logf("D "+msg, r.log.Debugf, "%s", msg)
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (r *recordingLogger) record(format string, args ...interface{}) {
+	r.lines = append(r.lines, fmt.Sprintf(format, args...))
+}
+
+func (r *recordingLogger) Errorf(format string, args ...interface{}) { r.record(format, args...) }
+func (r *recordingLogger) Error(args ...interface{})                 { r.record("%s", fmt.Sprint(args...)) }
+func (r *recordingLogger) Warnf(format string, args ...interface{})  { r.record(format, args...) }
+func (r *recordingLogger) Warn(args ...interface{})                  { r.record("%s", fmt.Sprint(args...)) }
+func (r *recordingLogger) Infof(format string, args ...interface{})  { r.record(format, args...) }
+func (r *recordingLogger) Info(args ...interface{})                  { r.record("%s", fmt.Sprint(args...)) }
+func (r *recordingLogger) Debugf(format string, args ...interface{}) { r.record(format, args...) }
+func (r *recordingLogger) Debug(args ...interface{})                 { r.record("%s", fmt.Sprint(args...)) }
+
+func TestRateLimited(t *testing.T) {
+	rec := &recordingLogger{}
+	l := RateLimited(rec, time.Hour)
+
+	l.Debugf("Skip %s", "a")
+	l.Debugf("Skip %s", "b")
+	l.Debugf("Skip %s", "c")
+	l.Errorf("Skip %s", "d")
+	l.Info("other")
+
+	assert.Equal(t, []string{"Skip a", "Skip d", "other"}, rec.lines)
+}
+
+func TestRateLimitedReportsSuppressed(t *testing.T) {
+	rec := &recordingLogger{}
+	l := RateLimited(rec, time.Millisecond)
+
+	l.Warnf("Skip %s", "a")
+	l.Warnf("Skip %s", "b")
+	l.Warnf("Skip %s", "c")
+	time.Sleep(2 * time.Millisecond)
+	l.Warnf("Skip %s", "d")
+
+	assert.Equal(t, []string{
+		"Skip a",
+		"Skip d (2 similar messages suppressed)",
+	}, rec.lines)
+}
@@ -320,6 +320,10 @@ func (c *Ceph) exec(command string) (string, error) {
 
 // CephStatus is used to unmarshal "ceph -s" output
 type CephStatus struct {
+	Health struct {
+		Status        string `json:"status"`
+		OverallStatus string `json:"overall_status"`
+	} `json:"health"`
 	OSDMap struct {
 		OSDMap struct {
 			Epoch          float64 `json:"epoch"`
@@ -356,6 +360,7 @@ func decodeStatus(acc telegraf.Accumulator, input string) error {
 	}
 
 	decoders := []func(telegraf.Accumulator, *CephStatus) error{
+		decodeStatusHealth,
 		decodeStatusOsdmap,
 		decodeStatusPgmap,
 		decodeStatusPgmapState,
@@ -370,6 +375,21 @@ func decodeStatus(acc telegraf.Accumulator, input string) error {
 	return nil
 }
 
+// decodeStatusHealth decodes the health portion of the output of 'ceph -s'
+func decodeStatusHealth(acc telegraf.Accumulator, data *CephStatus) error {
+	// releases before luminous only report the overall status
+	status := data.Health.Status
+	if status == "" {
+		status = data.Health.OverallStatus
+	}
+	fields := map[string]interface{}{
+		"status":         status,
+		"overall_status": data.Health.OverallStatus,
+	}
+	acc.AddFields("ceph_health", fields, map[string]string{})
+	return nil
+}
+
 // decodeStatusOsdmap decodes the OSD map portion of the output of 'ceph -s'
 func decodeStatusOsdmap(acc telegraf.Accumulator, data *CephStatus) error {
 	fields := map[string]interface{}{
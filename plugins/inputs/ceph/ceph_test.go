@@ -848,6 +848,14 @@ var clusterStatusDump = `
 `
 
 var cephStatusResults = []expectedResult{
+	{
+		metric: "ceph_health",
+		fields: map[string]interface{}{
+			"status":         "HEALTH_OK",
+			"overall_status": "HEALTH_OK",
+		},
+		tags: map[string]string{},
+	},
 	{
 		metric: "ceph_osdmap",
 		fields: map[string]interface{}{
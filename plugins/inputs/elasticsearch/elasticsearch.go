@@ -75,6 +75,16 @@ type clusterStats struct {
 	Nodes       interface{} `json:"nodes"`
 }
 
+type indexStat struct {
+	Primaries interface{} `json:"primaries"`
+	Total     interface{} `json:"total"`
+}
+
+type indicesStats struct {
+	All     indexStat            `json:"_all"`
+	Indices map[string]indexStat `json:"indices"`
+}
+
 type catMaster struct {
 	NodeID   string `json:"id"`
 	NodeIP   string `json:"ip"`
@@ -115,6 +125,13 @@ const sampleConfig = `
   ## "breaker". Per default, all stats are gathered.
   # node_stats = ["jvm", "http"]
 
+  ## indices_include is a list of the indices, or index patterns, whose stats
+  ## are gathered per index, with the index_name tag.  "_all" gathers the
+  ## stats of all indices.  Per default, no index stats are gathered.  Like
+  ## the cluster stats, they are only gathered from the master node if
+  ## cluster_stats_only_from_master and local are true.
+  # indices_include = ["_all"]
+
   ## Optional TLS Config
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
@@ -134,6 +151,7 @@ type Elasticsearch struct {
 	ClusterStats               bool
 	ClusterStatsOnlyFromMaster bool
 	NodeStats                  []string
+	IndicesInclude             []string `toml:"indices_include"`
 	tls.ClientConfig
 
 	client                  *http.Client
@@ -194,7 +212,7 @@ func (e *Elasticsearch) Gather(acc telegraf.Accumulator) error {
 			url := e.nodeStatsUrl(s)
 			e.isMaster = false
 
-			if e.ClusterStats {
+			if e.ClusterStats || len(e.IndicesInclude) > 0 {
 				// get cat/master information here so NodeStats can determine
 				// whether this node is the Master
 				if err := e.setCatMaster(s + "/_cat/master"); err != nil {
@@ -226,6 +244,13 @@ func (e *Elasticsearch) Gather(acc telegraf.Accumulator) error {
 					return
 				}
 			}
+
+			if len(e.IndicesInclude) > 0 && (e.isMaster || !e.ClusterStatsOnlyFromMaster || !e.Local) {
+				if err := e.gatherIndicesStats(s+"/"+strings.Join(e.IndicesInclude, ",")+"/_stats", acc); err != nil {
+					acc.AddError(fmt.Errorf(mask.ReplaceAllString(err.Error(), "http(s)://XXX:XXX@")))
+					return
+				}
+			}
 		}(serv, acc)
 	}
 
@@ -283,7 +308,7 @@ func (e *Elasticsearch) gatherNodeStats(url string, acc telegraf.Accumulator) er
 			"cluster_name": nodeStats.ClusterName,
 		}
 
-		if e.ClusterStats {
+		if e.ClusterStats || len(e.IndicesInclude) > 0 {
 			// check for master
 			e.isMaster = (id == e.catMasterResponseTokens[0])
 		}
@@ -403,6 +428,41 @@ func (e *Elasticsearch) gatherClusterStats(url string, acc telegraf.Accumulator)
 	return nil
 }
 
+func (e *Elasticsearch) gatherIndicesStats(url string, acc telegraf.Accumulator) error {
+	indicesStats := &indicesStats{}
+	if err := e.gatherJsonData(url, indicesStats); err != nil {
+		return err
+	}
+	now := time.Now()
+
+	// The stats of all indices are tagged with the index name _all.
+	indices := map[string]indexStat{"_all": indicesStats.All}
+	for name, stat := range indicesStats.Indices {
+		indices[name] = stat
+	}
+
+	for name, index := range indices {
+		stats := map[string]interface{}{
+			"primaries": index.Primaries,
+			"total":     index.Total,
+		}
+		for p, s := range stats {
+			if s == nil {
+				continue
+			}
+			f := jsonparser.JSONFlattener{}
+			// parse Json, ignoring strings and bools
+			err := f.FlattenJSON("", s)
+			if err != nil {
+				return err
+			}
+			acc.AddFields("elasticsearch_indices_stats_"+p, f.Fields, map[string]string{"index_name": name}, now)
+		}
+	}
+
+	return nil
+}
+
 func (e *Elasticsearch) setCatMaster(url string) error {
 	r, err := e.client.Get(url)
 	if err != nil {
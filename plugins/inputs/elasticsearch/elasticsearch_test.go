@@ -267,6 +267,29 @@ func TestGatherClusterStatsNonMaster(t *testing.T) {
 	checkNodeStatsResult(t, &acc)
 }
 
+func TestGatherIndicesStats(t *testing.T) {
+	es := newElasticsearchWithClient()
+	es.IndicesInclude = []string{"_all"}
+	es.Servers = []string{"http://example.com:9200"}
+	es.client.Transport = newTransportMock(http.StatusOK, indicesStatsResponse)
+
+	var acc testutil.Accumulator
+	require.NoError(t, es.gatherIndicesStats("junk", &acc))
+
+	acc.AssertContainsTaggedFields(t, "elasticsearch_indices_stats_primaries",
+		indicesStatsAllExpected,
+		map[string]string{"index_name": "_all"})
+	acc.AssertContainsTaggedFields(t, "elasticsearch_indices_stats_total",
+		indicesStatsAllExpected,
+		map[string]string{"index_name": "_all"})
+	acc.AssertContainsTaggedFields(t, "elasticsearch_indices_stats_primaries",
+		indicesStatsTwitterPrimariesExpected,
+		map[string]string{"index_name": "twitter"})
+	acc.AssertContainsTaggedFields(t, "elasticsearch_indices_stats_total",
+		indicesStatsTwitterTotalExpected,
+		map[string]string{"index_name": "twitter"})
+}
+
 func newElasticsearchWithClient() *Elasticsearch {
 	es := NewElasticsearch()
 	es.client = &http.Client{}
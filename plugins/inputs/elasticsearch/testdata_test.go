@@ -1154,3 +1154,117 @@ var clusterstatsNodesExpected = map[string]interface{}{
 const IsMasterResult = "SDFsfSDFsdfFSDSDfSFDSDF 10.206.124.66 10.206.124.66 test.host.com "
 
 const IsNotMasterResult = "junk 10.206.124.66 10.206.124.66 test.junk.com "
+
+const indicesStatsResponse = `
+{
+  "_shards": {
+    "total": 2,
+    "successful": 1,
+    "failed": 0
+  },
+  "_all": {
+    "primaries": {
+      "docs": {
+        "count": 4,
+        "deleted": 0
+      },
+      "store": {
+        "size_in_bytes": 16380
+      },
+      "indexing": {
+        "index_total": 4,
+        "index_time_in_millis": 42
+      },
+      "search": {
+        "query_total": 10,
+        "query_time_in_millis": 7
+      }
+    },
+    "total": {
+      "docs": {
+        "count": 4,
+        "deleted": 0
+      },
+      "store": {
+        "size_in_bytes": 16380
+      },
+      "indexing": {
+        "index_total": 4,
+        "index_time_in_millis": 42
+      },
+      "search": {
+        "query_total": 10,
+        "query_time_in_millis": 7
+      }
+    }
+  },
+  "indices": {
+    "twitter": {
+      "primaries": {
+        "docs": {
+          "count": 3,
+          "deleted": 1
+        },
+        "store": {
+          "size_in_bytes": 12288
+        },
+        "indexing": {
+          "index_total": 3,
+          "index_time_in_millis": 30
+        },
+        "search": {
+          "query_total": 8,
+          "query_time_in_millis": 5
+        }
+      },
+      "total": {
+        "docs": {
+          "count": 6,
+          "deleted": 2
+        },
+        "store": {
+          "size_in_bytes": 24576
+        },
+        "indexing": {
+          "index_total": 6,
+          "index_time_in_millis": 61
+        },
+        "search": {
+          "query_total": 8,
+          "query_time_in_millis": 5
+        }
+      }
+    }
+  }
+}
+`
+
+var indicesStatsAllExpected = map[string]interface{}{
+	"docs_count":                    float64(4),
+	"docs_deleted":                  float64(0),
+	"store_size_in_bytes":           float64(16380),
+	"indexing_index_total":          float64(4),
+	"indexing_index_time_in_millis": float64(42),
+	"search_query_total":            float64(10),
+	"search_query_time_in_millis":   float64(7),
+}
+
+var indicesStatsTwitterPrimariesExpected = map[string]interface{}{
+	"docs_count":                    float64(3),
+	"docs_deleted":                  float64(1),
+	"store_size_in_bytes":           float64(12288),
+	"indexing_index_total":          float64(3),
+	"indexing_index_time_in_millis": float64(30),
+	"search_query_total":            float64(8),
+	"search_query_time_in_millis":   float64(5),
+}
+
+var indicesStatsTwitterTotalExpected = map[string]interface{}{
+	"docs_count":                    float64(6),
+	"docs_deleted":                  float64(2),
+	"store_size_in_bytes":           float64(24576),
+	"indexing_index_total":          float64(6),
+	"indexing_index_time_in_millis": float64(61),
+	"search_query_total":            float64(8),
+	"search_query_time_in_millis":   float64(5),
+}
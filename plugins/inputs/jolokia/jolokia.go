@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
 	"net/url"
 	"time"
@@ -259,10 +258,6 @@ func (j *Jolokia) extractValues(measurement string, value interface{}, fields ma
 func (j *Jolokia) Gather(acc telegraf.Accumulator) error {
 
 	if j.jClient == nil {
-		log.Println("W! DEPRECATED: the jolokia plugin has been deprecated " +
-			"in favor of the jolokia2 plugin " +
-			"(https://github.com/influxdata/telegraf/tree/master/plugins/inputs/jolokia2)")
-
 		tr := &http.Transport{ResponseHeaderTimeout: j.ResponseHeaderTimeout.Duration}
 		j.jClient = &JolokiaClientImpl{&http.Client{
 			Transport: tr,
@@ -319,6 +314,10 @@ func (j *Jolokia) Gather(acc telegraf.Accumulator) error {
 }
 
 func init() {
+	inputs.AddDeprecated("jolokia", telegraf.DeprecationInfo{
+		Since:  "1.5.0",
+		Notice: "use the jolokia2 plugin instead",
+	})
 	inputs.Add("jolokia", func() telegraf.Input {
 		return &Jolokia{
 			ResponseHeaderTimeout: DefaultResponseHeaderTimeout,
@@ -11,6 +11,7 @@ import (
 	"github.com/eclipse/paho.mqtt.golang"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
@@ -37,16 +38,13 @@ type MQTTConsumer struct {
 	Servers                []string
 	Topics                 []string
 	Username               string
-	Password               string
+	Password               secret.Secret
 	QoS                    int               `toml:"qos"`
 	ConnectionTimeout      internal.Duration `toml:"connection_timeout"`
 	MaxUndeliveredMessages int               `toml:"max_undelivered_messages"`
 
 	parser parsers.Parser
 
-	// Legacy metric buffer support; deprecated in v0.10.3
-	MetricBuffer int
-
 	PersistentSession bool
 	ClientID          string `toml:"client_id"`
 	tls.ClientConfig
@@ -103,7 +101,8 @@ var sampleConfig = `
   # If empty, a random client ID will be generated.
   client_id = ""
 
-  ## username and password to connect MQTT server.
+  ## username and password to connect MQTT server, the password may
+  ## reference a secret store, ie "@{vault:telegraf/mqtt#password}".
   # username = "telegraf"
   # password = "metricsmetricsmetricsmetrics"
 
@@ -111,6 +110,11 @@ var sampleConfig = `
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
   # tls_key = "/etc/telegraf/key.pem"
+  ## Minimum and maximum TLS version, one of TLS10, TLS11 or TLS12
+  # tls_min_version = "TLS12"
+  # tls_max_version = "TLS12"
+  ## Allowed cipher suites, by default the Go defaults are used
+  # tls_cipher_suites = ["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"]
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
@@ -286,8 +290,11 @@ func (m *MQTTConsumer) createOpts() (*mqtt.ClientOptions, error) {
 	if user != "" {
 		opts.SetUsername(user)
 	}
-	password := m.Password
-	if password != "" {
+	if !m.Password.IsEmpty() {
+		password, err := m.Password.Get()
+		if err != nil {
+			return nil, err
+		}
 		opts.SetPassword(password)
 	}
 
@@ -317,6 +324,16 @@ func (m *MQTTConsumer) createOpts() (*mqtt.ClientOptions, error) {
 }
 
 func init() {
+	inputs.AddDeprecatedOption("mqtt_consumer", "metric_buffer", telegraf.DeprecationInfo{
+		Since:      "0.10.3",
+		ReplacedBy: "max_undelivered_messages",
+	})
+	for _, option := range []string{"ca", "cert", "key"} {
+		inputs.AddDeprecatedOption("mqtt_consumer", "ssl_"+option, telegraf.DeprecationInfo{
+			Since:      "1.7.0",
+			ReplacedBy: "tls_" + option,
+		})
+	}
 	inputs.Add("mqtt_consumer", func() telegraf.Input {
 		return &MQTTConsumer{
 			ConnectionTimeout:      defaultConnectionTimeout,
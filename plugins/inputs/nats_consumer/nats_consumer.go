@@ -42,9 +42,6 @@ type natsConsumer struct {
 
 	MaxUndeliveredMessages int `toml:"max_undelivered_messages"`
 
-	// Legacy metric buffer support; deprecated in v0.10.3
-	MetricBuffer int
-
 	conn *nats.Conn
 	subs []*nats.Subscription
 
@@ -234,6 +231,10 @@ func (n *natsConsumer) Gather(acc telegraf.Accumulator) error {
 }
 
 func init() {
+	inputs.AddDeprecatedOption("nats_consumer", "metric_buffer", telegraf.DeprecationInfo{
+		Since:      "0.10.3",
+		ReplacedBy: "max_undelivered_messages",
+	})
 	inputs.Add("nats_consumer", func() telegraf.Input {
 		return &natsConsumer{
 			Servers:                []string{"nats://localhost:4222"},
@@ -9,3 +9,23 @@ var Inputs = map[string]Creator{}
 func Add(name string, creator Creator) {
 	Inputs[name] = creator
 }
+
+// Deprecations holds the deprecated plugins by name.
+var Deprecations = map[string]telegraf.DeprecationInfo{}
+
+// DeprecatedOptions holds the deprecated options of the plugins by plugin
+// name and option name.
+var DeprecatedOptions = map[string]map[string]telegraf.DeprecationInfo{}
+
+// AddDeprecated marks the plugin as deprecated.
+func AddDeprecated(name string, info telegraf.DeprecationInfo) {
+	Deprecations[name] = info
+}
+
+// AddDeprecatedOption marks an option of the plugin as deprecated.
+func AddDeprecatedOption(name, option string, info telegraf.DeprecationInfo) {
+	if DeprecatedOptions[name] == nil {
+		DeprecatedOptions[name] = map[string]telegraf.DeprecationInfo{}
+	}
+	DeprecatedOptions[name][option] = info
+}
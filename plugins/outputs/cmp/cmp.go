@@ -2,43 +2,502 @@ package cmp
 
 import (
 	"bytes"
-	"crypto/tls"
-	"encoding/json"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
-	"log"
 	"net/http"
+	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/httpconfig"
+	"github.com/influxdata/telegraf/internal/secret"
 	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/selfstat"
 )
 
 // CMP represents our plugin config
 type CMP struct {
-	APIURL     string            `toml:"api_url"`
-	APIUser    string            `toml:"api_user"`
-	APIKey     string            `toml:"api_key"`
-	ResourceID string            `toml:"resource_id"`
-	Timeout    internal.Duration `toml:"timeout"`
-	UserAgent  string            `toml:"user_agent"`
-
-	client *http.Client
+	APIURL          string            `toml:"api_url"`
+	APIURLs         []string          `toml:"api_urls"`
+	APIUser         secret.Secret     `toml:"api_user"`
+	APIKey          secret.Secret     `toml:"api_key"`
+	APIUserFile     string            `toml:"api_user_file"`
+	APIKeyFile      string            `toml:"api_key_file"`
+	ResourceID      string            `toml:"resource_id"`
+	ResourceIDTag   string            `toml:"resource_id_tag"`
+	HostResourceIDs map[string]string `toml:"host_resource_ids"`
+	UserAgent       string            `toml:"user_agent"`
+	VersionFile     string            `toml:"version_file"`
+
+	MonitoringSystem string `toml:"monitoring_system"`
+
+	SkipConnectionCheck bool `toml:"skip_connection_check"`
+	LogRequests         bool `toml:"log_requests"`
+
+	AuthMode         string        `toml:"auth_mode"`
+	BearerToken      secret.Secret `toml:"bearer_token"`
+	BearerTokenFile  string        `toml:"bearer_token_file"`
+	ClientID         string        `toml:"client_id"`
+	ClientSecret     secret.Secret `toml:"client_secret"`
+	ClientSecretFile string        `toml:"client_secret_file"`
+	TokenURL         string        `toml:"token_url"`
+	Scopes           []string      `toml:"scopes"`
+
+	HMACSecret secret.Secret `toml:"hmac_secret"`
+
+	MaxDatapointsPerRequest int           `toml:"max_datapoints_per_request"`
+	MaxBodyBytes            internal.Size `toml:"max_body_bytes"`
+
+	TranslationsFile         string               `toml:"translations_file"`
+	TranslationInclude       []string             `toml:"translation_include"`
+	TranslationExclude       []string             `toml:"translation_exclude"`
+	PrometheusRules          []PrometheusRule     `toml:"prometheus"`
+	JolokiaRules             []JolokiaRule        `toml:"jolokia"`
+	DerivedFields            []DerivedField       `toml:"derived"`
+	UnmatchedSummaryInterval internal.Duration    `toml:"unmatched_summary_interval"`
+	UnmatchedFile            string               `toml:"unmatched_file"`
+	Specialisations          []SpecialisationRule `toml:"specialisation"`
+	SpecialisationOrder      []string             `toml:"specialisation_order"`
+	SpecialisationSeparator  string               `toml:"specialisation_separator"`
+	HostSpecialisation       bool                 `toml:"host_specialisation"`
+	ContainerTag             string               `toml:"container_specialisation_tag"`
+	ContainerNameFallback    bool                 `toml:"container_name_fallback"`
+	CounterRate              bool                 `toml:"counter_rate"`
+	DownsampleWindow         internal.Duration    `toml:"downsample_window"`
+	DedupMaxAge              internal.Duration    `toml:"dedup_max_age"`
+	MaxMetricAge             internal.Duration    `toml:"max_metric_age"`
+	ConversionErrors         string               `toml:"conversion_errors"`
+
+	MaxParallelRequests int     `toml:"max_parallel_requests"`
+	RequestsPerSecond   float64 `toml:"requests_per_second"`
+	Burst               int     `toml:"burst"`
+
+	MaxRetries           int               `toml:"max_retries"`
+	RetryInitialInterval internal.Duration `toml:"retry_initial_interval"`
+	RetryMaxInterval     internal.Duration `toml:"retry_max_interval"`
+	DeadLetterFile       string            `toml:"dead_letter_file"`
+	FailurePolicy        string            `toml:"failure_policy"`
+	FailureMaxAttempts   int               `toml:"failure_max_attempts"`
+	CloseTimeout         internal.Duration `toml:"close_timeout"`
+
+	Heartbeat         bool              `toml:"heartbeat"`
+	HeartbeatInterval internal.Duration `toml:"heartbeat_interval"`
+
+	FailoverThreshold int               `toml:"failover_threshold"`
+	FailbackInterval  internal.Duration `toml:"failback_interval"`
+
+	LogMeasurements   []string `toml:"log_measurements"`
+	LogMessageField   string   `toml:"log_message_field"`
+	LogSeverityTag    string   `toml:"log_severity_tag"`
+	MaxLogsPerRequest int      `toml:"max_logs_per_request"`
+
+	httpconfig.HTTPClientConfig
+
+	Log   telegraf.Logger
+	Stats *selfstat.PluginStats
+
+	client          *http.Client
+	auth            *httpconfig.AuthConfig
+	endpoints       *endpoints
+	translator      *translator
+	keyFilter       filter.Filter
+	prometheus      *prometheusTranslator
+	jolokia         *jolokiaTranslator
+	deriver         *deriver
+	specialiser     *specialiser
+	logFilter       filter.Filter
+	heartbeat       *heartbeat
+	rates           *rateTracker
+	downsampler     *downsampler
+	deduplicator    *deduplicator
+	unmatched       *unmatchedTracker
+	unmatchedExport *unmatchedExporter
+	// unmatchedMetrics is the number of data points dropped for lack of a
+	// translation by the last write.
+	unmatchedMetrics selfstat.Stat
+	// rejectedItems is the number of data points and log lines rejected by
+	// the API in partial success responses.
+	rejectedItems selfstat.Stat
+	// staleMetrics is the number of metrics dropped for being older than
+	// max_metric_age.
+	staleMetrics selfstat.Stat
+	// conversionErrors is the number of data points dropped because their
+	// value could not be converted.
+	conversionErrors selfstat.Stat
+	// sentDatapoints is the number of data points of the writes sent.
+	sentDatapoints selfstat.Stat
+	requestStats   map[string]*requestStats
+	deadLetterMu   sync.Mutex
+	// failedWrites is the number of writes failed in a row.
+	failedWrites int
+	// unsent are the request bodies by endpoint of the last write if it
+	// failed, which Close sends.
+	unsent map[string][][]byte
+	// translatorMu guards the translator, which Reload replaces while
+	// metrics are written.
+	translatorMu sync.RWMutex
 }
 
 var sampleConfig = `
-  ## CMP API URL and credentials are required
+  ## CMP API URL and credentials are required, the credentials may reference
+  ## a secret store, ie api_key = "@{vault:telegraf/cmp#api_key}" reads the
+  ## key from the telegraf/cmp KV path of a vault secret store, with the
+  ## token a Vault Agent keeps in its token_file.  The values are read again
+  ## every refresh_interval of the store, see docs/CONFIGURATION.md.
   api_url = "https://dev.cmp.nflex.io/cmp/basic/api"
   api_user = "api-user"
   api_key = "api-key"
+  ## Environment variables are expanded in the configuration, ie
+  ## api_key = "${CMP_API_KEY}".  Instead of api_user and api_key, the
+  ## credentials may be read from files such as mounted secrets, the files
+  ## are read again for every request.  bearer_token_file and
+  ## client_secret_file do the same for the other auth modes.
+  # api_user_file = "/run/secrets/cmp_api_user"
+  # api_key_file = "/run/secrets/cmp_api_key"
+  ## A request rejected with a 401 or 403 response is sent again once with
+  ## the credentials read again from their files and secret stores, so that
+  ## rotated credentials are used without restarting telegraf.
+
+  ## Instead of api_url, a primary API URL followed by the URLs to fail over
+  ## to, in order, once failover_threshold requests in a row failed with a
+  ## network error or a 5xx response.  Once failed over, a request every
+  ## failback_interval is sent to the primary, and the requests go back to
+  ## the primary once it succeeds.
+  # api_urls = ["https://cmp.example.com/cmp/basic/api", "https://cmp-dr.example.com/cmp/basic/api"]
+  # failover_threshold = 3
+  # failback_interval = "5m"
+
+  ## Authentication of the API requests, one of:
+  ##   basic  - basic authentication with api_user and api_key
+  ##   bearer - the bearer_token
+  ##   oauth2 - tokens of the OAuth2 client credentials grant, refreshed
+  ##            before they expire
+  # auth_mode = "basic"
+  # bearer_token = "@{vault:telegraf/cmp#token}"
+  # client_id = "telegraf"
+  # client_secret = "@{vault:telegraf/cmp#client_secret}"
+  # token_url = "https://login.example.com/oauth2/token"
+  # scopes = ["metrics:write"]
+
+  ## Sign the requests with the HMAC-SHA256 of the body and a shared secret,
+  ## the hex encoded signature of the unix time, a newline and the body
+  ## before content encoding is sent in the X-CMP-Signature header and the
+  ## time in the X-CMP-Timestamp header
+  # hmac_secret = "@{vault:telegraf/cmp#hmac_secret}"
 
   ## CMP Resource UUID is also required
   resource_id = "00000000-0000-0000-0000-000000000001"
 
+  ## Tag holding the CMP Resource UUID of each metric, metrics without the
+  ## tag are sent for resource_id, or dropped if resource_id is not set
+  # resource_id_tag = "cmp_resource_id"
+
+  ## Identifier of the collector sent with the data points and log lines,
+  ## telegraf@<hostname> by default so that CMP can tell the data of each
+  ## agent or collection tier apart.  An environment variable may give the
+  ## fleet name, ie "telegraf-${FLEET}".
+  # monitoring_system = "telegraf@<hostname>"
+
   ## Request settings
   timeout = "5s"
+  ## The user agent is telegraf/<version> by default, the version is read
+  ## from version_file if set, otherwise it is the version of telegraf
   user_agent = ""
+  # version_file = "/current_version"
+
+  ## Connect sends an authenticated GET request to the ping endpoint of the
+  ## API and fails if it is not accepted, so that a wrong api_url or wrong
+  ## credentials are reported at startup instead of on the first write.  Of
+  ## api_urls, the following URL is checked if one is unreachable or responds
+  ## with a 5xx error.
+  # skip_connection_check = false
+
+  ## Log the URL, status and latency of every request to the API at the
+  ## debug level, with the response body of the failed requests truncated.
+  ## The passwords, tokens and secrets of the configuration are redacted from
+  ## the URLs and bodies.
+  # log_requests = false
+
+  ## JSON file of translations added to the built-in translations, see
+  ## translate.go for the format.  The conversion of a translation is one of
+  ## subtract_from_100_percent, divide_by(n), multiply_by(n), add_offset(n),
+  ## bool_to_int, clamp(min, max), es_cluster_health, consul_health_status,
+  ## ceph_health, mongodb_member_state, uwsgi_worker_status or
+  ## enum(key: value, ..., *: default).  Instead of a conversion, source_unit
+  ## may be set to the unit of the field, the conversion to the unit of the
+  ## translation is then derived from them.
+  ## The units are B, KB, MB, GB, TB, KiB, MiB, GiB, TiB, ns, us, ms, s, min,
+  ## h, nanocores, millicores, cores, ppm, percent and ratio, with a /s
+  ## suffix for rates.
+  ## The string values of a field, ie the state of a service, are mapped to
+  ## numbers by the enum of a translation, {"running": 0, "*": 1}, or sent
+  ## as they are in status data points if status is true.
+  ## The name of a translation may be a template of the measurement, field
+  ## and tags of the metric, ie "{{.Measurement}}-{{.Tag \"role\"}}-latency".
+  ## The aliases of the file are metric names translated as another metric
+  ## name, the built-in aliases cover the metrics renamed by etcd 3.4.
+  ## The file is loaded again on SIGHUP, without reconnecting the output.
+  # translations_file = "/etc/telegraf/cmp-translations.json"
+
+  ## Glob patterns of the metric names, the keys of the translations such as
+  ## "cpu-usage.idle", the fields of which are sent if they match an include
+  ## pattern and no exclude pattern.  The fields left out are not counted as
+  ## without a translation.
+  # translation_include = ["cpu-*", "mem-*"]
+  # translation_exclude = ["cpu-usage.guest*"]
+
+  ## The metric names without a translation are logged once an interval with
+  ## the number of times they were dropped.  The internal input reports the
+  ## number dropped by the last write as cmp unmatched_metrics.
+  # unmatched_summary_interval = "10m"
+
+  ## File to which the metric names without a translation are appended as
+  ## JSON lines, once per name, with the measurement, field, tags and value
+  ## of the first metric seen, to author translations for them.
+  # unmatched_file = "/var/lib/telegraf/cmp-unmatched.json"
+
+  ## Components of the specialisation of the data points, in order, joined
+  ## by the separator and leaving out empty components.  The components are
+  ## translation for the specialisation of the translation, rules for those
+  ## of the specialisation rules below, or tag:<name> for the value of a tag.
+  # specialisation_order = ["translation", "rules"]
+  # specialisation_separator = "."
+
+  ## Add the host tag as the last component of the specialisation, so that
+  ## the metrics of several hosts sent for the same resource stay distinct
+  # host_specialisation = false
+
+  ## Tag of the docker containers in the built-in specialisation rules, the
+  ## docker compose service label by default.  The docker input reports the
+  ## container labels as tags, plain docker and kubernetes containers do not
+  ## have the compose label, with container_name_fallback the docker
+  ## container metrics without the tag are specialised by the container name.
+  # container_specialisation_tag = "com.docker.compose.service"
+  # container_name_fallback = false
+
+  ## Send the per second rate of counters instead of their value, counter
+  ## resets are detected and the first value of a counter only sets the
+  ## base of its rate
+  # counter_rate = false
+
+  ## Aggregate the numeric data points of each metric and specialisation of
+  ## a resource over windows of downsample_window, aligned on the duration,
+  ## and send a single data point per window once it is over: the average of
+  ## gauges, the last value of counters.  The windows not over when telegraf
+  ## stops are sent by Close.  0 sends every data point.
+  # downsample_window = "0s"
+
+  ## Suppress the data points with the same value as the last data point
+  ## sent for their metric and specialisation, so that unchanged values are
+  ## only sent once every dedup_max_age.  0 sends every data point.
+  # dedup_max_age = "0s"
+
+  ## Drop the metrics older than max_metric_age when they are written, ie
+  ## the metrics of the buffer replayed after an outage of the API, and count
+  ## them in the stale_metrics internal metric.  0 sends every metric.
+  # max_metric_age = "0s"
+
+  ## The data points whose value cannot be converted, ie a string field with
+  ## a translation dividing its value, are dropped and counted in the
+  ## conversion_errors internal metric.  With lenient the count is logged at
+  ## the debug level, with strict each data point is logged as an error.
+  # conversion_errors = "lenient"
+
+  ## The data points of a write are split in several requests of at most
+  ## max_datapoints_per_request data points and max_body_bytes bytes before
+  ## content encoding, sent one after the other, 0 is unlimited
+  # max_datapoints_per_request = 0
+  # max_body_bytes = 0
+
+  ## The API accepts the data points of a single resource per request, the
+  ## requests of up to max_parallel_requests resources are sent in parallel
+  # max_parallel_requests = 1
+
+  ## Limit the API calls, retries included, to requests_per_second on
+  ## average with bursts of up to burst calls, 0 is unlimited
+  # requests_per_second = 0.0
+  # burst = 1
+
+  ## Network errors, 5xx and 429 responses are retried up to max_retries
+  ## times before the write fails, waiting retry_initial_interval before the
+  ## first retry and doubling the wait up to retry_max_interval.  Requests
+  ## have an Idempotency-Key header, the SHA-256 of their body, which is the
+  ## same for their retries so that the API can ignore those it received.
+  # max_retries = 3
+  # retry_initial_interval = "1s"
+  # retry_max_interval = "30s"
+
+  ## The internal input reports as cmp, with an endpoint tag, the number of
+  ## requests including retries and their sent_bytes, and the average
+  ## request_time_ns until the response and request_bytes since its last
+  ## gather, and without the tag the sent_datapoints of the writes.  The
+  ## stats of each output are tagged with its alias.
+
+  ## On shutdown the pending data points, those of the downsampling windows
+  ## not over and the requests of the last write not sent, are sent with up
+  ## to close_timeout for their requests and retries.  Those not sent by then
+  ## are written to the dead_letter_file if set, or dropped.
+  # close_timeout = "10s"
+
+  ## Payloads permanently rejected by the API with a 4xx response are
+  ## appended to the dead letter file, one JSON object per line with the
+  ## endpoint, payload and response, instead of failing the write.  The
+  ## payload can be sent again to the endpoint of the API as is.  Of a 207
+  ## partial success response, only the rejected items are retried if the
+  ## API marks them retryable, or written to the dead letter file, or
+  ## dropped.  The internal input reports their number as cmp rejected_items.
+  # dead_letter_file = "/var/lib/telegraf/cmp-dead-letter.json"
+
+  ## What to do with a write whose requests still fail after their retries:
+  ##   block       - fail the write, the agent writes the same metrics again
+  ##                 until the API accepts them
+  ##   drop        - drop the metrics once the write failed
+  ##                 failure_max_attempts times in a row
+  ##   dead_letter - write the payloads not sent to dead_letter_file once the
+  ##                 write failed failure_max_attempts times in a row
+  # failure_policy = "block"
+  # failure_max_attempts = 3
+
+  ## Send an agent-heartbeat data point with the value 1 and the count unit
+  ## for each resource with every flush, so that the absence of data points
+  ## can be alerted on in CMP.  The resources are resource_id, the
+  ## host_resource_ids and those of the metrics written since the start.
+  # heartbeat = false
+  ## Send the heartbeat every heartbeat_interval as well, whether or not
+  ## there are metrics to flush, 0 disables the timer.
+  # heartbeat_interval = "0s"
+
+  ## Metrics of the log_measurements, such as those of the tail and syslog
+  ## inputs, are sent to the logs API as log lines instead of data points.
+  ## The message of a line is the string field log_message_field, its
+  ## severity the tag log_severity_tag, and its attributes the tags.  The
+  ## log lines are sent in their own requests of at most
+  ## max_logs_per_request log lines and max_body_bytes bytes.
+  # log_measurements = ["syslog"]
+  # log_message_field = "message"
+  # log_severity_tag = "severity"
+  # max_logs_per_request = 0
+
+  ## Idle connections are kept open for reuse for idle_conn_timeout, at most
+  ## max_idle_conns in total and max_idle_conns_per_host per host, by default
+  ## the larger of 2 and max_parallel_requests
+  # idle_conn_timeout = "90s"
+  # max_idle_conns = 100
+  # max_idle_conns_per_host = 2
+
+  ## Close the connections older than max_connection_age between requests,
+  ## so that new connections resolve the API host again and follow the
+  ## changes of its DNS records, 0 keeps them until they are idle for
+  ## idle_conn_timeout
+  # max_connection_age = "0s"
+
+  ## Negotiate HTTP/2 with the API over TLS, so that the parallel requests
+  ## share a single connection
+  # enable_http2 = true
+
+  ## HTTP proxy used for all requests, or use the proxy set by the
+  ## HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables with
+  ## use_system_proxy.  By default requests are sent directly.
+  # http_proxy = "http://localhost:8888"
+  # use_system_proxy = false
+
+  ## Content encoding of the request body, one of identity, gzip, snappy
+  ## or zstd
+  # content_encoding = "identity"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  ## Client certificate and key for mutual TLS, they are loaded again when
+  ## the files change
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Minimum and maximum TLS version, one of TLS10, TLS11 or TLS12
+  # tls_min_version = "TLS12"
+  # tls_max_version = "TLS12"
+  ## Allowed cipher suites, by default the Go defaults are used
+  # tls_cipher_suites = ["TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"]
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## CMP Resource UUIDs of the metrics by their host tag, used for the
+  ## metrics without the resource_id_tag
+  # [outputs.cmp.host_resource_ids]
+  #   "server01" = "00000000-0000-0000-0000-000000000002"
+
+  ## Rules deriving the specialisation of the data points from the tags of
+  ## the metric, the first rule applying to the metric is used.  A rule
+  ## applies to the measurements matching the measurement glob pattern that
+  ## have all the tags.  The specialisation is the template in which $tag or
+  ## ${tag} are replaced by the tag values, by default the values of the tags
+  ## joined with ".".  If pattern is set the specialisation must match the
+  ## regular expression and the match is replaced by replacement.
+  ## With continue the following rules are applied as well, each adding a
+  ## component to the specialisation.
+  ## The rules replace the built-in rules, which use the cpu, path,
+  ## com.docker.compose.service tags and the tags of the haproxy, diskio, net,
+  ## ntpq, kubernetes, postgresql, pg_stat_user_tables, pg_stat_user_indexes,
+  ## mongodb, consul_health_checks, cassandra, ceph, phpfpm, kafka,
+  ## elasticsearch_indices_stats, minio, nginx_plus_api, nginx_vts, uwsgi,
+  ## sqlserver_waitstats and influxdb 2.x measurements.
+  # [[outputs.cmp.specialisation]]
+  #   tags = ["cpu"]
+  #   pattern = '^cpu(\d+)$'
+  #   replacement = "$1"
+  # [[outputs.cmp.specialisation]]
+  #   measurement = "haproxy"
+  #   tags = ["type", "proxy", "sv"]
+  #   template = "${type}.${proxy}.${sv}"
+
+  ## Rules translating the metrics of the prometheus input without a
+  ## translation, the first rule whose metric glob pattern matches the
+  ## prometheus metric name is used.  Counters, sums and counts are sent as
+  ## CMP counters, gauges and untyped metrics as gauges, quantiles and
+  ## buckets are dropped.  In name ${metric} is the metric name without the
+  ## _total suffix and with - for _, sums and counts have a -sum or -count
+  ## suffix.  The values of the labels are the specialisation, conversion is
+  ## one of the conversions of the translations file, or source_unit one of
+  ## its units.
+  # [[outputs.cmp.prometheus]]
+  #   metric = "http_requests_total"
+  #   name = "myapp-${metric}"
+  #   unit = "requests/s"
+  #   labels = ["method", "code"]
+
+  ## Rules translating the metrics of the jolokia2 inputs without a
+  ## translation, the first rule whose measurement glob pattern matches the
+  ## measurement, and attributes glob patterns the field if set, is used.
+  ## In name ${domain} and ${type} are the measurement before and after its
+  ## first . or _, ${attribute} the field and ${<tag>} the value of a tag,
+  ## all in kebab case, by default "${domain}-${type}-${attribute}".  The
+  ## values of the tags are the specialisation, conversion and source_unit
+  ## are those of the prometheus rules.
+  # [[outputs.cmp.jolokia]]
+  #   measurement = "tomcat.GlobalRequestProcessor"
+  #   attributes = ["requestCount", "errorCount"]
+  #   unit = "requests/s"
+  #   counter = true
+  #   tags = ["name"]
+
+  ## Fields derived from other fields of the same metric, the sum of the
+  ## numerator fields divided by the sum of the denominator fields if set,
+  ## multiplied by scale.  The derived field is translated as the other
+  ## fields, or by name, specialisation and unit if there is no translation
+  ## for it.  It is left out if a field is missing or the denominator is 0.
+  # [[outputs.cmp.derived]]
+  #   measurement = "postgresql"
+  #   field = "blks_hit_ratio"
+  #   numerator = ["blks_hit"]
+  #   denominator = ["blks_hit", "blks_read"]
+  #   scale = 100.0
+  #   name = "postgresql-cache-hit-ratio"
+  #   unit = "percent"
 `
 
 var translateMap = map[string]Translation{
@@ -73,6 +532,70 @@ var translateMap = map[string]Translation{
 	"system-load15": {
 		Name: "load-avg-15",
 	},
+	"system-uptime": {
+		Name: "system-uptime",
+		Unit: "s",
+		// the input reports the uptime as a counter, but the CMP rate of
+		// it would always be 1, so it is sent as a gauge
+		Counter: false,
+	},
+	"system-n.users": {
+		Name: "system-users",
+		Unit: "count",
+	},
+	"chrony-system.time": {
+		Name: "chrony-system-time-offset",
+		Unit: "s",
+	},
+	"chrony-last.offset": {
+		Name: "chrony-last-offset",
+		Unit: "s",
+	},
+	"chrony-rms.offset": {
+		Name: "chrony-rms-offset",
+		Unit: "s",
+	},
+	"chrony-root.delay": {
+		Name: "chrony-root-delay",
+		Unit: "s",
+	},
+	"chrony-root.dispersion": {
+		Name: "chrony-root-dispersion",
+		Unit: "s",
+	},
+	"chrony-frequency": {
+		Name: "chrony-frequency",
+		Unit: "ppm",
+	},
+	"chrony-skew": {
+		Name: "chrony-skew",
+		Unit: "ppm",
+	},
+	"chrony-stratum": {
+		Name: "chrony-stratum",
+		Unit: "",
+		// derived from the stratum tag, see deriveFields
+	},
+	"ntpq-offset": {
+		Name:       "ntp-offset",
+		Unit:       "s",
+		SourceUnit: "ms",
+		// milliseconds in
+	},
+	"ntpq-delay": {
+		Name:       "ntp-delay",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"ntpq-jitter": {
+		Name:       "ntp-jitter",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"ntpq-stratum": {
+		Name: "ntp-stratum",
+		Unit: "",
+	},
 	"disk-used.percent": {
 		Name: "disk-usage",
 		Unit: "percent",
@@ -108,6 +631,163 @@ var translateMap = map[string]Translation{
 		// ms / 1000 for s then * 100 for percent
 		Conversion: divideBy(10.0),
 	},
+	"net-bytes.recv": {
+		Name:    "network-in",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"net-bytes.sent": {
+		Name:    "network-out",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"net-packets.recv": {
+		Name:    "network-packets-in",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"net-packets.sent": {
+		Name:    "network-packets-out",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"net-err.in": {
+		Name:    "network-errors-in",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"net-err.out": {
+		Name:    "network-errors-out",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"net-drop.in": {
+		Name:    "network-drops-in",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"net-drop.out": {
+		Name:    "network-drops-out",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"kubernetes_node-cpu.usage.nanocores": {
+		Name:       "k8s-node-cpu-usage",
+		Unit:       "cores",
+		SourceUnit: "nanocores",
+		// nanocores in
+	},
+	"kubernetes_node-memory.usage.bytes": {
+		Name:           "k8s-node-memory",
+		Specialisation: "usage",
+		Unit:           "B",
+	},
+	"kubernetes_node-memory.working.set.bytes": {
+		Name:           "k8s-node-memory",
+		Specialisation: "working.set",
+		Unit:           "B",
+	},
+	"kubernetes_node-memory.available.bytes": {
+		Name:           "k8s-node-memory",
+		Specialisation: "available",
+		Unit:           "B",
+	},
+	"kubernetes_node-fs.used.bytes": {
+		Name:           "k8s-node-fs",
+		Specialisation: "used",
+		Unit:           "B",
+	},
+	"kubernetes_node-fs.available.bytes": {
+		Name:           "k8s-node-fs",
+		Specialisation: "available",
+		Unit:           "B",
+	},
+	"kubernetes_node-fs.capacity.bytes": {
+		Name:           "k8s-node-fs",
+		Specialisation: "capacity",
+		Unit:           "B",
+	},
+	"kubernetes_node-network.rx.bytes": {
+		Name:    "k8s-node-network-in",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"kubernetes_node-network.tx.bytes": {
+		Name:    "k8s-node-network-out",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"kubernetes_pod_container-cpu.usage.nanocores": {
+		Name:       "k8s-pod-cpu-usage",
+		Unit:       "cores",
+		SourceUnit: "nanocores",
+		// nanocores in
+	},
+	"kubernetes_pod_container-memory.usage.bytes": {
+		Name:           "k8s-pod-memory",
+		Specialisation: "usage",
+		Unit:           "B",
+	},
+	"kubernetes_pod_container-memory.working.set.bytes": {
+		Name:           "k8s-pod-memory",
+		Specialisation: "working.set",
+		Unit:           "B",
+	},
+	"kubernetes_pod_container-rootfs.used.bytes": {
+		Name: "k8s-pod-rootfs-used",
+		Unit: "B",
+	},
+	"kubernetes_pod_volume-used.bytes": {
+		Name:           "k8s-pod-volume",
+		Specialisation: "used",
+		Unit:           "B",
+	},
+	"kubernetes_pod_volume-available.bytes": {
+		Name:           "k8s-pod-volume",
+		Specialisation: "available",
+		Unit:           "B",
+	},
+	"kubernetes_pod_volume-capacity.bytes": {
+		Name:           "k8s-pod-volume",
+		Specialisation: "capacity",
+		Unit:           "B",
+	},
+	"kubernetes_pod_network-rx.bytes": {
+		Name:    "k8s-pod-network-in",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"kubernetes_pod_network-tx.bytes": {
+		Name:    "k8s-pod-network-out",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"kube_pod_container_status_restarts_total-counter": {
+		Name:    "k8s-container-restarts",
+		Counter: true,
+		Unit:    "count",
+		// kube-state-metrics, with the prometheus input
+	},
+	"kube_node_status_allocatable_cpu_cores-gauge": {
+		Name:           "k8s-node-cpu",
+		Specialisation: "allocatable",
+		Unit:           "cores",
+	},
+	"kube_node_status_capacity_cpu_cores-gauge": {
+		Name:           "k8s-node-cpu",
+		Specialisation: "capacity",
+		Unit:           "cores",
+	},
+	"kube_node_status_allocatable_memory_bytes-gauge": {
+		Name:           "k8s-node-memory",
+		Specialisation: "allocatable",
+		Unit:           "B",
+	},
+	"kube_node_status_capacity_memory_bytes-gauge": {
+		Name:           "k8s-node-memory",
+		Specialisation: "capacity",
+		Unit:           "B",
+	},
 	"docker_container_cpu-usage.percent": {
 		Name: "docker-cpu-usage",
 		Unit: "percent",
@@ -116,6 +796,66 @@ var translateMap = map[string]Translation{
 		Name: "docker-memory-usage",
 		Unit: "percent",
 	},
+	"docker_container_net-rx.bytes": {
+		Name:    "docker-network-in",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"docker_container_net-tx.bytes": {
+		Name:    "docker-network-out",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"docker_container_net-rx.packets": {
+		Name:    "docker-network-packets-in",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"docker_container_net-tx.packets": {
+		Name:    "docker-network-packets-out",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"docker_container_net-rx.errors": {
+		Name:    "docker-network-errors-in",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"docker_container_net-tx.errors": {
+		Name:    "docker-network-errors-out",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"docker_container_net-rx.dropped": {
+		Name:    "docker-network-drops-in",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"docker_container_net-tx.dropped": {
+		Name:    "docker-network-drops-out",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"docker_container_blkio-io.service.bytes.recursive.read": {
+		Name:    "docker-disk-read-bytes",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"docker_container_blkio-io.service.bytes.recursive.write": {
+		Name:    "docker-disk-write-bytes",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"docker_container_blkio-io.serviced.recursive.read": {
+		Name:    "docker-disk-read-ops",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"docker_container_blkio-io.serviced.recursive.write": {
+		Name:    "docker-disk-write-ops",
+		Counter: true,
+		Unit:    "count/s",
+	},
 	"elasticsearch_cluster_health-status": {
 		Name:       "es-status",
 		Unit:       "",
@@ -197,14 +937,14 @@ var translateMap = map[string]Translation{
 		Specialisation: "query",
 		Counter:        true,
 		Unit:           "s",
-		Conversion:     divideBy(1000.0),
+		SourceUnit:     "ms",
 	},
 	"elasticsearch_indices-search.fetch.time.in.millis": {
 		Name:           "es-search-time",
 		Specialisation: "fetch",
 		Counter:        true,
 		Unit:           "s",
-		Conversion:     divideBy(1000.0),
+		SourceUnit:     "ms",
 	},
 	"elasticsearch_indices-get.total": {
 		Name:           "es-get-requests",
@@ -228,19 +968,19 @@ var translateMap = map[string]Translation{
 		Name:           "es-get-time",
 		Specialisation: "get",
 		Unit:           "s",
-		Conversion:     divideBy(1000.0),
+		SourceUnit:     "ms",
 	},
 	"elasticsearch_indices-get.exists.time.in.millis": {
 		Name:           "es-get-time",
 		Specialisation: "exists",
 		Unit:           "s",
-		Conversion:     divideBy(1000.0),
+		SourceUnit:     "ms",
 	},
 	"elasticsearch_indices-get.missing.time.in.millis": {
 		Name:           "es-get-time",
 		Specialisation: "missing",
 		Unit:           "s",
-		Conversion:     divideBy(1000.0),
+		SourceUnit:     "ms",
 	},
 	"elasticsearch_indices-indexing.index.total": {
 		Name:           "es-index-requests",
@@ -263,13 +1003,13 @@ var translateMap = map[string]Translation{
 		Specialisation: "index",
 		Counter:        true,
 		Unit:           "s",
-		Conversion:     divideBy(1000.0),
+		SourceUnit:     "ms",
 	},
 	"elasticsearch_indices-indexing.delete.time.in.millis": {
 		Name:           "es-index-time",
 		Specialisation: "delete",
 		Unit:           "s",
-		Conversion:     divideBy(1000.0),
+		SourceUnit:     "ms",
 	},
 	"elasticsearch_indices-flush.total.time.in.millis": {
 		Name:           "es-index-time",
@@ -278,6 +1018,60 @@ var translateMap = map[string]Translation{
 		Unit:           "requests",
 		Conversion:     divideBy(1000.0),
 	},
+	// per index stats, specialised by the index name
+	"elasticsearch_indices_stats_primaries-docs.count": {
+		Name: "es-index-documents",
+		Unit: "count",
+	},
+	"elasticsearch_indices_stats_primaries-docs.deleted": {
+		Name: "es-index-deleted-documents",
+		Unit: "count",
+	},
+	"elasticsearch_indices_stats_primaries-store.size.in.bytes": {
+		Name: "es-index-primary-store-size",
+		Unit: "B",
+	},
+	"elasticsearch_indices_stats_total-store.size.in.bytes": {
+		Name: "es-index-store-size",
+		Unit: "B",
+	},
+	"elasticsearch_indices_stats_total-indexing.index.total": {
+		Name:    "es-index-indexing-requests",
+		Counter: true,
+		Unit:    "requests",
+	},
+	"elasticsearch_indices_stats_total-indexing.index.time.in.millis": {
+		Name:       "es-index-indexing-time",
+		Counter:    true,
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"elasticsearch_indices_stats_total-search.query.total": {
+		Name:           "es-index-search-requests",
+		Specialisation: "query",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"elasticsearch_indices_stats_total-search.fetch.total": {
+		Name:           "es-index-search-requests",
+		Specialisation: "fetch",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"elasticsearch_indices_stats_total-search.query.time.in.millis": {
+		Name:           "es-index-search-time",
+		Specialisation: "query",
+		Counter:        true,
+		Unit:           "s",
+		SourceUnit:     "ms",
+	},
+	"elasticsearch_indices_stats_total-search.fetch.time.in.millis": {
+		Name:           "es-index-search-time",
+		Specialisation: "fetch",
+		Counter:        true,
+		Unit:           "s",
+		SourceUnit:     "ms",
+	},
 	"etcd_server_has_leader-gauge": {
 		Name: "etcd-has-leader",
 		Unit: "count",
@@ -356,6 +1150,25 @@ var translateMap = map[string]Translation{
 		Counter: true,
 		Unit:    "count",
 	},
+	"etcd_mvcc_db_total_size_in_use_in_bytes-gauge": {
+		Name: "etcd-mvcc-db-size-in-use",
+		Unit: "B",
+	},
+	"etcd_mvcc_put_total-counter": {
+		Name:    "etcd-mvcc-puts",
+		Counter: true,
+		Unit:    "count",
+	},
+	"etcd_mvcc_range_total-counter": {
+		Name:    "etcd-mvcc-ranges",
+		Counter: true,
+		Unit:    "count",
+	},
+	"etcd_mvcc_txn_total-counter": {
+		Name:    "etcd-mvcc-txns",
+		Counter: true,
+		Unit:    "count",
+	},
 	"etcd_debugging_mvcc_keys_total-gauge": {
 		Name: "etcd-mvcc-keys",
 		Unit: "count",
@@ -384,7 +1197,7 @@ var translateMap = map[string]Translation{
 	"haproxy-check.duration": {
 		Name:       "haproxy-check-duration",
 		Unit:       "s",
-		Conversion: divideBy(1000.0),
+		SourceUnit: "ms",
 	},
 	"haproxy-rate": {
 		Name: "haproxy-rate",
@@ -406,6 +1219,39 @@ var translateMap = map[string]Translation{
 		Name: "haproxy-sessions-total",
 		Unit: "sessions",
 	},
+	"haproxy-qcur": {
+		Name: "haproxy-queue-current",
+		Unit: "requests",
+	},
+	"haproxy-qmax": {
+		Name: "haproxy-queue-max",
+		Unit: "requests",
+	},
+	"haproxy-ereq": {
+		Name:    "haproxy-request-errors",
+		Counter: true,
+		Unit:    "requests/s",
+	},
+	"haproxy-econ": {
+		Name:    "haproxy-connection-errors",
+		Counter: true,
+		Unit:    "connections/s",
+	},
+	"haproxy-eresp": {
+		Name:    "haproxy-response-errors",
+		Counter: true,
+		Unit:    "responses/s",
+	},
+	"haproxy-wretr": {
+		Name:    "haproxy-retries",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"haproxy-wredis": {
+		Name:    "haproxy-redispatches",
+		Counter: true,
+		Unit:    "count/s",
+	},
 	"mongodb-open.connections": {
 		Name: "mongodb-open-connections",
 		Unit: "connections",
@@ -469,15 +1315,60 @@ var translateMap = map[string]Translation{
 		Specialisation: "flushes",
 		Unit:           "operations/s",
 	},
-	"mongodb-resident.megabytes": {
-		Name:       "mongodb-memory-resident",
-		Unit:       "B",
-		Conversion: divideBy(1000.0 * 1000.0),
+	// replica set members
+	"mongodb-repl.queries.per.sec": {
+		Name:           "mongodb-repl-ops",
+		Specialisation: "queries",
+		Unit:           "operations/s",
+	},
+	"mongodb-repl.inserts.per.sec": {
+		Name:           "mongodb-repl-ops",
+		Specialisation: "inserts",
+		Unit:           "operations/s",
+	},
+	"mongodb-repl.updates.per.sec": {
+		Name:           "mongodb-repl-ops",
+		Specialisation: "updates",
+		Unit:           "operations/s",
+	},
+	"mongodb-repl.deletes.per.sec": {
+		Name:           "mongodb-repl-ops",
+		Specialisation: "deletes",
+		Unit:           "operations/s",
+	},
+	"mongodb-repl.getmores.per.sec": {
+		Name:           "mongodb-repl-ops",
+		Specialisation: "getmores",
+		Unit:           "operations/s",
+	},
+	"mongodb-repl.commands.per.sec": {
+		Name:           "mongodb-repl-ops",
+		Specialisation: "commands",
+		Unit:           "operations/s",
+	},
+	"mongodb-repl.lag": {
+		Name: "mongodb-repl-lag",
+		Unit: "s",
+	},
+	"mongodb-repl.oplog.window.sec": {
+		Name: "mongodb-oplog-window",
+		Unit: "s",
+	},
+	// the replSetGetStatus state code, see mongodbMemberState
+	"mongodb-state": {
+		Name:       "mongodb-member-state",
+		Unit:       "",
+		Conversion: mongodbMemberState,
+	},
+	"mongodb-resident.megabytes": {
+		Name:       "mongodb-memory-resident",
+		Unit:       "B",
+		SourceUnit: "MiB",
 	},
 	"mongodb-vsize.megabytes": {
 		Name:       "mongodb-memory-vsize",
 		Unit:       "B",
-		Conversion: divideBy(1000.0 * 1000.0),
+		SourceUnit: "MiB",
 	},
 	"mongodb-percent.cache.dirty ": {
 		Name: "mongodb-cache-dirty",
@@ -608,6 +1499,228 @@ var translateMap = map[string]Translation{
 		// 100 x seconds, then differentate (.cntr) to get percentage
 		Conversion: divideBy(10.0),
 	},
+	// pg_stat_user_tables and pg_stat_user_indexes queries of the
+	// postgresql_extensible input, with these measurements and the
+	// schemaname, relname and indexrelname columns as tags
+	"pg_stat_user_tables-seq.scan": {
+		Name:    "postgres-table-seq-scans",
+		Counter: true,
+		Unit:    "scans/s",
+	},
+	"pg_stat_user_tables-seq.tup.read": {
+		Name:    "postgres-table-seq-tuples-read",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"pg_stat_user_tables-idx.scan": {
+		Name:    "postgres-table-idx-scans",
+		Counter: true,
+		Unit:    "scans/s",
+	},
+	"pg_stat_user_tables-idx.tup.fetch": {
+		Name:    "postgres-table-idx-tuples-fetched",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"pg_stat_user_tables-n.tup.ins": {
+		Name:    "postgres-table-tuples-inserted",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"pg_stat_user_tables-n.tup.upd": {
+		Name:    "postgres-table-tuples-updated",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"pg_stat_user_tables-n.tup.del": {
+		Name:    "postgres-table-tuples-deleted",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"pg_stat_user_tables-n.tup.hot.upd": {
+		Name:    "postgres-table-tuples-hot-updated",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"pg_stat_user_tables-n.live.tup": {
+		Name: "postgres-table-live-tuples",
+		Unit: "count",
+	},
+	"pg_stat_user_tables-n.dead.tup": {
+		Name: "postgres-table-dead-tuples",
+		Unit: "count",
+	},
+	"pg_stat_user_tables-vacuum.count": {
+		Name:    "postgres-table-vacuums",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"pg_stat_user_tables-autovacuum.count": {
+		Name:    "postgres-table-autovacuums",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"pg_stat_user_tables-analyze.count": {
+		Name:    "postgres-table-analyzes",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"pg_stat_user_tables-autoanalyze.count": {
+		Name:    "postgres-table-autoanalyzes",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"pg_stat_user_indexes-idx.scan": {
+		Name:    "postgres-index-scans",
+		Counter: true,
+		Unit:    "scans/s",
+	},
+	"pg_stat_user_indexes-idx.tup.read": {
+		Name:    "postgres-index-tuples-read",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"pg_stat_user_indexes-idx.tup.fetch": {
+		Name:    "postgres-index-tuples-fetched",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"mysql-threads.connected": {
+		Name: "mysql-threads-connected",
+		Unit: "count",
+	},
+	"mysql-threads.running": {
+		Name: "mysql-threads-running",
+		Unit: "count",
+	},
+	"mysql-max.used.connections": {
+		Name: "mysql-max-used-connections",
+		Unit: "count",
+	},
+	"mysql-connections": {
+		Name:    "mysql-connections",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"mysql-aborted.connects": {
+		Name:    "mysql-aborted-connects",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"mysql-questions": {
+		Name:    "mysql-questions",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"mysql-slow.queries": {
+		Name:    "mysql-slow-queries",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"mysql-bytes.received": {
+		Name:           "mysql-bytes",
+		Specialisation: "received",
+		Counter:        true,
+		Unit:           "B/s",
+	},
+	"mysql-bytes.sent": {
+		Name:           "mysql-bytes",
+		Specialisation: "sent",
+		Counter:        true,
+		Unit:           "B/s",
+	},
+	"mysql-innodb.buffer.pool.pages.total": {
+		Name:           "mysql-innodb-buffer-pool-pages",
+		Specialisation: "total",
+		Unit:           "count",
+	},
+	"mysql-innodb.buffer.pool.pages.free": {
+		Name:           "mysql-innodb-buffer-pool-pages",
+		Specialisation: "free",
+		Unit:           "count",
+	},
+	"mysql-innodb.buffer.pool.pages.data": {
+		Name:           "mysql-innodb-buffer-pool-pages",
+		Specialisation: "data",
+		Unit:           "count",
+	},
+	"mysql-innodb.buffer.pool.pages.dirty": {
+		Name:           "mysql-innodb-buffer-pool-pages",
+		Specialisation: "dirty",
+		Unit:           "count",
+	},
+	"mysql-innodb.buffer.pool.bytes.data": {
+		Name:           "mysql-innodb-buffer-pool-bytes",
+		Specialisation: "data",
+		Unit:           "B",
+	},
+	"mysql-innodb.buffer.pool.bytes.dirty": {
+		Name:           "mysql-innodb-buffer-pool-bytes",
+		Specialisation: "dirty",
+		Unit:           "B",
+	},
+	"mysql-innodb.buffer.pool.read.requests": {
+		Name:           "mysql-innodb-buffer-pool-reads",
+		Specialisation: "requests",
+		Counter:        true,
+		Unit:           "count/s",
+	},
+	"mysql-innodb.buffer.pool.reads": {
+		Name:           "mysql-innodb-buffer-pool-reads",
+		Specialisation: "disk",
+		Counter:        true,
+		Unit:           "count/s",
+	},
+	"mysql-innodb.rows.read": {
+		Name:           "mysql-innodb-rows",
+		Specialisation: "read",
+		Counter:        true,
+		Unit:           "count/s",
+	},
+	"mysql-innodb.rows.inserted": {
+		Name:           "mysql-innodb-rows",
+		Specialisation: "inserted",
+		Counter:        true,
+		Unit:           "count/s",
+	},
+	"mysql-innodb.rows.updated": {
+		Name:           "mysql-innodb-rows",
+		Specialisation: "updated",
+		Counter:        true,
+		Unit:           "count/s",
+	},
+	"mysql-innodb.rows.deleted": {
+		Name:           "mysql-innodb-rows",
+		Specialisation: "deleted",
+		Counter:        true,
+		Unit:           "count/s",
+	},
+	"mysql-innodb.row.lock.waits": {
+		Name:    "mysql-innodb-row-lock-waits",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"mysql-innodb.row.lock.time": {
+		Name:    "mysql-innodb-row-lock-time",
+		Counter: true,
+		Unit:    "percent",
+		// total milliseconds in, so divide by 10 to get
+		// 100 x seconds, then differentate (.cntr) to get percentage
+		Conversion: divideBy(10.0),
+	},
+	// the slave status columns are only lowercased with metric_version 2
+	"mysql-slave.seconds.behind.master": {
+		Name: "mysql-replication-lag",
+		Unit: "s",
+	},
+	"mysql-slave.Seconds.Behind.Master": {
+		Name: "mysql-replication-lag",
+		Unit: "s",
+	},
+	"mysql-uptime": {
+		Name: "mysql-uptime",
+		Unit: "s",
+	},
 	"Logins/sec | General Statistics-value": {
 		Name: "mssql-logins",
 		Unit: "count/s",
@@ -647,22 +1760,22 @@ var translateMap = map[string]Translation{
 	"Database Cache Memory (KB) | Memory Manager-value": {
 		Name:       "mssql-memory-db-cache",
 		Unit:       "B",
-		Conversion: divideBy(1024.0),
+		SourceUnit: "KiB",
 	},
 	"Log Pool Memory (KB) | Memory Manager-value": {
 		Name:       "mssql-memory-log-pool",
 		Unit:       "B",
-		Conversion: divideBy(1024.0),
+		SourceUnit: "KiB",
 	},
 	"Optimizer Memory (KB) | Memory Manager-value": {
 		Name:       "mssql-memory-optimizer",
 		Unit:       "B",
-		Conversion: divideBy(1024.0),
+		SourceUnit: "KiB",
 	},
 	"SQL Cache Memory (KB) | Memory Manager-value": {
 		Name:       "mssql-memory-sql-cache",
 		Unit:       "B",
-		Conversion: divideBy(1024.0),
+		SourceUnit: "KiB",
 	},
 	"Transactions/sec | _Total | Databases-value": {
 		Name: "mssql-transactions",
@@ -688,6 +1801,181 @@ var translateMap = map[string]Translation{
 		Name: "mssql-log-flushes",
 		Unit: "count/s",
 	},
+	"Page life expectancy | Buffer Manager-value": {
+		Name: "mssql-page-life-expectancy",
+		Unit: "s",
+	},
+	"Buffer cache hit ratio | Buffer Manager-value": {
+		Name: "mssql-buffer-cache-hit-ratio",
+		Unit: "ratio",
+	},
+	"Checkpoint pages/sec | Buffer Manager-value": {
+		Name: "mssql-checkpoint-pages",
+		Unit: "count/s",
+	},
+	// wait stats by category, over the 5 seconds of the query
+	"Wait time (ms)-I/O": {
+		Name:           "mssql-wait-time",
+		Specialisation: "io",
+		Unit:           "s",
+		SourceUnit:     "ms",
+	},
+	"Wait time (ms)-Latch": {
+		Name:           "mssql-wait-time",
+		Specialisation: "latch",
+		Unit:           "s",
+		SourceUnit:     "ms",
+	},
+	"Wait time (ms)-Lock": {
+		Name:           "mssql-wait-time",
+		Specialisation: "lock",
+		Unit:           "s",
+		SourceUnit:     "ms",
+	},
+	"Wait time (ms)-Network": {
+		Name:           "mssql-wait-time",
+		Specialisation: "network",
+		Unit:           "s",
+		SourceUnit:     "ms",
+	},
+	"Wait time (ms)-Service broker": {
+		Name:           "mssql-wait-time",
+		Specialisation: "service-broker",
+		Unit:           "s",
+		SourceUnit:     "ms",
+	},
+	"Wait time (ms)-Memory": {
+		Name:           "mssql-wait-time",
+		Specialisation: "memory",
+		Unit:           "s",
+		SourceUnit:     "ms",
+	},
+	"Wait time (ms)-Buffer": {
+		Name:           "mssql-wait-time",
+		Specialisation: "buffer",
+		Unit:           "s",
+		SourceUnit:     "ms",
+	},
+	"Wait time (ms)-CLR": {
+		Name:           "mssql-wait-time",
+		Specialisation: "clr",
+		Unit:           "s",
+		SourceUnit:     "ms",
+	},
+	"Wait time (ms)-SQLOS": {
+		Name:           "mssql-wait-time",
+		Specialisation: "sqlos",
+		Unit:           "s",
+		SourceUnit:     "ms",
+	},
+	"Wait time (ms)-XEvent": {
+		Name:           "mssql-wait-time",
+		Specialisation: "xevent",
+		Unit:           "s",
+		SourceUnit:     "ms",
+	},
+	"Wait time (ms)-Other": {
+		Name:           "mssql-wait-time",
+		Specialisation: "other",
+		Unit:           "s",
+		SourceUnit:     "ms",
+	},
+	"Wait time (ms)-Total": {
+		Name:           "mssql-wait-time",
+		Specialisation: "total",
+		Unit:           "s",
+		SourceUnit:     "ms",
+	},
+	"Wait tasks-I/O": {
+		Name:           "mssql-wait-tasks",
+		Specialisation: "io",
+		Unit:           "count",
+	},
+	"Wait tasks-Latch": {
+		Name:           "mssql-wait-tasks",
+		Specialisation: "latch",
+		Unit:           "count",
+	},
+	"Wait tasks-Lock": {
+		Name:           "mssql-wait-tasks",
+		Specialisation: "lock",
+		Unit:           "count",
+	},
+	"Wait tasks-Network": {
+		Name:           "mssql-wait-tasks",
+		Specialisation: "network",
+		Unit:           "count",
+	},
+	"Wait tasks-Service broker": {
+		Name:           "mssql-wait-tasks",
+		Specialisation: "service-broker",
+		Unit:           "count",
+	},
+	"Wait tasks-Memory": {
+		Name:           "mssql-wait-tasks",
+		Specialisation: "memory",
+		Unit:           "count",
+	},
+	"Wait tasks-Buffer": {
+		Name:           "mssql-wait-tasks",
+		Specialisation: "buffer",
+		Unit:           "count",
+	},
+	"Wait tasks-CLR": {
+		Name:           "mssql-wait-tasks",
+		Specialisation: "clr",
+		Unit:           "count",
+	},
+	"Wait tasks-SQLOS": {
+		Name:           "mssql-wait-tasks",
+		Specialisation: "sqlos",
+		Unit:           "count",
+	},
+	"Wait tasks-XEvent": {
+		Name:           "mssql-wait-tasks",
+		Specialisation: "xevent",
+		Unit:           "count",
+	},
+	"Wait tasks-Other": {
+		Name:           "mssql-wait-tasks",
+		Specialisation: "other",
+		Unit:           "count",
+	},
+	"Wait tasks-Total": {
+		Name:           "mssql-wait-tasks",
+		Specialisation: "total",
+		Unit:           "count",
+	},
+	// query_version 2, wait stats since the server started, specialised by
+	// the wait type
+	"sqlserver_waitstats-wait.time.ms": {
+		Name:       "mssql-wait-time",
+		Counter:    true,
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"sqlserver_waitstats-resource.wait.ms": {
+		Name:       "mssql-resource-wait-time",
+		Counter:    true,
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"sqlserver_waitstats-signal.wait.time.ms": {
+		Name:       "mssql-signal-wait-time",
+		Counter:    true,
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"sqlserver_waitstats-max.wait.time.ms": {
+		Name:       "mssql-max-wait-time",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"sqlserver_waitstats-waiting.tasks.count": {
+		Name:    "mssql-waiting-tasks",
+		Counter: true,
+		Unit:    "count",
+	},
 	"nginx-waiting": {
 		Name: "nginx-waiting",
 		Unit: "connections",
@@ -719,80 +2007,486 @@ var translateMap = map[string]Translation{
 		Counter: true,
 		Unit:    "requests",
 	},
-	"uwsgi_summary-memory-vsize": {
-		Name: "uwsgi-memory-vsize",
-		Unit: "B",
+	// nginx plus api, specialised by the zone, upstream, peer or cache
+	"nginx_plus_api_http_server_zones-processing": {
+		Name: "nginx-plus-zone-processing",
+		Unit: "requests",
 	},
-	"uwsgi_summary-memory-resident": {
-		Name: "uwsgi-memory-resident",
-		Unit: "B",
+	"nginx_plus_api_http_server_zones-requests": {
+		Name:    "nginx-plus-zone-requests",
+		Counter: true,
+		Unit:    "requests",
 	},
-	"uwsgi_summary-request-time": {
-		Name: "uwsgi-request-time",
-		Unit: "ms",
+	"nginx_plus_api_http_server_zones-responses.1xx": {
+		Name:           "nginx-plus-zone-responses",
+		Specialisation: "1xx",
+		Counter:        true,
+		Unit:           "requests",
 	},
-	"uwsgi_summary-requests": {
-		Name:    "uwsgi-requests",
+	"nginx_plus_api_http_server_zones-responses.2xx": {
+		Name:           "nginx-plus-zone-responses",
+		Specialisation: "2xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_plus_api_http_server_zones-responses.3xx": {
+		Name:           "nginx-plus-zone-responses",
+		Specialisation: "3xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_plus_api_http_server_zones-responses.4xx": {
+		Name:           "nginx-plus-zone-responses",
+		Specialisation: "4xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_plus_api_http_server_zones-responses.5xx": {
+		Name:           "nginx-plus-zone-responses",
+		Specialisation: "5xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_plus_api_http_server_zones-discarded": {
+		Name:    "nginx-plus-zone-discarded",
 		Counter: true,
 		Unit:    "requests",
 	},
-	"uwsgi_summary-workers": {
-		Name: "uwsgi-workers",
-		Unit: "",
-	},
-	"uwsgi_summary-active-workers": {
-		Name: "uwsgi-active-workers",
-		Unit: "",
+	"nginx_plus_api_http_server_zones-received": {
+		Name:    "nginx-plus-zone-received",
+		Counter: true,
+		Unit:    "B/s",
 	},
-	"uwsgi_summary-exceptions": {
-		Name:    "uwsgi-exceptions",
+	"nginx_plus_api_http_server_zones-sent": {
+		Name:    "nginx-plus-zone-sent",
 		Counter: true,
-		Unit:    "exceptions",
+		Unit:    "B/s",
 	},
-	"vault_audit_log_request-mean": {
-		Name: "vault-audit-log-requests",
-		Unit: "count",
+	"nginx_plus_api_http_upstreams-keepalive": {
+		Name: "nginx-plus-upstream-keepalive",
+		Unit: "connections",
 	},
-	"vault_audit_log_response-mean": {
-		Name: "vault-audit-log-responses",
+	"nginx_plus_api_http_upstreams-zombies": {
+		Name: "nginx-plus-upstream-zombies",
 		Unit: "count",
 	},
-	"vault_barrier_delete-mean": {
-		Name: "vault-barrier-deletes",
-		Unit: "count",
+	"nginx_plus_api_http_upstream_peers-active": {
+		Name: "nginx-plus-peer-active",
+		Unit: "connections",
 	},
-	"vault_barrier_get-mean": {
-		Name: "vault-barrier-get-ops",
-		Unit: "count",
+	"nginx_plus_api_http_upstream_peers-requests": {
+		Name:    "nginx-plus-peer-requests",
+		Counter: true,
+		Unit:    "requests",
 	},
-	"vault_barrier_put-mean": {
-		Name: "vault-barrier-put-ops",
-		Unit: "count",
+	"nginx_plus_api_http_upstream_peers-responses.1xx": {
+		Name:           "nginx-plus-peer-responses",
+		Specialisation: "1xx",
+		Counter:        true,
+		Unit:           "requests",
 	},
-	"vault_barrier_list-value": {
-		Name: "vault-barrier-list-ops",
-		Unit: "count",
+	"nginx_plus_api_http_upstream_peers-responses.2xx": {
+		Name:           "nginx-plus-peer-responses",
+		Specialisation: "2xx",
+		Counter:        true,
+		Unit:           "requests",
 	},
-	"vault_core_check_token-mean": {
-		Name:    "vault-token-checks",
-		Counter: true,
-		Unit:    "count",
+	"nginx_plus_api_http_upstream_peers-responses.3xx": {
+		Name:           "nginx-plus-peer-responses",
+		Specialisation: "3xx",
+		Counter:        true,
+		Unit:           "requests",
 	},
-	"vault_core_fetch_acl_and_token-mean": {
-		Name: "vault-acl-and-token-fetches",
-		Unit: "count",
+	"nginx_plus_api_http_upstream_peers-responses.4xx": {
+		Name:           "nginx-plus-peer-responses",
+		Specialisation: "4xx",
+		Counter:        true,
+		Unit:           "requests",
 	},
-	"vault_core_handle_request-mean": {
-		Name: "vault-requests",
-		Unit: "count",
+	"nginx_plus_api_http_upstream_peers-responses.5xx": {
+		Name:           "nginx-plus-peer-responses",
+		Specialisation: "5xx",
+		Counter:        true,
+		Unit:           "requests",
 	},
-	"vault_core_handle_login_request-mean": {
-		Name:    "vault-login-requests",
+	"nginx_plus_api_http_upstream_peers-received": {
+		Name:    "nginx-plus-peer-received",
 		Counter: true,
-		Unit:    "count",
+		Unit:    "B/s",
 	},
-	"vault_core_leadership_setup_failed-mean": {
-		Name:    "vault-leadership-setup-failures",
+	"nginx_plus_api_http_upstream_peers-sent": {
+		Name:    "nginx-plus-peer-sent",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"nginx_plus_api_http_upstream_peers-fails": {
+		Name:    "nginx-plus-peer-fails",
+		Counter: true,
+		Unit:    "count",
+	},
+	"nginx_plus_api_http_upstream_peers-unavail": {
+		Name:    "nginx-plus-peer-unavailable",
+		Counter: true,
+		Unit:    "count",
+	},
+	"nginx_plus_api_http_upstream_peers-header.time": {
+		Name:       "nginx-plus-peer-header-time",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"nginx_plus_api_http_upstream_peers-response.time": {
+		Name:       "nginx-plus-peer-response-time",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"nginx_plus_api_http_caches-size": {
+		Name: "nginx-plus-cache-size",
+		Unit: "B",
+	},
+	"nginx_plus_api_http_caches-max.size": {
+		Name: "nginx-plus-cache-max-size",
+		Unit: "B",
+	},
+	"nginx_plus_api_http_caches-hit.responses": {
+		Name:    "nginx-plus-cache-hits",
+		Counter: true,
+		Unit:    "requests",
+	},
+	"nginx_plus_api_http_caches-miss.responses": {
+		Name:    "nginx-plus-cache-misses",
+		Counter: true,
+		Unit:    "requests",
+	},
+	"nginx_plus_api_http_caches-hit.ratio": {
+		Name: "nginx-plus-cache-hit-ratio",
+		Unit: "percent",
+		// derived from the hit and miss responses, see deriveFields
+	},
+	"nginx_plus_api_stream_server_zones-processing": {
+		Name: "nginx-plus-stream-zone-processing",
+		Unit: "connections",
+	},
+	"nginx_plus_api_stream_server_zones-connections": {
+		Name:    "nginx-plus-stream-zone-connections",
+		Counter: true,
+		Unit:    "connections",
+	},
+	"nginx_plus_api_stream_server_zones-received": {
+		Name:    "nginx-plus-stream-zone-received",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"nginx_plus_api_stream_server_zones-sent": {
+		Name:    "nginx-plus-stream-zone-sent",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	// nginx vts module, specialised by the server zone, filter, upstream or cache zone
+	"nginx_vts_server-requests": {
+		Name:    "nginx-vts-zone-requests",
+		Counter: true,
+		Unit:    "requests",
+	},
+	"nginx_vts_server-response.1xx.count": {
+		Name:           "nginx-vts-zone-responses",
+		Specialisation: "1xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_server-response.2xx.count": {
+		Name:           "nginx-vts-zone-responses",
+		Specialisation: "2xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_server-response.3xx.count": {
+		Name:           "nginx-vts-zone-responses",
+		Specialisation: "3xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_server-response.4xx.count": {
+		Name:           "nginx-vts-zone-responses",
+		Specialisation: "4xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_server-response.5xx.count": {
+		Name:           "nginx-vts-zone-responses",
+		Specialisation: "5xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_server-in.bytes": {
+		Name:    "nginx-vts-zone-received",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"nginx_vts_server-out.bytes": {
+		Name:    "nginx-vts-zone-sent",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"nginx_vts_server-request.time": {
+		Name:       "nginx-vts-zone-request-time",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"nginx_vts_filter-requests": {
+		Name:    "nginx-vts-filter-requests",
+		Counter: true,
+		Unit:    "requests",
+	},
+	"nginx_vts_filter-response.1xx.count": {
+		Name:           "nginx-vts-filter-responses",
+		Specialisation: "1xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_filter-response.2xx.count": {
+		Name:           "nginx-vts-filter-responses",
+		Specialisation: "2xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_filter-response.3xx.count": {
+		Name:           "nginx-vts-filter-responses",
+		Specialisation: "3xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_filter-response.4xx.count": {
+		Name:           "nginx-vts-filter-responses",
+		Specialisation: "4xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_filter-response.5xx.count": {
+		Name:           "nginx-vts-filter-responses",
+		Specialisation: "5xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_filter-request.time": {
+		Name:       "nginx-vts-filter-request-time",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"nginx_vts_upstream-requests": {
+		Name:    "nginx-vts-upstream-requests",
+		Counter: true,
+		Unit:    "requests",
+	},
+	"nginx_vts_upstream-response.1xx.count": {
+		Name:           "nginx-vts-upstream-responses",
+		Specialisation: "1xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_upstream-response.2xx.count": {
+		Name:           "nginx-vts-upstream-responses",
+		Specialisation: "2xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_upstream-response.3xx.count": {
+		Name:           "nginx-vts-upstream-responses",
+		Specialisation: "3xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_upstream-response.4xx.count": {
+		Name:           "nginx-vts-upstream-responses",
+		Specialisation: "4xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_upstream-response.5xx.count": {
+		Name:           "nginx-vts-upstream-responses",
+		Specialisation: "5xx",
+		Counter:        true,
+		Unit:           "requests",
+	},
+	"nginx_vts_upstream-in.bytes": {
+		Name:    "nginx-vts-upstream-received",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"nginx_vts_upstream-out.bytes": {
+		Name:    "nginx-vts-upstream-sent",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"nginx_vts_upstream-request.time": {
+		Name:       "nginx-vts-upstream-request-time",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"nginx_vts_upstream-response.time": {
+		Name:       "nginx-vts-upstream-response-time",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"nginx_vts_cache-max.bytes": {
+		Name: "nginx-vts-cache-max-size",
+		Unit: "B",
+	},
+	"nginx_vts_cache-used.bytes": {
+		Name: "nginx-vts-cache-used",
+		Unit: "B",
+	},
+	"nginx_vts_cache-hit": {
+		Name:    "nginx-vts-cache-hits",
+		Counter: true,
+		Unit:    "requests",
+	},
+	"nginx_vts_cache-miss": {
+		Name:    "nginx-vts-cache-misses",
+		Counter: true,
+		Unit:    "requests",
+	},
+	"nginx_vts_cache-hit.ratio": {
+		Name: "nginx-vts-cache-hit-ratio",
+		Unit: "percent",
+		// derived from the hits and misses, see deriveFields
+	},
+	"uwsgi_summary-memory-vsize": {
+		Name: "uwsgi-memory-vsize",
+		Unit: "B",
+	},
+	"uwsgi_summary-memory-resident": {
+		Name: "uwsgi-memory-resident",
+		Unit: "B",
+	},
+	"uwsgi_summary-request-time": {
+		Name: "uwsgi-request-time",
+		Unit: "ms",
+	},
+	"uwsgi_summary-requests": {
+		Name:    "uwsgi-requests",
+		Counter: true,
+		Unit:    "requests",
+	},
+	"uwsgi_summary-workers": {
+		Name: "uwsgi-workers",
+		Unit: "",
+	},
+	"uwsgi_summary-active-workers": {
+		Name: "uwsgi-active-workers",
+		Unit: "",
+	},
+	"uwsgi_summary-exceptions": {
+		Name:    "uwsgi-exceptions",
+		Counter: true,
+		Unit:    "exceptions",
+	},
+	// per worker, specialised by the worker id
+	"uwsgi_workers-requests": {
+		Name:    "uwsgi-worker-requests",
+		Counter: true,
+		Unit:    "requests",
+	},
+	"uwsgi_workers-exceptions": {
+		Name:    "uwsgi-worker-exceptions",
+		Counter: true,
+		Unit:    "exceptions",
+	},
+	"uwsgi_workers-harakiri.count": {
+		Name:    "uwsgi-worker-harakiris",
+		Counter: true,
+		Unit:    "count",
+	},
+	"uwsgi_workers-respawn.count": {
+		Name:    "uwsgi-worker-respawns",
+		Counter: true,
+		Unit:    "count",
+	},
+	"uwsgi_workers-status": {
+		Name:       "uwsgi-worker-status",
+		Unit:       "",
+		Conversion: uwsgiWorkerStatus,
+	},
+	"uwsgi_workers-accepting": {
+		Name: "uwsgi-worker-accepting",
+		Unit: "",
+	},
+	"uwsgi_workers-signal.queue": {
+		Name: "uwsgi-worker-signal-queue",
+		Unit: "count",
+	},
+	"uwsgi_workers-rss": {
+		Name: "uwsgi-worker-memory-resident",
+		Unit: "B",
+	},
+	"uwsgi_workers-vsz": {
+		Name: "uwsgi-worker-memory-vsize",
+		Unit: "B",
+	},
+	"uwsgi_workers-avg.rt": {
+		Name:       "uwsgi-worker-request-time",
+		Unit:       "s",
+		SourceUnit: "us",
+	},
+	"uwsgi_workers-tx": {
+		Name:    "uwsgi-worker-sent",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	// per app of a worker, specialised by the worker and app id
+	"uwsgi_apps-requests": {
+		Name:    "uwsgi-app-requests",
+		Counter: true,
+		Unit:    "requests",
+	},
+	"uwsgi_apps-exceptions": {
+		Name:    "uwsgi-app-exceptions",
+		Counter: true,
+		Unit:    "exceptions",
+	},
+	"vault_audit_log_request-mean": {
+		Name: "vault-audit-log-requests",
+		Unit: "count",
+	},
+	"vault_audit_log_response-mean": {
+		Name: "vault-audit-log-responses",
+		Unit: "count",
+	},
+	"vault_barrier_delete-mean": {
+		Name: "vault-barrier-deletes",
+		Unit: "count",
+	},
+	"vault_barrier_get-mean": {
+		Name: "vault-barrier-get-ops",
+		Unit: "count",
+	},
+	"vault_barrier_put-mean": {
+		Name: "vault-barrier-put-ops",
+		Unit: "count",
+	},
+	"vault_barrier_list-value": {
+		Name: "vault-barrier-list-ops",
+		Unit: "count",
+	},
+	"vault_core_check_token-mean": {
+		Name:    "vault-token-checks",
+		Counter: true,
+		Unit:    "count",
+	},
+	"vault_core_fetch_acl_and_token-mean": {
+		Name: "vault-acl-and-token-fetches",
+		Unit: "count",
+	},
+	"vault_core_handle_request-mean": {
+		Name: "vault-requests",
+		Unit: "count",
+	},
+	"vault_core_handle_login_request-mean": {
+		Name:    "vault-login-requests",
+		Counter: true,
+		Unit:    "count",
+	},
+	"vault_core_leadership_setup_failed-mean": {
+		Name:    "vault-leadership-setup-failures",
 		Counter: true,
 		Unit:    "count",
 	},
@@ -831,6 +2525,69 @@ var translateMap = map[string]Translation{
 		Counter: true,
 		Unit:    "count",
 	},
+	// integrated storage
+	"vault_raft_commitTime-mean": {
+		Name:       "vault-raft-commit-time",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"vault_raft_commitTime-upper": {
+		Name:       "vault-raft-commit-time-max",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"vault_raft_fsm_apply-mean": {
+		Name:       "vault-raft-fsm-apply-time",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"vault_raft_fsm_apply-upper": {
+		Name:       "vault-raft-fsm-apply-time-max",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"vault_raft_leader_lastContact-mean": {
+		Name:       "vault-raft-leader-last-contact",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"vault_raft_leader_dispatchLog-mean": {
+		Name:       "vault-raft-dispatch-log-time",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"vault_raft_apply-value": {
+		Name: "vault-raft-applies",
+		Unit: "count",
+	},
+	"vault_raft_state_leader-value": {
+		Name:           "vault-raft-state-changes",
+		Specialisation: "leader",
+		Unit:           "count",
+	},
+	"vault_raft_state_candidate-value": {
+		Name:           "vault-raft-state-changes",
+		Specialisation: "candidate",
+		Unit:           "count",
+	},
+	"vault_raft_state_follower-value": {
+		Name:           "vault-raft-state-changes",
+		Specialisation: "follower",
+		Unit:           "count",
+	},
+	"vault_raft_transition_heartbeat_timeout-value": {
+		Name: "vault-raft-heartbeat-timeouts",
+		Unit: "count",
+	},
+	// 1 if all the raft peers are healthy
+	"vault_autopilot_healthy-value": {
+		Name: "vault-autopilot-healthy",
+		Unit: "",
+	},
+	"vault_autopilot_failure_tolerance-value": {
+		Name: "vault-autopilot-failure-tolerance",
+		Unit: "count",
+	},
 	"vault_runtime_alloc_bytes-value": {
 		Name: "vault-allocated-bytes",
 		Unit: "B",
@@ -860,13 +2617,13 @@ var translateMap = map[string]Translation{
 	"vault_runtime_gc_pause_ns-mean": {
 		Name:       "vault-gc-pause-time-avg",
 		Unit:       "s",
-		Conversion: divideBy(1000 * 1000 * 1000),
+		SourceUnit: "ns",
 	},
 	"vault_runtime_total_gc_pause_ns-value": {
 		Name:       "vault-gc-pause-time",
 		Counter:    true,
 		Unit:       "s",
-		Conversion: divideBy(1000 * 1000 * 1000),
+		SourceUnit: "ns",
 	},
 	"vault_runtime_total_gc_runs-value": {
 		Name:    "vault-gc-runs",
@@ -957,61 +2714,73 @@ var translateMap = map[string]Translation{
 		Counter: true,
 		Unit:    "count",
 	},
-	"vault_rollback_attempt_auth-token--mean": {
-		Name: "vault-rollback-attempts-auth-token",
+	"vault_etcd_put-value": {
+		Name: "vault-etcd-put-ops",
 		Unit: "count",
 	},
-	"vault_rollback_attempt_cubbyhole--mean": {
-		Name: "vault-rollback-attempts-cubbyhole",
+	"vault_etcd_get-value": {
+		Name: "vault-etcd-get-ops",
 		Unit: "count",
 	},
-	"vault_rollback_attempt_secret--mean": {
-		Name: "vault-rollback-attempts-secret",
+	"vault_etcd_delete-value": {
+		Name: "vault-etcd-delete-ops",
 		Unit: "count",
 	},
-	"vault_rollback_attempt_sys--mean": {
-		Name: "vault-rollback-attempts-sys",
-		Unit: "count",
-	},
-	"vault_rollback_attempt_pki--mean": {
-		Name: "vault-rollback-attempts-pki",
+	"vault_etcd_list-value": {
+		Name: "vault-etcd-list-ops",
 		Unit: "count",
 	},
-	"vault_route_rollback_auth-token--mean": {
-		Name: "vault-route-rollbacks-auth-token",
-		Unit: "count",
+	"consul_health_checks-status": {
+		Name:       "consul-health-check-status",
+		Unit:       "",
+		Conversion: consulHealthStatus,
 	},
-	"vault_route_rollback_cubbyhole--mean": {
-		Name: "vault-route-rollbacks-cubbyhole",
+	"consul_raft_state_leader-value": {
+		Name: "consul-raft-leader-elections",
 		Unit: "count",
 	},
-	"vault_route_rollback_secret--mean": {
-		Name: "vault-route-rollbacks-secret",
+	"consul_raft_state_candidate-value": {
+		Name: "consul-raft-candidate-transitions",
 		Unit: "count",
 	},
-	"vault_route_rollback_sys--mean": {
-		Name: "vault-route-rollbacks-sys",
-		Unit: "count",
+	"consul_raft_leader_lastContact-mean": {
+		Name:       "consul-raft-leader-last-contact",
+		Unit:       "s",
+		SourceUnit: "ms",
 	},
-	"vault_route_rollback_pki--mean": {
-		Name: "vault-route-rollbacks-pki",
-		Unit: "count",
+	"consul_autopilot_healthy-value": {
+		Name: "consul-autopilot-healthy",
+		Unit: "",
 	},
-	"vault_etcd_put-value": {
-		Name: "vault-etcd-put-ops",
-		Unit: "count",
+	"consul_members_clients-value": {
+		Name:           "consul-members",
+		Specialisation: "clients",
+		Unit:           "count",
 	},
-	"vault_etcd_get-value": {
-		Name: "vault-etcd-get-ops",
-		Unit: "count",
+	"consul_members_servers-value": {
+		Name:           "consul-members",
+		Specialisation: "servers",
+		Unit:           "count",
 	},
-	"vault_etcd_delete-value": {
-		Name: "vault-etcd-delete-ops",
-		Unit: "count",
+	"consul_serf_member_join-value": {
+		Name:           "consul-serf-member-events",
+		Specialisation: "join",
+		Unit:           "count",
 	},
-	"vault_etcd_list-value": {
-		Name: "vault-etcd-list-ops",
-		Unit: "count",
+	"consul_serf_member_left-value": {
+		Name:           "consul-serf-member-events",
+		Specialisation: "left",
+		Unit:           "count",
+	},
+	"consul_serf_member_failed-value": {
+		Name:           "consul-serf-member-events",
+		Specialisation: "failed",
+		Unit:           "count",
+	},
+	"consul_serf_member_flap-value": {
+		Name:           "consul-serf-member-events",
+		Specialisation: "flap",
+		Unit:           "count",
 	},
 	"redis-blocked.clients": {
 		Name: "redis-blocked-clients",
@@ -1143,6 +2912,241 @@ var translateMap = map[string]Translation{
 		Name: "redis-used-memory-rss",
 		Unit: "B",
 	},
+	"memcached-get.hits": {
+		Name:    "memcached-get-hits",
+		Counter: true,
+		Unit:    "count",
+	},
+	"memcached-get.misses": {
+		Name:    "memcached-get-misses",
+		Counter: true,
+		Unit:    "count",
+	},
+	// derived from get_hits and get_misses, see deriveFields
+	"memcached-get.hitrate": {
+		Name: "memcached-get-hitrate",
+		Unit: "percent",
+	},
+	"memcached-cmd.get": {
+		Name:           "memcached-commands",
+		Specialisation: "get",
+		Counter:        true,
+		Unit:           "count",
+	},
+	"memcached-cmd.set": {
+		Name:           "memcached-commands",
+		Specialisation: "set",
+		Counter:        true,
+		Unit:           "count",
+	},
+	"memcached-evictions": {
+		Name:    "memcached-evictions",
+		Counter: true,
+		Unit:    "count",
+	},
+	"memcached-curr.connections": {
+		Name: "memcached-connections",
+		Unit: "count",
+	},
+	"memcached-curr.items": {
+		Name: "memcached-items",
+		Unit: "count",
+	},
+	"memcached-bytes": {
+		Name: "memcached-used-memory",
+		Unit: "B",
+	},
+	"memcached-limit.maxbytes": {
+		Name: "memcached-max-memory",
+		Unit: "B",
+	},
+	"memcached-bytes.read": {
+		Name:    "memcached-net-input",
+		Counter: true,
+		Unit:    "B",
+	},
+	"memcached-bytes.written": {
+		Name:    "memcached-net-output",
+		Counter: true,
+		Unit:    "B",
+	},
+	"nats-in.msgs": {
+		Name:           "nats-messages",
+		Specialisation: "in",
+		Counter:        true,
+		Unit:           "count/s",
+	},
+	"nats-out.msgs": {
+		Name:           "nats-messages",
+		Specialisation: "out",
+		Counter:        true,
+		Unit:           "count/s",
+	},
+	"nats-in.bytes": {
+		Name:           "nats-bytes",
+		Specialisation: "in",
+		Counter:        true,
+		Unit:           "B/s",
+	},
+	"nats-out.bytes": {
+		Name:           "nats-bytes",
+		Specialisation: "out",
+		Counter:        true,
+		Unit:           "B/s",
+	},
+	"nats-connections": {
+		Name: "nats-connections",
+		Unit: "count",
+	},
+	"nats-total.connections": {
+		Name:    "nats-total-connections",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"nats-subscriptions": {
+		Name: "nats-subscriptions",
+		Unit: "count",
+	},
+	"nats-slow.consumers": {
+		Name:    "nats-slow-consumers",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"nats-routes": {
+		Name: "nats-routes",
+		Unit: "count",
+	},
+	"nats-remotes": {
+		Name: "nats-remotes",
+		Unit: "count",
+	},
+	"nats-cpu": {
+		Name: "nats-cpu-usage",
+		Unit: "percent",
+	},
+	"nats-mem": {
+		Name: "nats-memory",
+		Unit: "B",
+	},
+	"nats-uptime": {
+		// nanoseconds in
+		Name:       "nats-uptime",
+		Unit:       "s",
+		SourceUnit: "ns",
+	},
+	"couchdb-couchdb.open.databases.current": {
+		Name: "couchdb-open-databases",
+		Unit: "count",
+	},
+	"couchdb-couchdb.open.os.files.current": {
+		Name: "couchdb-open-os-files",
+		Unit: "count",
+	},
+	"couchdb-couchdb.database.reads.current": {
+		Name:    "couchdb-database-reads",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"couchdb-couchdb.database.writes.current": {
+		Name:    "couchdb-database-writes",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"couchdb-httpd.requests.current": {
+		Name:    "couchdb-requests",
+		Counter: true,
+		Unit:    "requests/s",
+	},
+	"couchdb-httpd.bulk.requests.current": {
+		Name:    "couchdb-bulk-requests",
+		Counter: true,
+		Unit:    "requests/s",
+	},
+	"couchdb-couchdb.open.databases.value": {
+		Name: "couchdb-open-databases",
+		Unit: "count",
+	},
+	"couchdb-couchdb.open.os.files.value": {
+		Name: "couchdb-open-os-files",
+		Unit: "count",
+	},
+	"couchdb-couchdb.database.reads.value": {
+		Name:    "couchdb-database-reads",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"couchdb-couchdb.database.writes.value": {
+		Name:    "couchdb-database-writes",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"couchdb-httpd.requests.value": {
+		Name:    "couchdb-requests",
+		Counter: true,
+		Unit:    "requests/s",
+	},
+	"couchdb-httpd.bulk.requests.value": {
+		Name:    "couchdb-bulk-requests",
+		Counter: true,
+		Unit:    "requests/s",
+	},
+	"couchdb-couchdb.request.time.mean": {
+		// milliseconds in
+		Name:       "couchdb-request-time",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"couchdb-couchdb.request.time.max": {
+		Name:       "couchdb-request-time-max",
+		Unit:       "s",
+		SourceUnit: "ms",
+	},
+	"phpfpm-accepted.conn": {
+		Name:    "phpfpm-accepted-connections",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"phpfpm-listen.queue": {
+		Name: "phpfpm-listen-queue",
+		Unit: "count",
+	},
+	"phpfpm-max.listen.queue": {
+		Name: "phpfpm-max-listen-queue",
+		Unit: "count",
+	},
+	"phpfpm-listen.queue.len": {
+		Name: "phpfpm-listen-queue-len",
+		Unit: "count",
+	},
+	"phpfpm-active.processes": {
+		Name:           "phpfpm-processes",
+		Specialisation: "active",
+		Unit:           "count",
+	},
+	"phpfpm-idle.processes": {
+		Name:           "phpfpm-processes",
+		Specialisation: "idle",
+		Unit:           "count",
+	},
+	"phpfpm-total.processes": {
+		Name:           "phpfpm-processes",
+		Specialisation: "total",
+		Unit:           "count",
+	},
+	"phpfpm-max.active.processes": {
+		Name: "phpfpm-max-active-processes",
+		Unit: "count",
+	},
+	"phpfpm-max.children.reached": {
+		Name:    "phpfpm-max-children-reached",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"phpfpm-slow.requests": {
+		Name:    "phpfpm-slow-requests",
+		Counter: true,
+		Unit:    "requests/s",
+	},
 	"zookeeper-outstanding.requests": {
 		Name: "zookeeper-outstanding-requests",
 		Unit: "count",
@@ -1219,47 +3223,47 @@ var translateMap = map[string]Translation{
 		Name:       "kafka-produce-time-total",
 		Counter:    true,
 		Unit:       "s",
-		Conversion: divideBy(1000),
+		SourceUnit: "ms",
 	},
 	"kafka.network-RequestMetrics.Count.FetchConsumer.TotalTimeMs": {
 		Name:       "kafka-fetch-consumer-time-total",
 		Unit:       "s",
-		Conversion: divideBy(1000),
+		SourceUnit: "ms",
 	},
 	"kafka.network-RequestMetrics.Count.FetchFollower.TotalTimeMs": {
 		Name:       "kafka-fetch-follower-time-total",
 		Unit:       "s",
-		Conversion: divideBy(1000),
+		SourceUnit: "ms",
 	},
 	"kafka.network-RequestMetrics.Min.Produce.TotalTimeMs": {
 		Name:       "kafka-produce-time-total-min",
 		Unit:       "s",
-		Conversion: divideBy(1000),
+		SourceUnit: "ms",
 	},
 	"kafka.network-RequestMetrics.Max.Produce.TotalTimeMs": {
 		Name:       "kafka-produce-time-total-max",
 		Unit:       "s",
-		Conversion: divideBy(1000),
+		SourceUnit: "ms",
 	},
 	"kafka.network-RequestMetrics.Min.FetchConsumer.TotalTimeMs": {
 		Name:       "kafka-fetch-consumer-time-total-min",
 		Unit:       "s",
-		Conversion: divideBy(1000),
+		SourceUnit: "ms",
 	},
 	"kafka.network-RequestMetrics.Max.FetchConsumer.TotalTimeMs": {
 		Name:       "kafka-fetch-consumer-time-total-max",
 		Unit:       "s",
-		Conversion: divideBy(1000),
+		SourceUnit: "ms",
 	},
 	"kafka.network-RequestMetrics.Min.FetchFollower.TotalTimeMs": {
 		Name:       "kafka-fetch-follower-time-total-min",
 		Unit:       "s",
-		Conversion: divideBy(1000),
+		SourceUnit: "ms",
 	},
 	"kafka.network-RequestMetrics.Max.FetchFollower.TotalTimeMs": {
 		Name:       "kafka-fetch-follower-time-total-max",
 		Unit:       "s",
-		Conversion: divideBy(1000),
+		SourceUnit: "ms",
 	},
 	"kafka.server-Fetch.queue-size": {
 		Name: "kafka-fetch-queue-size",
@@ -1345,7 +3349,7 @@ var translateMap = map[string]Translation{
 	"kafka.server-replica-fetcher-metrics.io-time-ns-avg": {
 		Name:       "kafka-replica-fetcher-io-time",
 		Unit:       "s",
-		Conversion: divideBy(1000 * 1000 * 1000),
+		SourceUnit: "ns",
 	},
 	"kafka.server-replica-fetcher-metrics.io-wait-ratio": {
 		Name: "kafka-replica-fetcher-io-wait-ratio",
@@ -1354,7 +3358,7 @@ var translateMap = map[string]Translation{
 	"kafka.server-replica-fetcher-metrics.io-wait-time-ns-avg": {
 		Name:       "kafka-replica-fetcher-io-wait-time",
 		Unit:       "s",
-		Conversion: divideBy(1000 * 1000 * 1000),
+		SourceUnit: "ns",
 	},
 	"kafka.server-replica-fetcher-metrics.network-io-rate": {
 		Name: "kafka-replica-fetcher-network-io-rate",
@@ -1416,7 +3420,7 @@ var translateMap = map[string]Translation{
 	"kafka.server-socket-server-metrics.io-time-ns-avg": {
 		Name:       "kafka-socket-avg-io-time",
 		Unit:       "s",
-		Conversion: divideBy(1000 * 1000 * 1000),
+		SourceUnit: "ns",
 	},
 	"kafka.server-socket-server-metrics.io-wait-ratio": {
 		Name: "kafka-socket-io-wait",
@@ -1425,7 +3429,7 @@ var translateMap = map[string]Translation{
 	"kafka.server-socket-server-metrics.io-wait-time-ns-avg": {
 		Name:       "kafka-socket-io-wait-time",
 		Unit:       "s",
-		Conversion: divideBy(1000 * 1000 * 1000),
+		SourceUnit: "ns",
 	},
 	"kafka.server-socket-server-metrics.network-io-rate": {
 		Name: "kafka-socket-network-io-rate",
@@ -1521,6 +3525,186 @@ var translateMap = map[string]Translation{
 		Name: "kafka-controller-stats-max",
 		Unit: "count",
 	},
+	"java_Memory-HeapMemoryUsage.used": {
+		Name:           "java-memory",
+		Specialisation: "heap.used",
+		Unit:           "B",
+	},
+	"java_Memory-HeapMemoryUsage.committed": {
+		Name:           "java-memory",
+		Specialisation: "heap.committed",
+		Unit:           "B",
+	},
+	"java_Memory-HeapMemoryUsage.max": {
+		Name:           "java-memory",
+		Specialisation: "heap.max",
+		Unit:           "B",
+	},
+	"java_Memory-NonHeapMemoryUsage.used": {
+		Name:           "java-memory",
+		Specialisation: "nonheap.used",
+		Unit:           "B",
+	},
+	"java_Memory-NonHeapMemoryUsage.committed": {
+		Name:           "java-memory",
+		Specialisation: "nonheap.committed",
+		Unit:           "B",
+	},
+	"cassandra_ClientRequest-Latency.Count": {
+		Name:    "cassandra-client-requests",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"cassandra_ClientRequest-Latency.Mean": {
+		// cassandra latencies are in microseconds
+		Name:       "cassandra-client-request-latency",
+		Unit:       "s",
+		SourceUnit: "us",
+	},
+	"cassandra_ClientRequest-Latency.99thPercentile": {
+		Name:       "cassandra-client-request-latency-p99",
+		Unit:       "s",
+		SourceUnit: "us",
+	},
+	"cassandra_ClientRequest-Timeouts.Count": {
+		Name:    "cassandra-client-request-timeouts",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"cassandra_ClientRequest-Unavailables.Count": {
+		Name:    "cassandra-client-request-unavailables",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"cassandra_Compaction-PendingTasks.Value": {
+		Name: "cassandra-pending-compactions",
+		Unit: "count",
+	},
+	"cassandra_Compaction-CompletedTasks.Value": {
+		Name:    "cassandra-completed-compactions",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"cassandra_DroppedMessage-Dropped.Count": {
+		Name:    "cassandra-dropped-messages",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"cassandra_ColumnFamily-LiveSSTableCount.Value": {
+		Name: "cassandra-sstables",
+		Unit: "count",
+	},
+	"cassandra_ColumnFamily-LiveDiskSpaceUsed.Count": {
+		Name: "cassandra-disk-space-used",
+		Unit: "B",
+	},
+	"cassandra_Storage-Load.Count": {
+		Name: "cassandra-load",
+		Unit: "B",
+	},
+	"cassandra_Storage-Exceptions.Count": {
+		Name:    "cassandra-exceptions",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"ceph_health-status": {
+		Name:       "ceph-health",
+		Unit:       "",
+		Conversion: cephHealth,
+	},
+	"ceph_osdmap-num.osds": {
+		Name:           "ceph-osds",
+		Specialisation: "total",
+		Unit:           "count",
+	},
+	"ceph_osdmap-num.up.osds": {
+		Name:           "ceph-osds",
+		Specialisation: "up",
+		Unit:           "count",
+	},
+	"ceph_osdmap-num.in.osds": {
+		Name:           "ceph-osds",
+		Specialisation: "in",
+		Unit:           "count",
+	},
+	"ceph_osdmap-full": {
+		Name:       "ceph-full",
+		Unit:       "",
+		Conversion: boolToInt,
+	},
+	"ceph_osdmap-nearfull": {
+		Name:       "ceph-nearfull",
+		Unit:       "",
+		Conversion: boolToInt,
+	},
+	"ceph_pgmap-num.pgs": {
+		Name: "ceph-pgs",
+		Unit: "count",
+	},
+	"ceph_pgmap_state-count": {
+		Name: "ceph-pgs-by-state",
+		Unit: "count",
+	},
+	"ceph_pgmap-data.bytes": {
+		Name:           "ceph-cluster-bytes",
+		Specialisation: "data",
+		Unit:           "B",
+	},
+	"ceph_pgmap-bytes.used": {
+		Name:           "ceph-cluster-bytes",
+		Specialisation: "used",
+		Unit:           "B",
+	},
+	"ceph_pgmap-bytes.avail": {
+		Name:           "ceph-cluster-bytes",
+		Specialisation: "avail",
+		Unit:           "B",
+	},
+	"ceph_pgmap-bytes.total": {
+		Name:           "ceph-cluster-bytes",
+		Specialisation: "total",
+		Unit:           "B",
+	},
+	"ceph_pgmap-read.bytes.sec": {
+		Name:           "ceph-client-io",
+		Specialisation: "read",
+		Unit:           "B/s",
+	},
+	"ceph_pgmap-write.bytes.sec": {
+		Name:           "ceph-client-io",
+		Specialisation: "write",
+		Unit:           "B/s",
+	},
+	"ceph_pgmap-op.per.sec": {
+		Name: "ceph-client-ops",
+		Unit: "count/s",
+	},
+	"ceph_pool_usage-bytes.used": {
+		Name: "ceph-pool-used",
+		Unit: "B",
+	},
+	"ceph_pool_usage-objects": {
+		Name: "ceph-pool-objects",
+		Unit: "count",
+	},
+	"ceph_pool_stats-read.bytes.sec": {
+		Name:           "ceph-pool-client-io",
+		Specialisation: "read",
+		Unit:           "B/s",
+	},
+	"ceph_pool_stats-write.bytes.sec": {
+		Name:           "ceph-pool-client-io",
+		Specialisation: "write",
+		Unit:           "B/s",
+	},
+	"ceph_pool_stats-op.per.sec": {
+		Name: "ceph-pool-client-ops",
+		Unit: "count/s",
+	},
+	"ceph_pool_stats-recovering.bytes.per.sec": {
+		Name: "ceph-pool-recovery",
+		Unit: "B/s",
+	},
 	"minio_network_sent_bytes_total-counter": {
 		Name: "minio-network-sent-total",
 		Unit: "B",
@@ -1553,6 +3737,62 @@ var translateMap = map[string]Translation{
 		Name: "minio-http-requests-count",
 		Unit: "count",
 	},
+	// per bucket, specialised by the bucket
+	"minio_bucket_usage_total_bytes-gauge": {
+		Name: "minio-bucket-usage",
+		Unit: "B",
+	},
+	"minio_bucket_usage_object_total-gauge": {
+		Name: "minio-bucket-objects",
+		Unit: "count",
+	},
+	"minio_bucket_traffic_received_bytes-counter": {
+		Name:    "minio-bucket-traffic-received",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	"minio_bucket_traffic_sent_bytes-counter": {
+		Name:    "minio-bucket-traffic-sent",
+		Counter: true,
+		Unit:    "B/s",
+	},
+	// per node, specialised by the server and disk
+	"minio_node_disk_total_bytes-gauge": {
+		Name: "minio-node-disk-total",
+		Unit: "B",
+	},
+	"minio_node_disk_used_bytes-gauge": {
+		Name: "minio-node-disk-used",
+		Unit: "B",
+	},
+	"minio_node_disk_free_bytes-gauge": {
+		Name: "minio-node-disk-free",
+		Unit: "B",
+	},
+	"minio_node_process_uptime_seconds-gauge": {
+		Name: "minio-node-uptime",
+		Unit: "s",
+	},
+	"minio_cluster_nodes_online_total-gauge": {
+		Name:           "minio-cluster-nodes",
+		Specialisation: "online",
+		Unit:           "count",
+	},
+	"minio_cluster_nodes_offline_total-gauge": {
+		Name:           "minio-cluster-nodes",
+		Specialisation: "offline",
+		Unit:           "count",
+	},
+	"minio_cluster_disk_online_total-gauge": {
+		Name:           "minio-cluster-disks",
+		Specialisation: "online",
+		Unit:           "count",
+	},
+	"minio_cluster_disk_offline_total-gauge": {
+		Name:           "minio-cluster-disks",
+		Specialisation: "offline",
+		Unit:           "count",
+	},
 	"influxdb_memstats-sys": {
 		Name: "influxdb-memstats-sys",
 		Unit: "B",
@@ -1604,7 +3844,7 @@ var translateMap = map[string]Translation{
 	"influxdb_runtime-PauseTotalNs": {
 		Name:       "influxdb-runtime-pause-total",
 		Unit:       "s",
-		Conversion: divideBy(1000 * 1000 * 1000),
+		SourceUnit: "ns",
 	},
 	"influxdb_runtime-Sys": {
 		Name: "influxdb-runtime-sys",
@@ -1617,7 +3857,7 @@ var translateMap = map[string]Translation{
 	"influxdb_queryExecutor-queryDurationNs": {
 		Name:       "influxdb-query-duration",
 		Unit:       "s",
-		Conversion: divideBy(1000 * 1000 * 1000),
+		SourceUnit: "ns",
 	},
 	"influxdb_queryExecutor-queriesActive": {
 		Name: "influxdb-queries-active",
@@ -1678,51 +3918,149 @@ var translateMap = map[string]Translation{
 		Counter: true,
 		Unit:    "count",
 	},
-}
-
-// Translation bears the convertion info from the source to the CMP metric
-type Translation struct {
-	Name           string
-	Specialisation string
-	Unit           string
-	Counter        bool
-	Conversion     func(interface{}) interface{}
-}
-
-func subtractFrom100Percent(value interface{}) interface{} {
-	return (100.0 - value.(float64))
-}
-
-func divideBy(divisor float64) func(value interface{}) interface{} {
-	return func(value interface{}) interface{} {
-		switch v := value.(type) {
-		case int64:
-			return float64(v) / divisor
-		case float64:
-			return v / divisor
-		default:
-			return 0.0
-		}
-	}
-}
-
-func esClusterHealth(status interface{}) interface{} {
-	switch status.(string) {
-	case "green":
-		return 0.0
-	case "yellow":
-		return 1.0
-	case "red":
-		return 2.0
-	default:
-		return 3.0
-	}
-}
-
-// PostMetrics is the payload sent to the CMP metrics API
-type PostMetrics struct {
-	MonitoringSystem string      `json:"monitoring_system"`
-	ResourceID       string      `json:"resource_id"`
+	// influxdb 2.x, scraped from /metrics by the prometheus input
+	"influxdb_uptime_seconds-gauge": {
+		Name: "influxdb-uptime",
+		Unit: "s",
+	},
+	"influxdb_buckets_total-counter": {
+		Name: "influxdb-buckets",
+		Unit: "count",
+	},
+	"influxdb_users_total-counter": {
+		Name: "influxdb-users",
+		Unit: "count",
+	},
+	"http_api_requests_total-counter": {
+		Name:    "influxdb-http-api-requests",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"http_api_request_duration_seconds-sum": {
+		Name:    "influxdb-http-api-request-duration-sum",
+		Counter: true,
+		Unit:    "s",
+	},
+	"http_api_request_duration_seconds-count": {
+		Name:    "influxdb-http-api-request-duration-count",
+		Counter: true,
+		Unit:    "count",
+	},
+	"storage_bucket_series_num-gauge": {
+		Name: "influxdb-storage-bucket-series",
+		Unit: "count",
+	},
+	"storage_bucket_measurement_num-gauge": {
+		Name: "influxdb-storage-bucket-measurements",
+		Unit: "count",
+	},
+	"storage_shard_disk_size-gauge": {
+		Name: "influxdb-storage-shard-disk-size",
+		Unit: "B",
+	},
+	"storage_tsm_files_disk_bytes-gauge": {
+		Name: "influxdb-storage-tsm-disk-size",
+		Unit: "B",
+	},
+	"storage_wal_size-gauge": {
+		Name: "influxdb-storage-wal-size",
+		Unit: "B",
+	},
+	"storage_cache_inuse_bytes-gauge": {
+		Name: "influxdb-storage-cache-inuse",
+		Unit: "B",
+	},
+	"storage_compactions_active-gauge": {
+		Name: "influxdb-storage-compactions-active",
+		Unit: "count",
+	},
+	"storage_compactions_failed-counter": {
+		Name:    "influxdb-storage-compactions-failed",
+		Counter: true,
+		Unit:    "count",
+	},
+	"storage_writer_ok_points-counter": {
+		Name:    "influxdb-storage-written-points",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"storage_writer_err_points-counter": {
+		Name:    "influxdb-storage-write-errors",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"boltdb_reads_total-counter": {
+		Name:    "influxdb-boltdb-reads",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"boltdb_writes_total-counter": {
+		Name:    "influxdb-boltdb-writes",
+		Counter: true,
+		Unit:    "count/s",
+	},
+	"task_executor_total_runs_active-gauge": {
+		Name: "influxdb-task-runs-active",
+		Unit: "count",
+	},
+	"task_executor_total_runs_complete-counter": {
+		Name:    "influxdb-task-runs-complete",
+		Counter: true,
+		Unit:    "count",
+	},
+	"task_executor_errors_counter-counter": {
+		Name:    "influxdb-task-errors",
+		Counter: true,
+		Unit:    "count",
+	},
+	"task_executor_run_latency_seconds-sum": {
+		Name:    "influxdb-task-run-latency-sum",
+		Counter: true,
+		Unit:    "s",
+	},
+	"task_executor_run_latency_seconds-count": {
+		Name:    "influxdb-task-run-latency-count",
+		Counter: true,
+		Unit:    "count",
+	},
+}
+
+// translateAliases are the metric names translated as another metric name,
+// by alias, for the metrics renamed by new versions of their source.
+var translateAliases = map[string]string{
+	// etcd 3.4 moved the mvcc metrics out of etcd_debugging
+	"etcd_mvcc_db_total_size_in_bytes-gauge":  "etcd_debugging_mvcc_db_total_size_in_bytes-gauge",
+	"etcd_mvcc_delete_total-counter":          "etcd_debugging_mvcc_delete_total-counter",
+	"etcd_debugging_mvcc_put_total-counter":   "etcd_mvcc_put_total-counter",
+	"etcd_debugging_mvcc_range_total-counter": "etcd_mvcc_range_total-counter",
+	"etcd_debugging_mvcc_txn_total-counter":   "etcd_mvcc_txn_total-counter",
+}
+
+// Translation bears the convertion info from the source to the CMP metric
+type Translation struct {
+	// Name is the CMP metric name, or a text/template of it with the
+	// .Measurement, .Field and .Tag "key" of the metric, see nameData.
+	Name           string
+	Specialisation string
+	Unit           string
+	// SourceUnit is the unit of the field, if set the conversion is the
+	// conversion from the source unit to the unit, see units.
+	SourceUnit string
+	Counter    bool
+	Conversion Conversion
+	// Enum maps the string values of the field to the values of the data
+	// points, "*" maps the other values.  The values missing from the enum
+	// fail the conversion, see conversion_errors.
+	Enum map[string]float64
+	// Status sends the value of the field as is, ie the state of a service,
+	// as the text of a status data point.
+	Status bool
+}
+
+// PostMetrics is the payload sent to the CMP metrics API
+type PostMetrics struct {
+	MonitoringSystem string      `json:"monitoring_system"`
+	ResourceID       string      `json:"resource_id"`
 	Metrics          []DataPoint `json:"metrics"`
 }
 
@@ -1734,6 +4072,9 @@ type DataPoint struct {
 	Value          string `json:"value"`
 	Time           string `json:"time"`
 	Counter        bool   `json:"counter"`
+	// Status marks the data points whose value is a text, see
+	// Translation.Status.
+	Status bool `json:"status,omitempty"`
 }
 
 // AddMetric appends a metric data point to the list of metrics
@@ -1744,154 +4085,891 @@ func (data *PostMetrics) AddMetric(item DataPoint) []DataPoint {
 
 // Connect makes a connection to CMP
 func (a *CMP) Connect() error {
-	if a.APIUser == "" || a.APIKey == "" || a.APIURL == "" || a.ResourceID == "" {
-		return fmt.Errorf(
-			"api_url, api_user, api_key and resource_id" +
-				"are required fields for cmp output",
-		)
+	if (a.APIURL == "" && len(a.APIURLs) == 0) || (a.ResourceID == "" && a.ResourceIDTag == "" && len(a.HostResourceIDs) == 0) {
+		return fmt.Errorf("api_url and one of resource_id, resource_id_tag or host_resource_ids are required fields for cmp output")
+	}
+	if a.APIURL != "" && len(a.APIURLs) > 0 {
+		return fmt.Errorf("api_url and api_urls are mutually exclusive")
+	}
+	urls := a.APIURLs
+	if len(urls) == 0 {
+		urls = []string{a.APIURL}
+	}
+	a.endpoints = newEndpoints(urls, a.FailoverThreshold, a.FailbackInterval.Duration, a.Log)
+	if err := a.checkFailurePolicy(); err != nil {
+		return err
+	}
+	if err := a.checkConversionErrors(); err != nil {
+		return err
+	}
+
+	auth, err := a.authConfig()
+	if err != nil {
+		return err
+	}
+	a.auth = auth
+	httpConfig := a.HTTPClientConfig
+	if httpConfig.MaxIdleConnsPerHost == 0 && a.MaxParallelRequests > http.DefaultMaxIdleConnsPerHost {
+		httpConfig.MaxIdleConnsPerHost = a.MaxParallelRequests
+	}
+	client, err := httpConfig.CreateClient(auth)
+	if err != nil {
+		return err
+	}
+
+	if err := a.setupTranslators(); err != nil {
+		return err
+	}
+
+	rules := a.Specialisations
+	if len(rules) == 0 {
+		rules = containerSpecialisationRules(a.ContainerTag, a.ContainerNameFallback)
 	}
-	tr := &http.Transport{
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+	order := a.SpecialisationOrder
+	if a.HostSpecialisation {
+		if len(order) == 0 {
+			order = defaultSpecialisationOrder
+		}
+		order = append(order[:len(order):len(order)], "tag:host")
+	}
+	specialiser, err := newSpecialiser(rules, order, a.SpecialisationSeparator)
+	if err != nil {
+		return err
 	}
 
-	a.client = &http.Client{
-		Transport: tr,
-		Timeout:   a.Timeout.Duration,
+	logFilter, err := filter.Compile(a.LogMeasurements)
+	if err != nil {
+		return fmt.Errorf("invalid log_measurements %q: %v", a.LogMeasurements, err)
+	}
+
+	if a.UserAgent == "" {
+		a.UserAgent = "telegraf/" + a.version()
+	}
+	if a.MonitoringSystem == "" {
+		a.MonitoringSystem = defaultMonitoringSystem()
+	}
+	if a.RequestsPerSecond > 0 {
+		client.Transport = &rateLimitedTransport{
+			base:   client.Transport,
+			bucket: newTokenBucket(a.RequestsPerSecond, a.Burst),
+		}
+	}
+
+	a.client = client
+	a.specialiser = specialiser
+	a.logFilter = logFilter
+	if !a.SkipConnectionCheck {
+		if err := a.checkConnection(); err != nil {
+			return err
+		}
+	}
+	if a.rates == nil {
+		a.rates = newRateTracker()
+	}
+	if a.DownsampleWindow.Duration > 0 && a.downsampler == nil {
+		a.downsampler = newDownsampler(a.DownsampleWindow.Duration)
+	}
+	if a.DedupMaxAge.Duration > 0 && a.deduplicator == nil {
+		a.deduplicator = newDeduplicator(a.DedupMaxAge.Duration)
+	}
+	if a.unmatched == nil {
+		a.unmatched = newUnmatchedTracker(a.UnmatchedSummaryInterval.Duration, time.Now())
+	}
+	if a.UnmatchedFile != "" && a.unmatchedExport == nil {
+		a.unmatchedExport, err = newUnmatchedExporter(a.UnmatchedFile)
+		if err != nil {
+			return err
+		}
+	}
+	if a.sentDatapoints == nil {
+		a.unmatchedMetrics = a.Stats.Register("unmatched_metrics", nil)
+		a.rejectedItems = a.Stats.Register("rejected_items", nil)
+		a.staleMetrics = a.Stats.Register("stale_metrics", nil)
+		a.conversionErrors = a.Stats.Register("conversion_errors", nil)
+		a.sentDatapoints = a.Stats.Register("sent_datapoints", nil)
+		a.requestStats = map[string]*requestStats{
+			"metrics": newRequestStats(a.Stats, "metrics"),
+			"logs":    newRequestStats(a.Stats, "logs"),
+		}
+	}
+	if (a.Heartbeat || a.HeartbeatInterval.Duration > 0) && a.heartbeat == nil {
+		resourceIDs := []string{a.ResourceID}
+		for _, resourceID := range a.HostResourceIDs {
+			resourceIDs = append(resourceIDs, resourceID)
+		}
+		a.heartbeat = newHeartbeat(a.HeartbeatInterval.Duration, resourceIDs, a.sendHeartbeat)
+		if a.HeartbeatInterval.Duration > 0 {
+			a.heartbeat.start()
+		}
 	}
 	return nil
 }
 
+// setupTranslators loads the translations and compiles the prometheus and
+// jolokia rules.
+func (a *CMP) setupTranslators() error {
+	translator, err := a.loadTranslator()
+	if err != nil {
+		return err
+	}
+	prometheus, err := newPrometheusTranslator(a.PrometheusRules)
+	if err != nil {
+		return err
+	}
+	jolokia, err := newJolokiaTranslator(a.JolokiaRules)
+	if err != nil {
+		return err
+	}
+	deriver, err := newDeriver(a.DerivedFields)
+	if err != nil {
+		return err
+	}
+	// The key filter is nil if neither includes nor excludes are set, so
+	// that every key matches.
+	var keyFilter filter.Filter
+	if len(a.TranslationInclude) > 0 || len(a.TranslationExclude) > 0 {
+		keyFilter, err = filter.NewIncludeExcludeFilter(a.TranslationInclude, a.TranslationExclude)
+		if err != nil {
+			return fmt.Errorf("invalid translation_include or translation_exclude: %v", err)
+		}
+	}
+
+	a.translatorMu.Lock()
+	a.translator = translator
+	a.translatorMu.Unlock()
+	a.prometheus = prometheus
+	a.jolokia = jolokia
+	a.deriver = deriver
+	a.keyFilter = keyFilter
+	return nil
+}
+
+// loadTranslator returns the translator of the translations file if set,
+// otherwise of the built-in translations.
+func (a *CMP) loadTranslator() (*translator, error) {
+	translations, patterns, aliases := translateMap, translatePatterns, translateAliases
+	if a.TranslationsFile != "" {
+		var err error
+		translations, patterns, aliases, err = loadTranslations(a.TranslationsFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+	translations, err := withAliases(translations, aliases)
+	if err != nil {
+		return nil, err
+	}
+	return newTranslator(translations, patterns)
+}
+
+// Reload loads the translations file again and swaps it for the current
+// translations, the agent calls it on SIGHUP if the configuration of the
+// output is unchanged.  The current translations are kept if the file fails
+// to load.
+func (a *CMP) Reload() error {
+	if a.TranslationsFile == "" {
+		return nil
+	}
+	translator, err := a.loadTranslator()
+	if err != nil {
+		return fmt.Errorf("could not reload %s, keeping the current translations: %v", a.TranslationsFile, err)
+	}
+	a.translatorMu.Lock()
+	a.translator = translator
+	a.translatorMu.Unlock()
+	a.Log.Infof("Reloaded the translations of %s", a.TranslationsFile)
+	return nil
+}
+
+// fieldKey returns the field of the metric with the tags distinguishing the
+// kafka metrics of the same field.
+func fieldKey(m telegraf.Metric, k string) string {
+	if k == "DelayedFetchMetrics.Count" {
+		return fmt.Sprintf("%s.%s", k, m.Tags()["fetcherType"])
+	} else if k == "BrokerTopicMetrics.Count" || k == "FetcherStats.Count" {
+		return fmt.Sprintf("%s.%s", k, m.Tags()["name"])
+	} else if strings.HasPrefix(k, "RequestMetrics.") {
+		return fmt.Sprintf("%s.%s.%s", k, m.Tags()["request"], m.Tags()["name"])
+	}
+	return k
+}
+
+// lookup returns the translation of the field of the metric, from the
+// translations and then the prometheus and jolokia rules, with its name
+// executed if it is a template.
+func (a *CMP) lookup(m telegraf.Metric, metricName, field string) (Translation, bool) {
+	a.translatorMu.RLock()
+	translator := a.translator
+	a.translatorMu.RUnlock()
+
+	translation, found := translator.lookup(metricName)
+	if !found {
+		translation, found = a.prometheus.lookup(m, field)
+	}
+	if !found {
+		translation, found = a.jolokia.lookup(m, field)
+	}
+	if !found {
+		translation, found = a.deriver.lookup(m, field)
+	}
+	if found && isNameTemplate(translation.Name) {
+		name, err := translator.expandName(translation.Name, m, field)
+		if err != nil {
+			return Translation{}, false
+		}
+		translation.Name = name
+	}
+	return translation, found
+}
+
+// defaultMonitoringSystem returns telegraf@ followed by the hostname, or
+// telegraf if the hostname is not known.
+func defaultMonitoringSystem() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		return "telegraf"
+	}
+	return "telegraf@" + hostname
+}
+
+// version returns the version of the user agent, the content of the
+// version_file if set and readable, otherwise the version of telegraf.
+func (a *CMP) version() string {
+	if a.VersionFile != "" {
+		b, err := ioutil.ReadFile(a.VersionFile)
+		if err == nil && len(bytes.TrimSpace(b)) > 0 {
+			return string(bytes.TrimSpace(b))
+		}
+		a.Log.Warnf("Could not read the version from %s, using the telegraf version", a.VersionFile)
+	}
+	if v := internal.Version(); v != "" {
+		return v
+	}
+	return "unknown"
+}
+
+// authMode returns the auth_mode, basic by default.
+func (a *CMP) authMode() string {
+	if a.AuthMode == "" {
+		return "basic"
+	}
+	return a.AuthMode
+}
+
+// credential returns the secret of a credential option, or the secret read
+// from the file of its _file variant if set.  The file must be readable when
+// the output connects.
+func credential(option string, value secret.Secret, filename string) (secret.Secret, error) {
+	if filename == "" {
+		return value, nil
+	}
+	if !value.IsEmpty() {
+		return secret.Secret{}, fmt.Errorf("%s and %s_file are mutually exclusive", option, option)
+	}
+	s := secret.NewFileSecret(filename)
+	if _, err := s.Get(); err != nil {
+		return secret.Secret{}, fmt.Errorf("unable to read %s_file: %v", option, err)
+	}
+	return s, nil
+}
+
+// authConfig returns the authentication of the API requests for the auth_mode.
+func (a *CMP) authConfig() (*httpconfig.AuthConfig, error) {
+	switch a.AuthMode {
+	case "", "basic":
+		user, err := credential("api_user", a.APIUser, a.APIUserFile)
+		if err != nil {
+			return nil, err
+		}
+		key, err := credential("api_key", a.APIKey, a.APIKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		if user.IsEmpty() || key.IsEmpty() {
+			return nil, fmt.Errorf("api_user and api_key are required fields for the basic auth_mode")
+		}
+		return &httpconfig.AuthConfig{Username: user, Password: key}, nil
+	case "bearer":
+		token, err := credential("bearer_token", a.BearerToken, a.BearerTokenFile)
+		if err != nil {
+			return nil, err
+		}
+		if token.IsEmpty() {
+			return nil, fmt.Errorf("bearer_token is a required field for the bearer auth_mode")
+		}
+		return &httpconfig.AuthConfig{BearerToken: token}, nil
+	case "oauth2":
+		clientSecret, err := credential("client_secret", a.ClientSecret, a.ClientSecretFile)
+		if err != nil {
+			return nil, err
+		}
+		if a.ClientID == "" || clientSecret.IsEmpty() || a.TokenURL == "" {
+			return nil, fmt.Errorf("client_id, client_secret and token_url are required fields for the oauth2 auth_mode")
+		}
+		return &httpconfig.AuthConfig{
+			ClientID:     a.ClientID,
+			ClientSecret: clientSecret,
+			TokenURL:     a.TokenURL,
+			Scopes:       a.Scopes,
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported auth_mode %q, expected basic, bearer or oauth2", a.AuthMode)
+}
+
 // Write sends the metrics to CMP
 func (a *CMP) Write(metrics []telegraf.Metric) error {
 	if len(metrics) == 0 {
 		return nil
 	}
-	payload := &PostMetrics{
-		MonitoringSystem: "telegraf",
-		ResourceID:       a.ResourceID,
+	// The API accepts the data points of a single resource per request.
+	payloads := map[string]*PostMetrics{}
+	var resourceIDs []string
+	var count, unmatched, duplicates, stale, unconverted int
+	add := func(payload *PostMetrics, p DataPoint) {
+		if a.addDataPoint(payload, p) {
+			count++
+		} else {
+			duplicates++
+		}
+	}
+	logPayloads := map[string]*PostLogs{}
+	var logResourceIDs []string
+	var logCount int
+	now := time.Now()
+	// The counter values and downsampling windows of a failed write are
+	// computed again from the batch sent again.
+	a.rates.discard()
+	if a.downsampler != nil {
+		a.downsampler.discard()
 	}
 
 	for _, m := range metrics {
-		log.Printf("D! [CMP] Process %+v", m)
-
-		suffix := ""
-		cpu := m.Tags()["cpu"]
-		path := m.Tags()["path"]
-		haproxyService := m.Tags()["proxy"] + "_" + m.Tags()["sv"]
-		containerName := m.Tags()["com.docker.compose.service"]
-		diskName := m.Tags()["name"]
-		db := m.Tags()["db"]
-		kafkaTopic := m.Tags()["topic"]
-		kafkaBrokerHost := m.Tags()["brokerHost"]
-		mongoDBName := m.Tags()["db_name"]
-
-		if len(cpu) > 0 && cpu != "cpu-total" {
-			suffix = cpu[3:]
-		} else if len(path) > 0 {
-			suffix = path
-		} else if len(containerName) > 0 {
-			suffix = containerName
-		} else if m.Name() == "haproxy" && len(haproxyService) > 0 {
-			suffix = haproxyService
-		} else if m.Name() == "diskio" && len(diskName) > 0 {
-			suffix = diskName
-		} else if m.Name() == "postgresql" && len(db) > 0 {
-			suffix = db
-		} else if strings.HasPrefix(m.Name(), "mongodb_") && len(mongoDBName) > 0 {
-			suffix = mongoDBName
-		} else if strings.HasPrefix(m.Name(), "kafka.") && len(kafkaTopic) > 0 {
-			suffix = kafkaTopic
-		} else if strings.HasPrefix(m.Name(), "kafka.") && len(kafkaBrokerHost) > 0 {
-			suffix = kafkaBrokerHost
+		a.Log.Debugf("Process %+v", m)
+
+		if a.MaxMetricAge.Duration > 0 && now.Sub(m.Time()) > a.MaxMetricAge.Duration {
+			stale++
+			continue
+		}
+		resourceID := a.resourceID(m)
+		if resourceID == "" {
+			a.Log.Debugf("Skip %s without the %s tag", m.Name(), a.ResourceIDTag)
+			continue
+		}
+		if a.heartbeat != nil {
+			a.heartbeat.add(resourceID)
 		}
+		if a.logFilter != nil && a.logFilter.Match(m.Name()) {
+			entry, ok := a.logEntry(m)
+			if !ok {
+				a.Log.Debugf("Skip %s without the %s string field", m.Name(), a.LogMessageField)
+				continue
+			}
+			logs, ok := logPayloads[resourceID]
+			if !ok {
+				logs = &PostLogs{
+					MonitoringSystem: a.MonitoringSystem,
+					ResourceID:       resourceID,
+				}
+				logPayloads[resourceID] = logs
+				logResourceIDs = append(logResourceIDs, resourceID)
+			}
+			logs.Logs = append(logs.Logs, entry)
+			logCount++
+			continue
+		}
+		payload := a.addPayload(payloads, &resourceIDs, resourceID)
+
+		components := a.specialiser.components(m)
 
 		timestamp := m.Time().UTC().Format("2006-01-02T15:04:05.999999Z")
-		for k, v := range m.Fields() {
-			if k == "DelayedFetchMetrics.Count" {
-				k = fmt.Sprintf("%s.%s", k, m.Tags()["fetcherType"])
-			} else if k == "BrokerTopicMetrics.Count" || k == "FetcherStats.Count" {
-				k = fmt.Sprintf("%s.%s", k, m.Tags()["name"])
-			} else if strings.HasPrefix(k, "RequestMetrics.") {
-				k = fmt.Sprintf("%s.%s.%s", k, m.Tags()["request"], m.Tags()["name"])
-			}
+		for k, v := range a.deriver.derive(m) {
+			k = fieldKey(m, k)
 			metricName := m.Name() + "-" + strings.Replace(k, "_", ".", -1)
-			translation, found := translateMap[metricName]
+			if a.keyFilter != nil && !a.keyFilter.Match(metricName) {
+				continue
+			}
+			translation, found := a.lookup(m, metricName, k)
 			if !found {
-				log.Printf("D! [CMP] Skip %s", metricName)
+				a.unmatched.add(metricName)
+				unmatched++
+				if a.unmatchedExport != nil {
+					if err := a.unmatchedExport.export(metricName, m, k, v); err != nil {
+						a.Log.Errorf("Could not export %s to %s: %v", metricName, a.UnmatchedFile, err)
+					}
+				}
 				continue
 			}
 
-			if translation.Conversion != nil {
-				v = translation.Conversion(v)
-			}
+			specialisation := a.specialiser.specialisation(m, translation.Specialisation, components)
 
-			specialisations := []string{}
-			if translation.Specialisation != "" {
-				specialisations = append(specialisations, translation.Specialisation)
+			// The rate is computed before the conversion, the conversions
+			// of counters are linear.
+			counter := translation.Counter
+			if counter && a.CounterRate {
+				if value, ok := toFloat(v); ok {
+					key := resourceID + "\x00" + translation.Name + "\x00" + specialisation
+					rate, ok := a.rates.rate(key, value, m.Time())
+					if !ok {
+						a.Log.Debugf("Skip %s[%s] until its rate is known", translation.Name, specialisation)
+						continue
+					}
+					v = rate
+					counter = false
+				}
 			}
-			if suffix != "" {
-				specialisations = append(specialisations, suffix)
+
+			if translation.Conversion != nil {
+				converted, err := translation.Conversion(v)
+				if err != nil {
+					unconverted++
+					if a.ConversionErrors == conversionErrorsStrict {
+						a.Log.Errorf("Dropped %s[%s] of the %s field of %s: %s", translation.Name, specialisation, k, m.Name(), err)
+					}
+					continue
+				}
+				v = converted
 			}
 
 			p := DataPoint{
-				Counter:        translation.Counter,
+				Counter:        counter,
+				Status:         translation.Status,
 				Name:           translation.Name,
-				Specialisation: strings.Join(specialisations, "."),
+				Specialisation: specialisation,
 				Unit:           translation.Unit,
-				Value:          fmt.Sprintf("%v", v),
+				Value:          formatValue(v),
 				Time:           timestamp,
 			}
-			log.Printf(
-				"D! [CMP] Create %s[%s] = %s(%s) %s",
-				p.Name,
-				p.Specialisation,
-				p.Value,
-				p.Unit,
-				p.Time,
-			)
-			payload.AddMetric(p)
+			a.Log.Debugf("Create %s", dataPointLog{&p})
+			if a.downsampler != nil && !p.Status {
+				if value, ok := toFloat(v); ok {
+					if done, ok := a.downsampler.add(resourceID, p, value, m.Time()); ok {
+						add(payload, done)
+					}
+					continue
+				}
+			}
+			add(payload, p)
+		}
+	}
+	if a.Heartbeat {
+		// The heartbeat is not deduplicated, its value is always the same.
+		for _, resourceID := range a.heartbeat.resources() {
+			payload := a.addPayload(payloads, &resourceIDs, resourceID)
+			payload.AddMetric(heartbeatPoint(now))
+			count++
+		}
+	}
+	if a.downsampler != nil {
+		points := a.downsampler.flush(time.Now(), false)
+		for _, resourceID := range sortedResourceIDs(points) {
+			payload := a.addPayload(payloads, &resourceIDs, resourceID)
+			for _, p := range points[resourceID] {
+				add(payload, p)
+			}
 		}
 	}
+	if duplicates > 0 {
+		a.Log.Debugf("Suppressed %d data points repeating the last value sent", duplicates)
+	}
+	if stale > 0 {
+		a.Log.Debugf("Dropped %d metrics older than %s", stale, a.MaxMetricAge.Duration)
+		a.staleMetrics.Incr(int64(stale))
+	}
+	if unconverted > 0 {
+		a.Log.Debugf("Dropped %d data points whose value could not be converted", unconverted)
+		a.conversionErrors.Incr(int64(unconverted))
+	}
 
-	cmpBytes, err := json.Marshal(payload)
+	a.unmatchedMetrics.Set(int64(unmatched))
+	a.unmatched.report(a.Log, time.Now())
+
+	bodies := make([][][]byte, 0, len(resourceIDs))
+	var requests int
+	for _, resourceID := range resourceIDs {
+		payload := payloads[resourceID]
+		if len(payload.Metrics) == 0 {
+			releasePayload(payload)
+			continue
+		}
+		b, err := a.serialize(payload)
+		releasePayload(payload)
+		if err != nil {
+			return err
+		}
+		bodies = append(bodies, b)
+		requests += len(b)
+	}
+
+	logBodies := make([][][]byte, 0, len(logResourceIDs))
+	var logRequests int
+	for _, resourceID := range logResourceIDs {
+		b, err := a.serializeLogs(logPayloads[resourceID])
+		if err != nil {
+			return err
+		}
+		logBodies = append(logBodies, b)
+		logRequests += len(b)
+	}
+
+	a.Log.Infof(
+		"Sending %d data points generated from %d metrics for %d resources to the API in %d requests",
+		count,
+		len(metrics)-logCount,
+		len(bodies),
+		requests,
+	)
+	ctx := context.Background()
+	unsent := map[string][][]byte{}
+	var err error
+	unsent["metrics"], err = a.trySendResources(ctx, "metrics", bodies)
+	if logCount > 0 {
+		a.Log.Infof(
+			"Sending %d log lines for %d resources to the API in %d requests",
+			logCount,
+			len(logBodies),
+			logRequests,
+		)
+		var logErr error
+		unsent["logs"], logErr = a.trySendResources(ctx, "logs", logBodies)
+		if err == nil {
+			err = logErr
+		}
+	}
 	if err != nil {
-		return fmt.Errorf("unable to JSON-serialize the data points: %s", err.Error())
+		a.unsent = nil
+		err = a.writeFailed(unsent, err)
+		if err != nil {
+			a.unsent = unsent
+		}
+		return err
+	}
+	a.unsent = nil
+	a.failedWrites = 0
+	a.rates.commit()
+	if a.downsampler != nil {
+		a.downsampler.commit()
+	}
+	a.sentDatapoints.Incr(int64(count))
+	return nil
+}
+
+// addDataPoint adds the data point to the payload unless it is a duplicate,
+// and returns if it was added.
+func (a *CMP) addDataPoint(payload *PostMetrics, p DataPoint) bool {
+	if a.deduplicator != nil && a.deduplicator.duplicate(payload.ResourceID, p) {
+		return false
+	}
+	payload.AddMetric(p)
+	return true
+}
+
+// addPayload returns the payload of the resource, adding a payload of the
+// pool to the payloads and its resource to the resources if missing.
+func (a *CMP) addPayload(payloads map[string]*PostMetrics, resourceIDs *[]string, resourceID string) *PostMetrics {
+	payload, ok := payloads[resourceID]
+	if !ok {
+		payload = newPayload(a.MonitoringSystem, resourceID)
+		payloads[resourceID] = payload
+		*resourceIDs = append(*resourceIDs, resourceID)
+	}
+	return payload
+}
+
+// sendResources sends the request bodies of each resource, the bodies of a
+// resource one after the other and up to max_parallel_requests resources in
+// parallel.  All resources are sent even if some fail, the first error is
+// returned.
+func (a *CMP) sendResources(ctx context.Context, endpoint string, resources [][][]byte) error {
+	_, err := a.trySendResources(ctx, endpoint, resources)
+	return err
+}
+
+// trySendResources sends the request bodies as sendResources does, and
+// returns the bodies not sent: those of each resource from the one which
+// failed.
+func (a *CMP) trySendResources(ctx context.Context, endpoint string, resources [][][]byte) ([][]byte, error) {
+	parallel := a.MaxParallelRequests
+	if parallel < 1 {
+		parallel = 1
+	}
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		firstErr error
+		unsent   [][]byte
+	)
+	sem := make(chan struct{}, parallel)
+	for _, bodies := range resources {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(bodies [][]byte) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			for i, body := range bodies {
+				if err := a.send(ctx, endpoint, body); err != nil {
+					mu.Lock()
+					if firstErr == nil {
+						firstErr = err
+					} else {
+						a.Log.Error(err)
+					}
+					unsent = append(unsent, bodies[i:]...)
+					mu.Unlock()
+					return
+				}
+			}
+		}(bodies)
+	}
+	wg.Wait()
+	return unsent, firstErr
+}
+
+// resourceID returns the CMP resource of the metric, the value of the
+// resource_id_tag if set, otherwise the resource of its host in
+// host_resource_ids, otherwise resource_id.
+func (a *CMP) resourceID(m telegraf.Metric) string {
+	if a.ResourceIDTag != "" {
+		if resourceID, ok := m.GetTag(a.ResourceIDTag); ok && resourceID != "" {
+			return resourceID
+		}
+	}
+	if host, ok := m.GetTag("host"); ok {
+		if resourceID, ok := a.HostResourceIDs[host]; ok {
+			return resourceID
+		}
+	}
+	return a.ResourceID
+}
+
+// serialize returns the request bodies of the payload, its data points are
+// split in several bodies of at most max_datapoints_per_request data points
+// and max_body_bytes bytes.  A single data point larger than max_body_bytes is
+// sent alone.
+func (a *CMP) serialize(payload *PostMetrics) ([][]byte, error) {
+	chunks := [][]DataPoint{payload.Metrics}
+	if n := a.MaxDatapointsPerRequest; n > 0 && len(payload.Metrics) > n {
+		chunks = nil
+		for i := 0; i < len(payload.Metrics); i += n {
+			end := i + n
+			if end > len(payload.Metrics) {
+				end = len(payload.Metrics)
+			}
+			chunks = append(chunks, payload.Metrics[i:end])
+		}
+	}
+
+	var bodies [][]byte
+	for len(chunks) > 0 {
+		chunk := chunks[0]
+		chunks = chunks[1:]
+
+		body, err := encodeJSON(&PostMetrics{
+			MonitoringSystem: payload.MonitoringSystem,
+			ResourceID:       payload.ResourceID,
+			Metrics:          chunk,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to JSON-serialize the data points: %s", err.Error())
+		}
+
+		if limit := a.MaxBodyBytes.Size; limit > 0 && int64(len(body)) > limit && len(chunk) > 1 {
+			half := len(chunk) / 2
+			chunks = append([][]DataPoint{chunk[:half], chunk[half:]}, chunks...)
+			continue
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies, nil
+}
+
+// send posts the request body to the endpoint of the API, retrying up to
+// max_retries times or until the context is done.  Bodies permanently
+// rejected by the API are written to the dead letter file if set.
+func (a *CMP) send(ctx context.Context, endpoint string, body []byte) error {
+	refreshed := false
+	for attempt := 0; ; attempt++ {
+		err := a.post(ctx, endpoint, body)
+		if err == nil {
+			return nil
+		}
+
+		// The credentials may have been rotated since they were read, the
+		// secrets are read again from their stores and the request sent again
+		// right away, once and without counting as a retry.
+		if apiErr, ok := err.(*apiError); ok && apiErr.unauthorized() && !refreshed {
+			a.Log.Warnf("%s, reading the credentials again", apiErr)
+			secret.Refresh()
+			refreshed = true
+			attempt--
+			continue
+		}
+
+		if partial, ok := err.(*partialError); ok {
+			body, err = a.handlePartial(endpoint, body, partial)
+			if err != nil || body == nil {
+				return err
+			}
+			err = partial
+		}
+
+		if apiErr, ok := err.(*apiError); ok && apiErr.permanent() && a.DeadLetterFile != "" {
+			if err := a.writeDeadLetter(endpoint, body, apiErr); err != nil {
+				return fmt.Errorf("%s, and writing the dead letter file failed: %s", apiErr, err)
+			}
+			a.Log.Errorf("%s, the payload was written to %s", apiErr, a.DeadLetterFile)
+			return nil
+		}
+		if !retryable(err) || attempt >= a.MaxRetries {
+			return err
+		}
+
+		wait := a.retryInterval(attempt)
+		a.Log.Warnf("%s, retrying in %s", err, wait)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
 	}
+}
+
+// post sends the serialized payload to the endpoint of the API, at the API
+// URL selected by the failover.
+func (a *CMP) post(ctx context.Context, endpoint string, body []byte) error {
+	url, i := a.endpoints.url(time.Now())
+	err := a.postURL(ctx, url, endpoint, body)
+	a.endpoints.result(i, endpointFailure(err), time.Now())
+	return err
+}
+
+// postURL sends the serialized payload to the endpoint of the API URL.
+func (a *CMP) postURL(ctx context.Context, url, endpoint string, body []byte) error {
 	req, err := http.NewRequest(
 		"POST",
-		a.authenticatedURL(),
-		bytes.NewBuffer(cmpBytes),
+		a.authenticatedURL(url, endpoint),
+		bytes.NewBuffer(body),
 	)
 	if err != nil {
 		return fmt.Errorf("unable to prepare the HTTP request %s", err.Error())
 	}
+	req = req.WithContext(ctx)
 
-	if a.UserAgent == "" {
-		a.UserAgent = "telegraf/unknown"
-	}
 	req.Header.Add("User-Agent", a.UserAgent)
 	req.Header.Add("Content-Type", "application/json")
-	req.SetBasicAuth(a.APIUser, a.APIKey)
+	req.Header.Add("Idempotency-Key", idempotencyKey(body))
+	if !a.HMACSecret.IsEmpty() {
+		if err := a.sign(req, body, time.Now()); err != nil {
+			return fmt.Errorf("unable to sign the HTTP request %s", err.Error())
+		}
+	}
 
-	log.Printf(
-		"I! [CMP] Sending %d data points generated from %d metrics to the API",
-		len(payload.Metrics),
-		len(metrics),
-	)
+	start := time.Now()
 	resp, err := a.client.Do(req)
+	elapsed := time.Since(start)
+	a.observeRequest(endpoint, len(body), elapsed)
 	if err != nil {
-		return fmt.Errorf("API call failed: %s", err.Error())
+		a.logRequest(req, nil, elapsed, nil, err)
+		return &requestError{err: err}
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusMultiStatus {
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return &requestError{err: err}
+		}
+		a.logRequest(req, resp, elapsed, body, nil)
+		return parsePartialResponse(resp.Status, body)
+	}
 	if resp.StatusCode != http.StatusOK {
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			log.Printf("E! [CMP] failed to parse CMP response body: %s", err)
+			a.Log.Errorf("failed to parse CMP response body: %s", err)
 		}
-		return fmt.Errorf("received a non-200 response: %s %s", resp.Status, body)
+		a.logRequest(req, resp, elapsed, body, nil)
+		return &apiError{statusCode: resp.StatusCode, status: resp.Status, body: body}
 	}
 
+	a.logRequest(req, resp, elapsed, nil, nil)
+	return nil
+}
+
+// idempotencyKey returns the key of the request body, the hex encoded SHA-256
+// of the body, so that it is the same when the body is sent again and the API
+// can ignore the bodies it already received.
+func idempotencyKey(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// checkConnection sends an authenticated request to the ping endpoint of the
+// API.
+func (a *CMP) checkConnection() error {
+	var firstErr error
+	for i, url := range a.endpoints.urls {
+		err := a.checkURL(url)
+		if err == nil {
+			return nil
+		}
+		if _, ok := err.(*connectionError); !ok {
+			return err
+		}
+		if firstErr == nil {
+			firstErr = err
+		}
+		if i+1 < len(a.endpoints.urls) {
+			a.Log.Warnf("%s, checking %s", err, a.endpoints.urls[i+1])
+		}
+	}
+	return firstErr
+}
+
+// connectionError is returned by checkURL when the API URL is unreachable or
+// responds with a 5xx error, so that the following API URL may be checked.
+type connectionError struct {
+	error
+}
+
+// checkURL checks the connection to the API URL and the credentials.
+func (a *CMP) checkURL(url string) error {
+	req, err := http.NewRequest("GET", a.authenticatedURL(url, "ping"), nil)
+	if err != nil {
+		return fmt.Errorf("unable to prepare the HTTP request %s", err.Error())
+	}
+	req.Header.Add("User-Agent", a.UserAgent)
+	if !a.HMACSecret.IsEmpty() {
+		if err := a.sign(req, nil, time.Now()); err != nil {
+			return fmt.Errorf("unable to sign the HTTP request %s", err.Error())
+		}
+	}
+
+	start := time.Now()
+	resp, err := a.client.Do(req)
+	if err != nil {
+		a.logRequest(req, nil, time.Since(start), nil, err)
+		return &connectionError{fmt.Errorf("connection check of %s failed: %s", url, err)}
+	}
+	defer resp.Body.Close()
+	var body []byte
+	if a.LogRequests && resp.StatusCode != http.StatusOK {
+		body, _ = ioutil.ReadAll(resp.Body)
+	}
+	a.logRequest(req, resp, time.Since(start), body, nil)
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("connection check of %s failed: %s, check the credentials of the %s auth_mode",
+			url, resp.Status, a.authMode())
+	case resp.StatusCode >= 500:
+		return &connectionError{fmt.Errorf("connection check of %s failed: %s", url, resp.Status)}
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("connection check of %s failed: %s, check the api_url", url, resp.Status)
+	}
 	return nil
 }
 
@@ -1905,18 +4983,101 @@ func (a *CMP) Description() string {
 	return "Configuration for CMP Server to send metrics to."
 }
 
-func (a *CMP) authenticatedURL() string {
-	return fmt.Sprintf("%s/metrics", a.APIURL)
+func (a *CMP) authenticatedURL(url, endpoint string) string {
+	return fmt.Sprintf("%s/%s", url, endpoint)
+}
+
+// flushPending sends the data points of all the downsampling windows and the
+// requests of the last write not sent, until the context is done.  Those
+// not sent are written to the dead letter file if set.
+func (a *CMP) flushPending(ctx context.Context) {
+	if a.downsampler != nil {
+		// The changes of a failed write are left to the batch sent again.
+		a.downsampler.discard()
+		points := a.downsampler.flush(time.Now(), true)
+		a.downsampler.commit()
+		for _, resourceID := range sortedResourceIDs(points) {
+			b, err := a.serialize(&PostMetrics{
+				MonitoringSystem: a.MonitoringSystem,
+				ResourceID:       resourceID,
+				Metrics:          points[resourceID],
+			})
+			if err != nil {
+				a.Log.Errorf("Could not send the downsampled data points: %s", err)
+				return
+			}
+			if a.unsent == nil {
+				a.unsent = map[string][][]byte{}
+			}
+			a.unsent["metrics"] = append(a.unsent["metrics"], b...)
+		}
+	}
+
+	for _, endpoint := range []string{"metrics", "logs"} {
+		bodies := a.unsent[endpoint]
+		if len(bodies) == 0 {
+			continue
+		}
+		a.Log.Debugf("Sending %d pending requests to the %s API", len(bodies), endpoint)
+		unsent, err := a.trySendResources(ctx, endpoint, [][][]byte{bodies})
+		if err == nil {
+			continue
+		}
+		if a.DeadLetterFile == "" {
+			a.Log.Errorf("%s, dropped %d pending requests", err, len(unsent))
+			continue
+		}
+		var written int
+		for _, body := range unsent {
+			if dlErr := a.writeDeadLetter(endpoint, body, err); dlErr != nil {
+				a.Log.Errorf("%s, and writing the dead letter file failed: %s", err, dlErr)
+				break
+			}
+			written++
+		}
+		a.Log.Errorf("%s, %d pending requests were written to %s", err, written, a.DeadLetterFile)
+	}
+	a.unsent = nil
 }
 
-// Close closes the connection
+// Close sends the pending data points, giving up after close_timeout, and
+// closes the connection
 func (a *CMP) Close() error {
+	if a.heartbeat != nil {
+		a.heartbeat.stop()
+		a.heartbeat = nil
+	}
+	if a.client != nil {
+		timeout := a.CloseTimeout.Duration
+		if timeout <= 0 {
+			timeout = defaultCloseTimeout
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		a.flushPending(ctx)
+		cancel()
+	}
 	a.client = nil
 	return nil
 }
 
 func init() {
 	outputs.Add("cmp", func() telegraf.Output {
-		return &CMP{}
+		return &CMP{
+			MaxRetries:           defaultMaxRetries,
+			FailureMaxAttempts:   defaultFailureMaxAttempts,
+			FailoverThreshold:    defaultFailoverThreshold,
+			FailbackInterval:     internal.Duration{Duration: defaultFailbackInterval},
+			RetryInitialInterval: internal.Duration{Duration: defaultRetryInitialInterval},
+			RetryMaxInterval:     internal.Duration{Duration: defaultRetryMaxInterval},
+			CloseTimeout:         internal.Duration{Duration: defaultCloseTimeout},
+			UnmatchedSummaryInterval: internal.Duration{
+				Duration: defaultUnmatchedSummaryInterval,
+			},
+			HTTPClientConfig: httpconfig.HTTPClientConfig{
+				EnableHTTP2: true,
+			},
+			LogMessageField: "message",
+			LogSeverityTag:  "severity",
+		}
 	})
 }
@@ -0,0 +1,326 @@
+package cmp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Conversion converts the value of a field to the value of the CMP metric.
+// It fails if the value cannot be converted, rather than returning a value
+// which is not the one of the field.
+type Conversion func(value interface{}) (interface{}, error)
+
+// The handling of the data points whose value cannot be converted, set by the
+// conversion_errors option.
+const (
+	// conversionErrorsLenient skips the data points, they are counted in the
+	// conversion_errors internal metric and summed up in the debug log.
+	conversionErrorsLenient = "lenient"
+	// conversionErrorsStrict skips the data points as well, each with an error
+	// log of its field, value and error.
+	conversionErrorsStrict = "strict"
+)
+
+// checkConversionErrors returns an error if the conversion_errors mode is not
+// supported.
+func (a *CMP) checkConversionErrors() error {
+	switch a.ConversionErrors {
+	case "", conversionErrorsLenient, conversionErrorsStrict:
+		return nil
+	}
+	return fmt.Errorf("unsupported conversion_errors %q, expected lenient or strict", a.ConversionErrors)
+}
+
+// toFloat returns the numeric field value as a float64.
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// notNumeric returns the error of the conversions of numeric values.
+func notNumeric(value interface{}) error {
+	return fmt.Errorf("%T value %q is not numeric", value, fmt.Sprint(value))
+}
+
+// arithmetic returns a conversion applying fn to numeric values, other values
+// fail the conversion.
+func arithmetic(fn func(float64) float64) Conversion {
+	return func(value interface{}) (interface{}, error) {
+		v, ok := toFloat(value)
+		if !ok {
+			return nil, notNumeric(value)
+		}
+		return fn(v), nil
+	}
+}
+
+var subtractFrom100Percent = arithmetic(func(v float64) float64 { return 100.0 - v })
+
+func divideBy(divisor float64) Conversion {
+	return arithmetic(func(v float64) float64 { return v / divisor })
+}
+
+func multiplyBy(factor float64) Conversion {
+	return arithmetic(func(v float64) float64 { return v * factor })
+}
+
+func addOffset(offset float64) Conversion {
+	return arithmetic(func(v float64) float64 { return v + offset })
+}
+
+// boolToInt converts booleans to 1 or 0, other values are kept.
+func boolToInt(value interface{}) (interface{}, error) {
+	if v, ok := value.(bool); ok {
+		if v {
+			return int64(1), nil
+		}
+		return int64(0), nil
+	}
+	return value, nil
+}
+
+// clamp limits numeric values to the range from min to max, other values are
+// kept.
+func clamp(min, max float64) Conversion {
+	return func(value interface{}) (interface{}, error) {
+		v, ok := toFloat(value)
+		if !ok {
+			return value, nil
+		}
+		if v < min {
+			return min, nil
+		}
+		if v > max {
+			return max, nil
+		}
+		return v, nil
+	}
+}
+
+// stringEnumMap maps the string form of values to the values of the map.
+// Values missing from the map are converted to def, or kept if def is nil.
+func stringEnumMap(values map[string]interface{}, def interface{}) Conversion {
+	return func(value interface{}) (interface{}, error) {
+		if v, ok := values[fmt.Sprint(value)]; ok {
+			return v, nil
+		}
+		if def != nil {
+			return def, nil
+		}
+		return value, nil
+	}
+}
+
+var esClusterHealth = stringEnumMap(map[string]interface{}{
+	"green":  0.0,
+	"yellow": 1.0,
+	"red":    2.0,
+}, 3.0)
+
+var consulHealthStatus = stringEnumMap(map[string]interface{}{
+	"passing":  0.0,
+	"warning":  1.0,
+	"critical": 2.0,
+}, 3.0)
+
+var cephHealth = stringEnumMap(map[string]interface{}{
+	"HEALTH_OK":   0.0,
+	"HEALTH_WARN": 1.0,
+	"HEALTH_ERR":  2.0,
+}, 3.0)
+
+// mongodbMemberState maps the replica set member states reported by the
+// mongodb input to the state codes of replSetGetStatus.
+var mongodbMemberState = stringEnumMap(map[string]interface{}{
+	"STARTUP":    0.0,
+	"PRIMARY":    1.0,
+	"SECONDARY":  2.0,
+	"RECOVERING": 3.0,
+	"STARTUP2":   5.0,
+	"UNKNOWN":    6.0,
+	"ARBITER":    7.0,
+	"DOWN":       8.0,
+	"ROLLBACK":   9.0,
+	"REMOVED":    10.0,
+}, 6.0)
+
+// uwsgiWorkerStatus maps the status of the uwsgi workers, the workers
+// running a signal handler have a status of "sig" followed by the signal.
+var uwsgiWorkerStatus = stringEnumMap(map[string]interface{}{
+	"idle":  0.0,
+	"busy":  1.0,
+	"pause": 2.0,
+	"cheap": 3.0,
+}, 4.0)
+
+// enumConversion maps the string form of values to the values of the enum,
+// "*" maps the values missing from the enum, which fail the conversion
+// otherwise.
+func enumConversion(values map[string]float64) Conversion {
+	def, hasDefault := values["*"]
+	return func(value interface{}) (interface{}, error) {
+		s := fmt.Sprint(value)
+		if v, ok := values[s]; ok && s != "*" {
+			return v, nil
+		}
+		if hasDefault {
+			return def, nil
+		}
+		return nil, fmt.Errorf("value %q is not in the enum", s)
+	}
+}
+
+// normalizeTranslation sets the conversion of a translation with a source
+// unit or an enum, see normalizeUnit, and checks that its options do not
+// conflict.
+func normalizeTranslation(t *Translation) error {
+	if t.Enum != nil {
+		if t.Conversion != nil || t.SourceUnit != "" {
+			return fmt.Errorf("either a conversion, a source unit or an enum may be set")
+		}
+		if len(t.Enum) == 0 {
+			return fmt.Errorf("empty enum")
+		}
+	}
+	if t.Status && (t.Counter || t.Enum != nil || t.Conversion != nil || t.SourceUnit != "") {
+		return fmt.Errorf("status translations cannot be counters nor have a conversion, source unit or enum")
+	}
+	if err := normalizeUnit(t); err != nil {
+		return err
+	}
+	if t.Enum != nil {
+		t.Conversion = enumConversion(t.Enum)
+	}
+	return nil
+}
+
+// parseConversion returns the conversion of a translation file.  The
+// conversion is given by its name, followed by its arguments in parentheses
+// if any, ie "multiply_by(8)", "clamp(0, 100)" or
+// "enum(green: 0, yellow: 1, *: 3)" where * is the value of other strings.
+func parseConversion(spec string) (Conversion, error) {
+	spec = strings.TrimSpace(spec)
+	name, args := spec, []string(nil)
+	if i := strings.Index(spec, "("); i >= 0 {
+		if !strings.HasSuffix(spec, ")") {
+			return nil, fmt.Errorf("invalid conversion %q: missing closing parenthesis", spec)
+		}
+		name = strings.TrimSpace(spec[:i])
+		for _, arg := range strings.Split(spec[i+1:len(spec)-1], ",") {
+			if arg = strings.TrimSpace(arg); arg != "" {
+				args = append(args, arg)
+			}
+		}
+	}
+
+	floats := func(n int) ([]float64, error) {
+		if len(args) != n {
+			return nil, fmt.Errorf("invalid conversion %q: expected %d arguments", spec, n)
+		}
+		values := make([]float64, n)
+		for i, arg := range args {
+			v, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid conversion %q: %v", spec, err)
+			}
+			values[i] = v
+		}
+		return values, nil
+	}
+
+	switch name {
+	case "subtract_from_100_percent":
+		if _, err := floats(0); err != nil {
+			return nil, err
+		}
+		return subtractFrom100Percent, nil
+	case "bool_to_int":
+		if _, err := floats(0); err != nil {
+			return nil, err
+		}
+		return boolToInt, nil
+	case "es_cluster_health":
+		if _, err := floats(0); err != nil {
+			return nil, err
+		}
+		return esClusterHealth, nil
+	case "consul_health_status":
+		if _, err := floats(0); err != nil {
+			return nil, err
+		}
+		return consulHealthStatus, nil
+	case "ceph_health":
+		if _, err := floats(0); err != nil {
+			return nil, err
+		}
+		return cephHealth, nil
+	case "mongodb_member_state":
+		if _, err := floats(0); err != nil {
+			return nil, err
+		}
+		return mongodbMemberState, nil
+	case "uwsgi_worker_status":
+		if _, err := floats(0); err != nil {
+			return nil, err
+		}
+		return uwsgiWorkerStatus, nil
+	case "divide_by", "multiply_by", "add_offset":
+		v, err := floats(1)
+		if err != nil {
+			return nil, err
+		}
+		switch name {
+		case "divide_by":
+			return divideBy(v[0]), nil
+		case "multiply_by":
+			return multiplyBy(v[0]), nil
+		}
+		return addOffset(v[0]), nil
+	case "clamp":
+		v, err := floats(2)
+		if err != nil {
+			return nil, err
+		}
+		if v[0] > v[1] {
+			return nil, fmt.Errorf("invalid conversion %q: minimum greater than maximum", spec)
+		}
+		return clamp(v[0], v[1]), nil
+	case "enum":
+		return parseEnum(spec, args)
+	}
+	return nil, fmt.Errorf("unknown conversion %q", spec)
+}
+
+func parseEnum(spec string, args []string) (Conversion, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("invalid conversion %q: expected values", spec)
+	}
+
+	values := make(map[string]interface{}, len(args))
+	var def interface{}
+	for _, arg := range args {
+		i := strings.LastIndex(arg, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid conversion %q: expected key: value", spec)
+		}
+		key := strings.TrimSpace(arg[:i])
+		v, err := strconv.ParseFloat(strings.TrimSpace(arg[i+1:]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid conversion %q: %v", spec, err)
+		}
+		if key == "*" {
+			def = v
+			continue
+		}
+		values[key] = v
+	}
+	return stringEnumMap(values, def), nil
+}
@@ -0,0 +1,90 @@
+package cmp
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Coverage is the translation of the metric names of a set of metrics.
+type Coverage struct {
+	// Matched are the CMP metric names of the metric names with a
+	// translation.
+	Matched map[string]string
+	// Unmatched are the metric names without a translation.
+	Unmatched map[string]bool
+}
+
+// CheckCoverage translates the metrics as Write does, without sending them,
+// and returns the metric names with and without a translation, leaving out
+// those filtered by translation_include and translation_exclude.
+func (a *CMP) CheckCoverage(metrics []telegraf.Metric) (*Coverage, error) {
+	if err := a.setupTranslators(); err != nil {
+		return nil, err
+	}
+
+	c := &Coverage{
+		Matched:   make(map[string]string),
+		Unmatched: make(map[string]bool),
+	}
+	for _, m := range metrics {
+		for k := range a.deriver.derive(m) {
+			k = fieldKey(m, k)
+			metricName := m.Name() + "-" + strings.Replace(k, "_", ".", -1)
+			if a.keyFilter != nil && !a.keyFilter.Match(metricName) {
+				continue
+			}
+			if translation, found := a.lookup(m, metricName, k); found {
+				c.Matched[metricName] = translation.Name
+			} else {
+				c.Unmatched[metricName] = true
+			}
+		}
+	}
+	return c, nil
+}
+
+// Report writes the number of metric names with a translation, then the
+// metric names with their CMP metric names and those without a translation.
+func (c *Coverage) Report(w io.Writer) error {
+	total := len(c.Matched) + len(c.Unmatched)
+	var percent float64
+	if total > 0 {
+		percent = float64(len(c.Matched)) / float64(total) * 100
+	}
+	fmt.Fprintf(w, "%d of %d metric names have a translation (%.1f%%)\n",
+		len(c.Matched), total, percent)
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if len(c.Matched) > 0 {
+		fmt.Fprintf(tw, "\nMatched:\n")
+		for _, name := range sortedKeys(c.Matched) {
+			fmt.Fprintf(tw, "  %s\t%s\n", name, c.Matched[name])
+		}
+	}
+	if len(c.Unmatched) > 0 {
+		fmt.Fprintf(tw, "\nUnmatched:\n")
+		names := make([]string, 0, len(c.Unmatched))
+		for name := range c.Unmatched {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(tw, "  %s\n", name)
+		}
+	}
+	return tw.Flush()
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,49 @@
+package cmp
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// deadLetter is a line of the dead letter file.
+type deadLetter struct {
+	Time     string          `json:"time"`
+	Endpoint string          `json:"endpoint"`
+	Status   string          `json:"status"`
+	Response string          `json:"response"`
+	Payload  json.RawMessage `json:"payload"`
+}
+
+// writeDeadLetter appends the request body rejected by the endpoint and the
+// error to the dead letter file, the status and response of the API if the
+// error is an API error.
+func (a *CMP) writeDeadLetter(endpoint string, body []byte, reason error) error {
+	letter := &deadLetter{
+		Time:     time.Now().UTC().Format(time.RFC3339),
+		Endpoint: endpoint,
+		Response: reason.Error(),
+		Payload:  json.RawMessage(body),
+	}
+	if apiErr, ok := reason.(*apiError); ok {
+		letter.Status = apiErr.status
+		letter.Response = string(apiErr.body)
+	}
+	line, err := json.Marshal(letter)
+	if err != nil {
+		return err
+	}
+
+	a.deadLetterMu.Lock()
+	defer a.deadLetterMu.Unlock()
+
+	f, err := os.OpenFile(a.DeadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
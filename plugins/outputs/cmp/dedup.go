@@ -0,0 +1,43 @@
+package cmp
+
+import (
+	"time"
+)
+
+// sentPoint is the last data point sent for a series.
+type sentPoint struct {
+	value string
+	time  time.Time
+}
+
+// deduplicator suppresses the data points of a series with the value of the
+// last data point sent, unless it is older than max age.
+type deduplicator struct {
+	maxAge time.Duration
+	last   map[series]sentPoint
+}
+
+func newDeduplicator(maxAge time.Duration) *deduplicator {
+	return &deduplicator{
+		maxAge: maxAge,
+		last:   make(map[series]sentPoint),
+	}
+}
+
+// duplicate returns if the data point of the resource repeats the last data
+// point sent for its series, otherwise it becomes the last data point.  A
+// data point with the time of the last one is not a duplicate, since it is
+// the same data point sent again after a failed write.
+func (d *deduplicator) duplicate(resourceID string, p DataPoint) bool {
+	t, err := time.Parse(time.RFC3339Nano, p.Time)
+	if err != nil {
+		return false
+	}
+	key := series{resourceID: resourceID, name: p.Name, specialisation: p.Specialisation}
+	last, ok := d.last[key]
+	if ok && last.value == p.Value && t.After(last.time) && t.Sub(last.time) < d.maxAge {
+		return true
+	}
+	d.last[key] = sentPoint{value: p.Value, time: t}
+	return false
+}
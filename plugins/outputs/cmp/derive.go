@@ -0,0 +1,195 @@
+package cmp
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+)
+
+// fieldDerivations add the fields computed from other fields or the tags of
+// a metric, by measurement, for the inputs not reporting them themselves.
+var fieldDerivations = map[string]func(m telegraf.Metric, fields map[string]interface{}){
+	"memcached":                  memcachedHitRate,
+	"nginx_plus_api_http_caches": nginxCacheHitRatio("hit_responses", "miss_responses"),
+	"nginx_vts_cache":            nginxCacheHitRatio("hit", "miss"),
+	"chrony":                     stratumField,
+	"ntpq":                       stratumField,
+}
+
+// deriveFields returns the fields of the metric with the derived fields.  The
+// fields of the metric are not modified.
+func deriveFields(m telegraf.Metric) map[string]interface{} {
+	fields := m.Fields()
+	derive, ok := fieldDerivations[m.Name()]
+	if !ok {
+		return fields
+	}
+	derived := make(map[string]interface{}, len(fields)+1)
+	for k, v := range fields {
+		derived[k] = v
+	}
+	derive(m, derived)
+	return derived
+}
+
+// memcachedHitRate adds the percentage of the get commands which found the
+// key since the server started, as the redis input does for its keyspace.
+func memcachedHitRate(m telegraf.Metric, fields map[string]interface{}) {
+	hits, ok := toFloat(fields["get_hits"])
+	if !ok {
+		return
+	}
+	misses, ok := toFloat(fields["get_misses"])
+	if !ok || hits+misses == 0 {
+		return
+	}
+	fields["get_hitrate"] = hits / (hits + misses) * 100
+}
+
+// nginxCacheHitRatio adds the percentage of the cache lookups which hit,
+// from the fields of the hits and misses.
+func nginxCacheHitRatio(hitField, missField string) func(m telegraf.Metric, fields map[string]interface{}) {
+	return func(m telegraf.Metric, fields map[string]interface{}) {
+		hits, ok := toFloat(fields[hitField])
+		if !ok {
+			return
+		}
+		misses, ok := toFloat(fields[missField])
+		if !ok || hits+misses == 0 {
+			return
+		}
+		fields["hit_ratio"] = hits / (hits + misses) * 100
+	}
+}
+
+// stratumField adds the stratum, which the chrony and ntpq inputs report as a
+// tag.
+func stratumField(m telegraf.Metric, fields map[string]interface{}) {
+	tag, ok := m.GetTag("stratum")
+	if !ok {
+		return
+	}
+	if stratum, err := strconv.ParseInt(tag, 10, 64); err == nil {
+		fields["stratum"] = stratum
+	}
+}
+
+// DerivedField adds a field computed from other fields of the same metric,
+// the sum of the numerator fields divided by the sum of the denominator
+// fields, multiplied by the scale.
+type DerivedField struct {
+	// Measurement is the glob pattern of the measurements.
+	Measurement string `toml:"measurement"`
+	// Field is the name of the derived field.
+	Field       string   `toml:"field"`
+	Numerator   []string `toml:"numerator"`
+	Denominator []string `toml:"denominator"`
+	// Scale is the factor of the value, 1 if not set, 100 for a percentage.
+	Scale float64 `toml:"scale"`
+	// Name is the CMP metric name of the derived field, if the translations
+	// do not have a translation for it.
+	Name           string `toml:"name"`
+	Specialisation string `toml:"specialisation"`
+	Unit           string `toml:"unit"`
+}
+
+type derivedField struct {
+	measurement filter.Filter
+	field       string
+	numerator   []string
+	denominator []string
+	scale       float64
+	translation Translation
+}
+
+// deriver adds the derived fields of the built-in field derivations and of
+// the configured derived fields to the fields of the metrics.
+type deriver struct {
+	fields []derivedField
+}
+
+func newDeriver(fields []DerivedField) (*deriver, error) {
+	d := &deriver{}
+	for _, f := range fields {
+		if f.Measurement == "" || f.Field == "" || len(f.Numerator) == 0 {
+			return nil, fmt.Errorf("derived fields require a measurement, a field and numerator fields")
+		}
+		measurement, err := filter.Compile([]string{f.Measurement})
+		if err != nil {
+			return nil, fmt.Errorf("invalid derived measurement %q: %v", f.Measurement, err)
+		}
+		field := derivedField{
+			measurement: measurement,
+			field:       f.Field,
+			numerator:   f.Numerator,
+			denominator: f.Denominator,
+			scale:       f.Scale,
+			translation: Translation{Name: f.Name, Specialisation: f.Specialisation, Unit: f.Unit},
+		}
+		if field.scale == 0 {
+			field.scale = 1
+		}
+		d.fields = append(d.fields, field)
+	}
+	return d, nil
+}
+
+// derive returns the fields of the metric with the derived fields, see
+// deriveFields.  A derived field is left out if one of its fields is missing
+// or not numeric, or its denominator is 0.
+func (d *deriver) derive(m telegraf.Metric) map[string]interface{} {
+	fields := deriveFields(m)
+	var derived map[string]interface{}
+	for _, f := range d.fields {
+		if !f.measurement.Match(m.Name()) {
+			continue
+		}
+		value, ok := sumFields(fields, f.numerator)
+		if !ok {
+			continue
+		}
+		if len(f.denominator) > 0 {
+			denominator, ok := sumFields(fields, f.denominator)
+			if !ok || denominator == 0 {
+				continue
+			}
+			value /= denominator
+		}
+		if derived == nil {
+			derived = make(map[string]interface{}, len(fields)+1)
+			for k, v := range fields {
+				derived[k] = v
+			}
+		}
+		derived[f.field] = value * f.scale
+	}
+	if derived == nil {
+		return fields
+	}
+	return derived
+}
+
+// lookup returns the translation of the derived field of the metric, if the
+// derived field has a name.
+func (d *deriver) lookup(m telegraf.Metric, field string) (Translation, bool) {
+	for _, f := range d.fields {
+		if f.field == field && f.translation.Name != "" && f.measurement.Match(m.Name()) {
+			return f.translation, true
+		}
+	}
+	return Translation{}, false
+}
+
+func sumFields(fields map[string]interface{}, keys []string) (float64, bool) {
+	var sum float64
+	for _, k := range keys {
+		v, ok := toFloat(fields[k])
+		if !ok {
+			return 0, false
+		}
+		sum += v
+	}
+	return sum, true
+}
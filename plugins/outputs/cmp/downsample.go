@@ -0,0 +1,152 @@
+package cmp
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// series identifies the data points of a CMP metric of a resource.
+type series struct {
+	resourceID     string
+	name           string
+	specialisation string
+}
+
+// sampleWindow is the aggregate of the data points of a series in a window.
+type sampleWindow struct {
+	start time.Time
+	last  DataPoint
+	sum   float64
+	count int
+}
+
+// point returns the data point of the window, the average of the gauges or
+// the last value of the counters, which CMP receives as running totals.
+func (w *sampleWindow) point() DataPoint {
+	p := w.last
+	if !p.Counter {
+		p.Value = fmt.Sprintf("%v", w.sum/float64(w.count))
+	}
+	return p
+}
+
+// downsampler aggregates the numeric data points of each series over windows
+// aligned on the window duration, and returns the data points of the windows
+// once they are over.  The changes to the windows of a write are pending
+// until the write succeeds, so that a batch sent again after a failed write
+// is not added twice and the windows it flushed are not lost.
+type downsampler struct {
+	window  time.Duration
+	windows map[series]*sampleWindow
+	// pending are the windows changed by the write, nil for those flushed.
+	pending map[series]*sampleWindow
+}
+
+func newDownsampler(window time.Duration) *downsampler {
+	return &downsampler{
+		window:  window,
+		windows: make(map[series]*sampleWindow),
+		pending: make(map[series]*sampleWindow),
+	}
+}
+
+// get returns the window of the series for the write, copied on first use
+// so that the window is unchanged if the write fails.
+func (d *downsampler) get(key series) (*sampleWindow, bool) {
+	if w, ok := d.pending[key]; ok {
+		return w, w != nil
+	}
+	w, ok := d.windows[key]
+	if !ok {
+		return nil, false
+	}
+	c := *w
+	d.pending[key] = &c
+	return &c, true
+}
+
+// commit keeps the changes to the windows once the write succeeded.
+func (d *downsampler) commit() {
+	for key, w := range d.pending {
+		if w == nil {
+			delete(d.windows, key)
+		} else {
+			d.windows[key] = w
+		}
+	}
+	d.discard()
+}
+
+// discard drops the changes to the windows of a failed write.
+func (d *downsampler) discard() {
+	d.pending = make(map[series]*sampleWindow)
+}
+
+// add adds the data point of the resource with its numeric value, the window
+// of the series is returned if the data point starts a new window.
+func (d *downsampler) add(resourceID string, p DataPoint, value float64, t time.Time) (DataPoint, bool) {
+	key := series{resourceID: resourceID, name: p.Name, specialisation: p.Specialisation}
+	start := t.Truncate(d.window)
+
+	var done DataPoint
+	var ok bool
+	w, found := d.get(key)
+	if found && !w.start.Equal(start) {
+		done, ok = w.point(), true
+		found = false
+	}
+	if !found {
+		w = &sampleWindow{start: start}
+		d.pending[key] = w
+	}
+	w.last = p
+	w.sum += value
+	w.count++
+	return done, ok
+}
+
+// flush returns the data points of the windows over at now by resource, or
+// of all windows if all is set, and forgets them.
+func (d *downsampler) flush(now time.Time, all bool) map[string][]DataPoint {
+	keys := make([]series, 0, len(d.windows)+len(d.pending))
+	over := func(key series, w *sampleWindow) {
+		if all || !now.Before(w.start.Add(d.window)) {
+			keys = append(keys, key)
+		}
+	}
+	for key, w := range d.pending {
+		if w != nil {
+			over(key, w)
+		}
+	}
+	for key, w := range d.windows {
+		if _, ok := d.pending[key]; !ok {
+			over(key, w)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].name != keys[j].name {
+			return keys[i].name < keys[j].name
+		}
+		return keys[i].specialisation < keys[j].specialisation
+	})
+
+	points := make(map[string][]DataPoint)
+	for _, key := range keys {
+		w, _ := d.get(key)
+		points[key.resourceID] = append(points[key.resourceID], w.point())
+		d.pending[key] = nil
+	}
+	return points
+}
+
+// sortedResourceIDs returns the resources of the data points in order.
+func sortedResourceIDs(points map[string][]DataPoint) []string {
+	resourceIDs := make([]string, 0, len(points))
+	for resourceID := range points {
+		resourceIDs = append(resourceIDs, resourceID)
+	}
+	sort.Strings(resourceIDs)
+	return resourceIDs
+}
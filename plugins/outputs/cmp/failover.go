@@ -0,0 +1,99 @@
+package cmp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	defaultFailoverThreshold = 3
+	defaultFailbackInterval  = 5 * time.Minute
+)
+
+// endpoints selects the API URL of the requests among the api_urls: the
+// primary, the first URL, until threshold requests in a row failed, then the
+// next URL.  Once failed over, a request every failback interval is sent to
+// the primary, and the requests go back to the primary once it succeeds.
+type endpoints struct {
+	urls             []string
+	threshold        int
+	failbackInterval time.Duration
+	log              telegraf.Logger
+
+	mu       sync.Mutex
+	current  int
+	failures int
+	// probed is the time of the failover or of the last request to the
+	// primary since.
+	probed time.Time
+}
+
+func newEndpoints(urls []string, threshold int, failbackInterval time.Duration, log telegraf.Logger) *endpoints {
+	if threshold < 1 {
+		threshold = defaultFailoverThreshold
+	}
+	if failbackInterval <= 0 {
+		failbackInterval = defaultFailbackInterval
+	}
+	return &endpoints{
+		urls:             urls,
+		threshold:        threshold,
+		failbackInterval: failbackInterval,
+		log:              log,
+	}
+}
+
+// url returns the URL of the next request and its index, to report the
+// result of the request.
+func (e *endpoints) url(now time.Time) (string, int) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.current != 0 && now.Sub(e.probed) >= e.failbackInterval {
+		e.probed = now
+		return e.urls[0], 0
+	}
+	return e.urls[e.current], e.current
+}
+
+// result reports if the request sent to the URL of the index failed for a
+// reason of the endpoint rather than of the request, see endpointFailure.
+func (e *endpoints) result(i int, failed bool, now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !failed {
+		if i == 0 && e.current != 0 {
+			e.log.Infof("Failing back to %s", e.urls[0])
+			e.current = 0
+		}
+		if i == e.current {
+			e.failures = 0
+		}
+		return
+	}
+	if i != e.current || len(e.urls) == 1 {
+		return
+	}
+	e.failures++
+	if e.failures < e.threshold {
+		return
+	}
+	next := (e.current + 1) % len(e.urls)
+	e.log.Warnf("%d requests to %s failed in a row, failing over to %s", e.failures, e.urls[e.current], e.urls[next])
+	e.current = next
+	e.failures = 0
+	e.probed = now
+}
+
+// endpointFailure returns if a request failed with the error because of the
+// endpoint: a network error or a 5xx response.
+func endpointFailure(err error) bool {
+	switch err := err.(type) {
+	case *requestError:
+		return true
+	case *apiError:
+		return err.statusCode >= 500
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package cmp
+
+import "fmt"
+
+// The failure policies of the writes whose requests still fail after their
+// retries.
+const (
+	// failurePolicyBlock fails the write, so that the agent writes the same
+	// metrics again until the API accepts them.
+	failurePolicyBlock = "block"
+	// failurePolicyDrop drops the metrics of the write once it failed
+	// failure_max_attempts times in a row.
+	failurePolicyDrop = "drop"
+	// failurePolicyDeadLetter writes the request bodies not sent to the dead
+	// letter file once the write failed failure_max_attempts times in a row.
+	failurePolicyDeadLetter = "dead_letter"
+)
+
+const defaultFailureMaxAttempts = 3
+
+// checkFailurePolicy returns an error if the failure_policy is not supported
+// or misses its options.
+func (a *CMP) checkFailurePolicy() error {
+	switch a.FailurePolicy {
+	case "", failurePolicyBlock, failurePolicyDrop:
+	case failurePolicyDeadLetter:
+		if a.DeadLetterFile == "" {
+			return fmt.Errorf("dead_letter_file is a required field for the dead_letter failure_policy")
+		}
+	default:
+		return fmt.Errorf("unsupported failure_policy %q, expected block, drop or dead_letter", a.FailurePolicy)
+	}
+	return nil
+}
+
+// writeFailed applies the failure policy to a write which failed with err,
+// unsent are the request bodies not sent by endpoint.  It returns the error
+// of the write, nil if its metrics were dropped or written to the dead
+// letter file.
+func (a *CMP) writeFailed(unsent map[string][][]byte, err error) error {
+	a.failedWrites++
+	if a.FailurePolicy == "" || a.FailurePolicy == failurePolicyBlock || a.failedWrites < a.FailureMaxAttempts {
+		return err
+	}
+	attempts := a.failedWrites
+	a.failedWrites = 0
+
+	if a.FailurePolicy == failurePolicyDeadLetter {
+		var count int
+		for endpoint, bodies := range unsent {
+			for _, body := range bodies {
+				if dlErr := a.writeDeadLetter(endpoint, body, err); dlErr != nil {
+					return fmt.Errorf("%s, and writing the dead letter file failed: %s", err, dlErr)
+				}
+				count++
+			}
+		}
+		a.Log.Errorf("%s, %d payloads were written to %s after %d failed writes", err, count, a.DeadLetterFile, attempts)
+		return nil
+	}
+	a.Log.Errorf("%s, dropped the metrics after %d failed writes", err, attempts)
+	return nil
+}
@@ -0,0 +1,123 @@
+package cmp
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// heartbeatName is the name of the data point sent with every flush with
+// heartbeat, and every heartbeat_interval.
+const heartbeatName = "agent-heartbeat"
+
+// heartbeat tracks the resources of the heartbeat data point.  If started,
+// it sends the data point every interval, whether or not there are metrics
+// to write, so that CMP can alert on its absence.
+type heartbeat struct {
+	interval time.Duration
+	send     func(ctx context.Context, resourceIDs []string, now time.Time)
+
+	mu          sync.Mutex
+	resourceIDs map[string]bool
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func newHeartbeat(interval time.Duration, resourceIDs []string, send func(context.Context, []string, time.Time)) *heartbeat {
+	h := &heartbeat{
+		interval:    interval,
+		send:        send,
+		resourceIDs: make(map[string]bool),
+	}
+	for _, resourceID := range resourceIDs {
+		h.add(resourceID)
+	}
+	return h
+}
+
+// add adds a resource to those of the heartbeat.
+func (h *heartbeat) add(resourceID string) {
+	if resourceID == "" {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.resourceIDs[resourceID] = true
+}
+
+// resources returns the resources of the heartbeat in order.
+func (h *heartbeat) resources() []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	resourceIDs := make([]string, 0, len(h.resourceIDs))
+	for resourceID := range h.resourceIDs {
+		resourceIDs = append(resourceIDs, resourceID)
+	}
+	sort.Strings(resourceIDs)
+	return resourceIDs
+}
+
+func (h *heartbeat) start() {
+	var ctx context.Context
+	ctx, h.cancel = context.WithCancel(context.Background())
+	h.wg.Add(1)
+	go func() {
+		defer h.wg.Done()
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if resourceIDs := h.resources(); len(resourceIDs) > 0 {
+					h.send(ctx, resourceIDs, now)
+				}
+			}
+		}
+	}()
+}
+
+// stop stops the heartbeat if started, canceling the requests in flight.
+func (h *heartbeat) stop() {
+	if h.cancel == nil {
+		return
+	}
+	h.cancel()
+	h.wg.Wait()
+}
+
+// heartbeatPoint returns the heartbeat data point at now.
+func heartbeatPoint(now time.Time) DataPoint {
+	return DataPoint{
+		Name:  heartbeatName,
+		Unit:  "count",
+		Value: "1",
+		Time:  now.UTC().Format("2006-01-02T15:04:05.999999Z"),
+	}
+}
+
+// sendHeartbeat sends the heartbeat data point of each resource.
+func (a *CMP) sendHeartbeat(ctx context.Context, resourceIDs []string, now time.Time) {
+	bodies := make([][][]byte, 0, len(resourceIDs))
+	for _, resourceID := range resourceIDs {
+		payload := &PostMetrics{
+			MonitoringSystem: a.MonitoringSystem,
+			ResourceID:       resourceID,
+		}
+		payload.AddMetric(heartbeatPoint(now))
+		b, err := a.serialize(payload)
+		if err != nil {
+			a.Log.Errorf("Could not send the heartbeat: %s", err)
+			return
+		}
+		bodies = append(bodies, b)
+	}
+
+	a.Log.Debugf("Sending the heartbeat of %d resources", len(resourceIDs))
+	if err := a.sendResources(ctx, "metrics", bodies); err != nil {
+		a.Log.Errorf("Could not send the heartbeat: %s", err)
+	}
+}
@@ -0,0 +1,170 @@
+package cmp
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+)
+
+// JolokiaRule translates the metrics of the jolokia2 inputs, whose
+// measurement is the configured name of the mbean, usually its domain and
+// type as in "tomcat.GlobalRequestProcessor" or "cassandra_ClientRequest",
+// its fields the attributes of the mbean, and its tags the keys of the mbean
+// name.
+type JolokiaRule struct {
+	// Measurement is the glob pattern of the measurements.
+	Measurement string `toml:"measurement"`
+	// Attributes are the glob patterns of the attributes, all attributes if
+	// empty.
+	Attributes []string `toml:"attributes"`
+	// Name is the CMP metric name, in which ${domain} and ${type} are
+	// replaced by the measurement before and after its first . or _,
+	// ${attribute} by the attribute and ${tag} by the value of a tag, all in
+	// kebab case: "GlobalRequestProcessor" becomes
+	// "global-request-processor" and "HeapMemoryUsage.used"
+	// "heap-memory-usage-used".
+	Name string `toml:"name"`
+	Unit string `toml:"unit"`
+	// Tags are the tags whose values, joined with ".", are the
+	// specialisation of the data points.
+	Tags    []string `toml:"tags"`
+	Counter bool     `toml:"counter"`
+	// Conversion is a conversion of the translations file, see
+	// parseConversion.
+	Conversion string `toml:"conversion"`
+	// SourceUnit is the unit of the attributes, see normalizeUnit.
+	SourceUnit string `toml:"source_unit"`
+}
+
+// defaultJolokiaName builds the name from the whole mbean structure.
+const defaultJolokiaName = "${domain}-${type}-${attribute}"
+
+type jolokiaRule struct {
+	measurement filter.Filter
+	attributes  filter.Filter
+	name        string
+	translation Translation
+	tags        []string
+}
+
+// jolokiaTranslator translates the metrics of the jolokia2 inputs by the
+// first rule matching the measurement and attribute.
+type jolokiaTranslator struct {
+	rules []jolokiaRule
+}
+
+func newJolokiaTranslator(rules []JolokiaRule) (*jolokiaTranslator, error) {
+	t := &jolokiaTranslator{}
+	for _, r := range rules {
+		if r.Measurement == "" {
+			return nil, fmt.Errorf("jolokia rules require a measurement")
+		}
+		measurement, err := filter.Compile([]string{r.Measurement})
+		if err != nil {
+			return nil, fmt.Errorf("invalid jolokia measurement %q: %v", r.Measurement, err)
+		}
+		attributes, err := filter.Compile(r.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid jolokia attributes %q: %v", r.Attributes, err)
+		}
+		rule := jolokiaRule{
+			measurement: measurement,
+			attributes:  attributes,
+			name:        r.Name,
+			translation: Translation{Unit: r.Unit, SourceUnit: r.SourceUnit, Counter: r.Counter},
+			tags:        r.Tags,
+		}
+		if rule.name == "" {
+			rule.name = defaultJolokiaName
+		}
+		if r.Conversion != "" {
+			rule.translation.Conversion, err = parseConversion(r.Conversion)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := normalizeUnit(&rule.translation); err != nil {
+			return nil, fmt.Errorf("invalid jolokia measurement %q: %v", r.Measurement, err)
+		}
+		t.rules = append(t.rules, rule)
+	}
+	return t, nil
+}
+
+// lookup returns the translation of the attribute of the metric.
+func (t *jolokiaTranslator) lookup(m telegraf.Metric, attribute string) (Translation, bool) {
+	for _, r := range t.rules {
+		if !r.measurement.Match(m.Name()) {
+			continue
+		}
+		if r.attributes != nil && !r.attributes.Match(attribute) {
+			continue
+		}
+
+		domain, typ := splitMeasurement(m.Name())
+		translation := r.translation
+		translation.Name = os.Expand(r.name, func(key string) string {
+			switch key {
+			case "domain":
+				return kebabCase(domain)
+			case "type":
+				return kebabCase(typ)
+			case "attribute":
+				return kebabCase(attribute)
+			}
+			value, _ := m.GetTag(key)
+			return kebabCase(value)
+		})
+		values := make([]string, 0, len(r.tags))
+		for _, tag := range r.tags {
+			if value, ok := m.GetTag(tag); ok && value != "" {
+				values = append(values, value)
+			}
+		}
+		translation.Specialisation = strings.Join(values, ".")
+		return translation, true
+	}
+	return Translation{}, false
+}
+
+// splitMeasurement returns the measurement before and after its first . or
+// _, or an empty domain if there is neither.
+func splitMeasurement(measurement string) (string, string) {
+	i := strings.IndexAny(measurement, "._")
+	if i < 0 {
+		return "", measurement
+	}
+	return measurement[:i], measurement[i+1:]
+}
+
+// kebabCase returns s in lower case words separated by -, the words of s are
+// separated by a change to upper case or by any other character than a
+// letter or digit.
+func kebabCase(s string) string {
+	runes := []rune(s)
+	out := make([]rune, 0, len(runes)+4)
+	separate := func() {
+		if len(out) > 0 && out[len(out)-1] != '-' {
+			out = append(out, '-')
+		}
+	}
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			separate()
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				separate()
+			}
+		}
+		out = append(out, unicode.ToLower(r))
+	}
+	return strings.TrimSuffix(string(out), "-")
+}
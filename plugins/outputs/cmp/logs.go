@@ -0,0 +1,84 @@
+package cmp
+
+import (
+	"fmt"
+
+	"github.com/influxdata/telegraf"
+)
+
+// PostLogs is the payload sent to the CMP logs API
+type PostLogs struct {
+	MonitoringSystem string     `json:"monitoring_system"`
+	ResourceID       string     `json:"resource_id"`
+	Logs             []LogEntry `json:"logs"`
+}
+
+// LogEntry represents a CMP log line
+type LogEntry struct {
+	Source     string            `json:"source"`
+	Severity   string            `json:"severity,omitempty"`
+	Message    string            `json:"message"`
+	Time       string            `json:"time"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// logEntry returns the log line of a metric of the log_measurements, or false
+// if the metric has no string message field.
+func (a *CMP) logEntry(m telegraf.Metric) (LogEntry, bool) {
+	field, ok := m.GetField(a.LogMessageField)
+	if !ok {
+		return LogEntry{}, false
+	}
+	message, ok := field.(string)
+	if !ok {
+		return LogEntry{}, false
+	}
+	severity, _ := m.GetTag(a.LogSeverityTag)
+	return LogEntry{
+		Source:     m.Name(),
+		Severity:   severity,
+		Message:    message,
+		Time:       m.Time().UTC().Format("2006-01-02T15:04:05.999999Z"),
+		Attributes: m.Tags(),
+	}, true
+}
+
+// serializeLogs returns the request bodies of the payload, its log lines are
+// split in several bodies of at most max_logs_per_request log lines and
+// max_body_bytes bytes as for the data points.
+func (a *CMP) serializeLogs(payload *PostLogs) ([][]byte, error) {
+	chunks := [][]LogEntry{payload.Logs}
+	if n := a.MaxLogsPerRequest; n > 0 && len(payload.Logs) > n {
+		chunks = nil
+		for i := 0; i < len(payload.Logs); i += n {
+			end := i + n
+			if end > len(payload.Logs) {
+				end = len(payload.Logs)
+			}
+			chunks = append(chunks, payload.Logs[i:end])
+		}
+	}
+
+	var bodies [][]byte
+	for len(chunks) > 0 {
+		chunk := chunks[0]
+		chunks = chunks[1:]
+
+		body, err := encodeJSON(&PostLogs{
+			MonitoringSystem: payload.MonitoringSystem,
+			ResourceID:       payload.ResourceID,
+			Logs:             chunk,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to JSON-serialize the log lines: %s", err.Error())
+		}
+
+		if limit := a.MaxBodyBytes.Size; limit > 0 && int64(len(body)) > limit && len(chunk) > 1 {
+			half := len(chunk) / 2
+			chunks = append([][]LogEntry{chunk[:half], chunk[half:]}, chunks...)
+			continue
+		}
+		bodies = append(bodies, body)
+	}
+	return bodies, nil
+}
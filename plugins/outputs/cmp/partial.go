@@ -0,0 +1,115 @@
+package cmp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// rejectedItem is a data point or log line rejected by the API in a 207
+// Multi-Status response, by its index in the request.
+type rejectedItem struct {
+	Index     int    `json:"index"`
+	Error     string `json:"error"`
+	Retryable bool   `json:"retryable"`
+}
+
+// partialResponse is the body of a 207 Multi-Status response.
+type partialResponse struct {
+	Rejected []rejectedItem `json:"rejected"`
+}
+
+// partialError is returned when the API accepted only some of the data
+// points or log lines of a request.
+type partialError struct {
+	status   string
+	body     []byte
+	rejected []rejectedItem
+}
+
+func (e *partialError) Error() string {
+	return fmt.Sprintf("the API rejected %d items: %s", len(e.rejected), e.rejected[0].Error)
+}
+
+// split returns the indexes of the items which may be accepted when sent
+// again, and those which will be rejected again.
+func (e *partialError) split() (retry []int, permanent []int) {
+	for _, item := range e.rejected {
+		if item.Retryable {
+			retry = append(retry, item.Index)
+		} else {
+			permanent = append(permanent, item.Index)
+		}
+	}
+	return retry, permanent
+}
+
+// parsePartialResponse returns the error of a 207 Multi-Status response, or
+// nil if no item was rejected.
+func parsePartialResponse(status string, body []byte) error {
+	var response partialResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("unable to parse the %s response: %s", status, err)
+	}
+	if len(response.Rejected) == 0 {
+		return nil
+	}
+	return &partialError{status: status, body: body, rejected: response.Rejected}
+}
+
+// subsetBody returns the request body to the endpoint with only the items at
+// the indexes, the data points for the metrics endpoint or the log lines for
+// the logs endpoint.
+func subsetBody(endpoint string, body []byte, indexes []int) ([]byte, error) {
+	var payload map[string]json.RawMessage
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, err
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(payload[endpoint], &items); err != nil {
+		return nil, err
+	}
+	subset := make([]json.RawMessage, 0, len(indexes))
+	for _, i := range indexes {
+		if i < 0 || i >= len(items) {
+			return nil, fmt.Errorf("the API rejected the item %d of a request of %d items", i, len(items))
+		}
+		subset = append(subset, items[i])
+	}
+	b, err := json.Marshal(subset)
+	if err != nil {
+		return nil, err
+	}
+	payload[endpoint] = b
+	return json.Marshal(payload)
+}
+
+// handlePartial logs the items rejected by the API and writes those rejected
+// permanently to the dead letter file if set.  It returns the body of the
+// items to retry, or nil if there is none.
+func (a *CMP) handlePartial(endpoint string, body []byte, partial *partialError) ([]byte, error) {
+	a.rejectedItems.Incr(int64(len(partial.rejected)))
+	retry, permanent := partial.split()
+	a.Log.Warnf("The %s API rejected %d items, %d may be accepted when sent again: %s",
+		endpoint, len(partial.rejected), len(retry), partial.rejected[0].Error)
+
+	if len(permanent) > 0 {
+		rejected, err := subsetBody(endpoint, body, permanent)
+		if err != nil {
+			return nil, err
+		}
+		if a.DeadLetterFile != "" {
+			apiErr := &apiError{status: partial.status, body: partial.body}
+			if err := a.writeDeadLetter(endpoint, rejected, apiErr); err != nil {
+				return nil, fmt.Errorf("%s, and writing the dead letter file failed: %s", partial, err)
+			}
+			a.Log.Errorf("%d rejected items were written to %s", len(permanent), a.DeadLetterFile)
+		} else {
+			a.Log.Errorf("Dropped %d rejected items: %s", len(permanent), rejected)
+		}
+	}
+
+	if len(retry) == 0 {
+		return nil, nil
+	}
+	return subsetBody(endpoint, body, retry)
+}
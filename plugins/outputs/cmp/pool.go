@@ -0,0 +1,106 @@
+package cmp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// maxPooledBufferBytes is the size above which the buffers are not put back
+// in the pool, so that a single large flush does not keep its memory.
+const maxPooledBufferBytes = 4 << 20
+
+// maxPooledDataPoints is the capacity above which the data points of a
+// payload are not put back in the pool, for the same reason.
+const maxPooledDataPoints = 64 << 10
+
+// bufferPool holds the buffers the request bodies are encoded in.
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// payloadPool holds the payloads of the flushes, with the capacity of their
+// data points.
+var payloadPool = sync.Pool{
+	New: func() interface{} { return new(PostMetrics) },
+}
+
+// encodeJSON returns the JSON encoding of v as json.Marshal does, encoded in
+// a pooled buffer so that only the returned body is allocated.
+func encodeJSON(v interface{}) ([]byte, error) {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer func() {
+		if buf.Cap() <= maxPooledBufferBytes {
+			bufferPool.Put(buf)
+		}
+	}()
+
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	// The encoder terminates the value with a newline, json.Marshal does not.
+	encoded := bytes.TrimSuffix(buf.Bytes(), []byte("\n"))
+	body := make([]byte, len(encoded))
+	copy(body, encoded)
+	return body, nil
+}
+
+// newPayload returns an empty payload of the resource from the pool.
+func newPayload(monitoringSystem, resourceID string) *PostMetrics {
+	payload := payloadPool.Get().(*PostMetrics)
+	payload.MonitoringSystem = monitoringSystem
+	payload.ResourceID = resourceID
+	return payload
+}
+
+// releasePayload puts the payload back in the pool once its data points were
+// serialized, the payload must not be used afterwards.
+func releasePayload(payload *PostMetrics) {
+	if cap(payload.Metrics) > maxPooledDataPoints {
+		return
+	}
+	// The data points are cleared so that the pool does not keep their
+	// strings alive.
+	for i := range payload.Metrics {
+		payload.Metrics[i] = DataPoint{}
+	}
+	payload.Metrics = payload.Metrics[:0]
+	payload.ResourceID = ""
+	payloadPool.Put(payload)
+}
+
+// formatValue returns the value of a data point as fmt.Sprintf("%v", v),
+// without going through fmt for the types of the metric fields.
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case int:
+		return strconv.Itoa(v)
+	case uint64:
+		return strconv.FormatUint(v, 10)
+	case bool:
+		return strconv.FormatBool(v)
+	case string:
+		return v
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// dataPointLog formats the data point in the debug logs only when they are
+// written, the fields of the data point are not converted to interfaces for
+// every data point created.
+type dataPointLog struct {
+	p *DataPoint
+}
+
+func (l dataPointLog) String() string {
+	return fmt.Sprintf("%s[%s] = %s(%s) %s", l.p.Name, l.p.Specialisation, l.p.Value, l.p.Unit, l.p.Time)
+}
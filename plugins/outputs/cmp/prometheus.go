@@ -0,0 +1,129 @@
+package cmp
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+)
+
+// PrometheusRule translates the metrics of the prometheus input, whose
+// measurement is the name of the prometheus metric, its labels the tags, and
+// the field its type: counter, gauge, value for untyped metrics, or sum and
+// count for summaries and histograms.
+type PrometheusRule struct {
+	// Metric is the glob pattern of the prometheus metric names.
+	Metric string `toml:"metric"`
+	// Name is the CMP metric name, in which ${metric} is replaced by the
+	// prometheus metric name without its _total suffix and with - for _, and
+	// ${name} by the prometheus metric name itself.  The names of sums and
+	// counts have a -sum or -count suffix.
+	Name string `toml:"name"`
+	Unit string `toml:"unit"`
+	// Labels are the labels whose values, joined with ".", are the
+	// specialisation of the data points.
+	Labels []string `toml:"labels"`
+	// Conversion is a conversion of the translations file, see
+	// parseConversion.
+	Conversion string `toml:"conversion"`
+	// SourceUnit is the unit of the prometheus metric, see normalizeUnit.
+	SourceUnit string `toml:"source_unit"`
+}
+
+type prometheusRule struct {
+	metric     filter.Filter
+	name       string
+	unit       string
+	labels     []string
+	conversion Conversion
+}
+
+// prometheusTranslator translates the metrics of the prometheus input by the
+// first rule matching the metric name.
+type prometheusTranslator struct {
+	rules []prometheusRule
+}
+
+func newPrometheusTranslator(rules []PrometheusRule) (*prometheusTranslator, error) {
+	t := &prometheusTranslator{}
+	for _, r := range rules {
+		if r.Metric == "" {
+			return nil, fmt.Errorf("prometheus rules require a metric")
+		}
+		metric, err := filter.Compile([]string{r.Metric})
+		if err != nil {
+			return nil, fmt.Errorf("invalid prometheus metric %q: %v", r.Metric, err)
+		}
+		rule := prometheusRule{
+			metric: metric,
+			name:   r.Name,
+			unit:   r.Unit,
+			labels: r.Labels,
+		}
+		if rule.name == "" {
+			rule.name = "${metric}"
+		}
+		if r.Conversion != "" {
+			rule.conversion, err = parseConversion(r.Conversion)
+			if err != nil {
+				return nil, err
+			}
+		}
+		translation := Translation{Unit: r.Unit, SourceUnit: r.SourceUnit, Conversion: rule.conversion}
+		if err := normalizeUnit(&translation); err != nil {
+			return nil, fmt.Errorf("invalid prometheus metric %q: %v", r.Metric, err)
+		}
+		rule.conversion = translation.Conversion
+		t.rules = append(t.rules, rule)
+	}
+	return t, nil
+}
+
+// lookup returns the translation of the field of the metric.
+func (t *prometheusTranslator) lookup(m telegraf.Metric, field string) (Translation, bool) {
+	var suffix string
+	var counter bool
+	switch field {
+	case "counter":
+		counter = true
+	case "gauge", "value":
+	case "sum", "count":
+		suffix = "-" + field
+		counter = true
+	default:
+		// quantiles and buckets
+		return Translation{}, false
+	}
+
+	for _, r := range t.rules {
+		if !r.metric.Match(m.Name()) {
+			continue
+		}
+
+		name := os.Expand(r.name, func(key string) string {
+			switch key {
+			case "metric":
+				return strings.Replace(strings.TrimSuffix(m.Name(), "_total"), "_", "-", -1)
+			case "name":
+				return m.Name()
+			}
+			return ""
+		})
+		values := make([]string, 0, len(r.labels))
+		for _, label := range r.labels {
+			if value, ok := m.GetTag(label); ok && value != "" {
+				values = append(values, value)
+			}
+		}
+		return Translation{
+			Name:           name + suffix,
+			Specialisation: strings.Join(values, "."),
+			Unit:           r.unit,
+			Counter:        counter,
+			Conversion:     r.conversion,
+		}, true
+	}
+	return Translation{}, false
+}
@@ -0,0 +1,83 @@
+package cmp
+
+import (
+	"math"
+	"time"
+)
+
+// counterSample is the last value of a counter.
+type counterSample struct {
+	value float64
+	time  time.Time
+}
+
+// rateTracker computes the per second rate of counters from their previous
+// value.  The values of a write are pending until the write succeeds, so
+// that the rates of a batch sent again after a failed write are the same.
+type rateTracker struct {
+	samples map[string]counterSample
+	pending map[string]counterSample
+}
+
+func newRateTracker() *rateTracker {
+	return &rateTracker{
+		samples: make(map[string]counterSample),
+		pending: make(map[string]counterSample),
+	}
+}
+
+// rate returns the per second rate of the counter since its previous value.
+// No rate is returned for the first value of a counter, for values not newer
+// than the previous one, or when the counter was reset.
+func (r *rateTracker) rate(key string, value float64, t time.Time) (float64, bool) {
+	prev, ok := r.pending[key]
+	if !ok {
+		prev, ok = r.samples[key]
+	}
+	if ok && !t.After(prev.time) {
+		return 0, false
+	}
+	r.pending[key] = counterSample{value: value, time: t}
+	if !ok {
+		return 0, false
+	}
+
+	delta, ok := counterDelta(prev.value, value)
+	if !ok {
+		return 0, false
+	}
+	return delta / t.Sub(prev.time).Seconds(), true
+}
+
+// commit keeps the pending values once the write succeeded.
+func (r *rateTracker) commit() {
+	for key, sample := range r.pending {
+		r.samples[key] = sample
+	}
+	r.discard()
+}
+
+// discard drops the pending values of a failed write.
+func (r *rateTracker) discard() {
+	r.pending = make(map[string]counterSample)
+}
+
+// counterDelta returns the increase of a counter from prev to cur.  A
+// decrease is taken as a wraparound of a 32 or 64 bit counter if the
+// increase through the wraparound is less than half of the counter range,
+// otherwise the counter was reset and false is returned.
+func counterDelta(prev, cur float64) (float64, bool) {
+	if cur >= prev {
+		return cur - prev, true
+	}
+	for _, max := range []float64{math.MaxUint32, math.MaxUint64} {
+		if prev > max {
+			continue
+		}
+		if delta := max - prev + cur + 1; delta < max/2 {
+			return delta, true
+		}
+		return 0, false
+	}
+	return 0, false
+}
@@ -0,0 +1,111 @@
+package cmp
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/secret"
+)
+
+// maxLoggedBodyBytes is the size of the response bodies logged by
+// log_requests, longer bodies are truncated.
+const maxLoggedBodyBytes = 512
+
+const redacted = "<redacted>"
+
+// logRequest logs the request to the API if log_requests is set: its URL,
+// the status and latency of its response, and the response body if the
+// request failed.  err is the error of a request without a response.
+func (a *CMP) logRequest(req *http.Request, resp *http.Response, elapsed time.Duration, body []byte, err error) {
+	if !a.LogRequests {
+		return
+	}
+	u := redactURL(req.URL)
+	if err != nil {
+		msg := strings.Replace(err.Error(), req.URL.String(), u, -1)
+		a.Log.Debugf("%s %s failed after %s: %s", req.Method, u, elapsed, a.redact(msg))
+		return
+	}
+	if resp.StatusCode == http.StatusOK {
+		a.Log.Debugf("%s %s %s in %s", req.Method, u, resp.Status, elapsed)
+		return
+	}
+	a.Log.Debugf("%s %s %s in %s: %s", req.Method, u, resp.Status, elapsed, truncate(a.redact(string(body))))
+}
+
+// redactURL returns the URL with the password of its user info and the
+// values of its credential query parameters redacted.
+func redactURL(u *url.URL) string {
+	r := *u
+	if r.User != nil {
+		if _, ok := r.User.Password(); ok {
+			r.User = url.UserPassword(r.User.Username(), "redacted")
+		}
+	}
+	if r.RawQuery != "" {
+		query := r.Query()
+		for key := range query {
+			if isCredentialParameter(key) {
+				query.Set(key, "redacted")
+			}
+		}
+		r.RawQuery = query.Encode()
+	}
+	return r.String()
+}
+
+func isCredentialParameter(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range []string{"key", "token", "secret", "password", "signature"} {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// redact returns s with the credentials of the requests replaced, in plain
+// text and as the value of a basic authorization header.
+func (a *CMP) redact(s string) string {
+	var values []string
+	get := func(secrets ...secret.Secret) []string {
+		plain := make([]string, len(secrets))
+		for i, sec := range secrets {
+			if sec.IsEmpty() {
+				continue
+			}
+			if v, err := sec.Get(); err == nil {
+				plain[i] = v
+			}
+		}
+		return plain
+	}
+	if a.auth != nil {
+		basic := get(a.auth.Username, a.auth.Password)
+		if basic[0] != "" || basic[1] != "" {
+			values = append(values, base64.StdEncoding.EncodeToString([]byte(basic[0]+":"+basic[1])))
+		}
+		values = append(values, basic[1])
+		values = append(values, get(a.auth.BearerToken, a.auth.ClientSecret)...)
+	}
+	values = append(values, get(a.HMACSecret)...)
+	for _, v := range values {
+		if v != "" {
+			s = strings.Replace(s, v, redacted, -1)
+		}
+	}
+	return s
+}
+
+// truncate returns the body cut to maxLoggedBodyBytes, once redacted so that
+// no part of a credential is left at the cut.
+func truncate(body string) string {
+	if len(body) <= maxLoggedBodyBytes {
+		return body
+	}
+	return fmt.Sprintf("%s... (%d bytes)", body[:maxLoggedBodyBytes], len(body))
+}
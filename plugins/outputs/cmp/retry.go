@@ -0,0 +1,75 @@
+package cmp
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+const (
+	defaultMaxRetries           = 3
+	defaultRetryInitialInterval = time.Second
+	defaultRetryMaxInterval     = 30 * time.Second
+	defaultCloseTimeout         = 10 * time.Second
+)
+
+// requestError is returned when a request could not be sent or no response
+// was received.
+type requestError struct {
+	err error
+}
+
+func (e *requestError) Error() string {
+	return fmt.Sprintf("API call failed: %s", e.err.Error())
+}
+
+// apiError is returned when the API responds with an error status.
+type apiError struct {
+	statusCode int
+	status     string
+	body       []byte
+}
+
+func (e *apiError) Error() string {
+	return fmt.Sprintf("received a non-200 response: %s %s", e.status, e.body)
+}
+
+// permanent returns if the API rejected the request itself, so that sending
+// it again will fail the same way.
+func (e *apiError) permanent() bool {
+	return e.statusCode >= 400 && e.statusCode < 500 && e.statusCode != http.StatusTooManyRequests
+}
+
+// unauthorized returns if the API rejected the credentials of the request.
+func (e *apiError) unauthorized() bool {
+	return e.statusCode == http.StatusUnauthorized || e.statusCode == http.StatusForbidden
+}
+
+// retryable returns if a request which failed with the error may succeed
+// when sent again.
+func retryable(err error) bool {
+	switch err := err.(type) {
+	case *requestError, *partialError:
+		return true
+	case *apiError:
+		return err.statusCode >= 500 || err.statusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// retryInterval returns the time to wait before the retry following the
+// given attempt, starting at 0.  The interval doubles with each attempt up to
+// retry_max_interval, a random jitter of up to half of the interval is
+// subtracted so that agents failing together do not retry together.
+func (a *CMP) retryInterval(attempt int) time.Duration {
+	interval := a.RetryInitialInterval.Duration
+	for i := 0; i < attempt && interval < a.RetryMaxInterval.Duration; i++ {
+		interval *= 2
+	}
+	if interval > a.RetryMaxInterval.Duration {
+		interval = a.RetryMaxInterval.Duration
+	}
+	return interval - internal.RandomDuration(interval/2)
+}
@@ -0,0 +1,39 @@
+package cmp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	signatureHeader = "X-CMP-Signature"
+	timestampHeader = "X-CMP-Timestamp"
+)
+
+// sign adds the HMAC-SHA256 signature of the request body with the
+// hmac_secret to the request, with the time of the signature so that the
+// gateway can reject replayed requests.  The signature is the hex encoded
+// HMAC of the unix timestamp, a newline and the body, before any content
+// encoding.
+func (a *CMP) sign(req *http.Request, body []byte, now time.Time) error {
+	key, err := a.HMACSecret.Get()
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set(timestampHeader, timestamp)
+	req.Header.Set(signatureHeader, signature([]byte(key), timestamp, body))
+	return nil
+}
+
+func signature(key []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("\n"))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
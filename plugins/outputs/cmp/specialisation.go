@@ -0,0 +1,280 @@
+package cmp
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+)
+
+// SpecialisationRule derives the specialisation of the data points of a
+// metric from its tags.
+type SpecialisationRule struct {
+	// Measurement is the glob pattern of the measurements the rule applies
+	// to, all measurements if empty.
+	Measurement string `toml:"measurement"`
+	// Tags are the tags the metric must have for the rule to apply.
+	Tags []string `toml:"tags"`
+	// Template is the specialisation, in which $tag or ${tag} are replaced by
+	// the value of the tag.  By default the values of the Tags are joined
+	// with the specialisation separator.
+	Template string `toml:"template"`
+	// Pattern is an optional regular expression the specialisation must
+	// match for the rule to apply, the match is replaced by Replacement, see
+	// regexp.Regexp.ReplaceAllString.
+	Pattern     string `toml:"pattern"`
+	Replacement string `toml:"replacement"`
+	// Continue applies the following rules as well, so that each rule adds a
+	// component to the specialisation.
+	Continue bool `toml:"continue"`
+}
+
+// defaultContainerSpecialisationTag is the tag of the docker containers in
+// the default rules, the docker compose service label.
+const defaultContainerSpecialisationTag = "com.docker.compose.service"
+
+// defaultSpecialisationRules are the rules used if none are configured.
+var defaultSpecialisationRules = []SpecialisationRule{
+	{Tags: []string{"cpu"}, Pattern: `^cpu(\d+)$`, Replacement: "$1"},
+	// The influxdb 2.x storage metrics have the path of the shard or wal
+	// directory, and the http api metrics the path of the request.
+	{Measurement: "storage_*", Tags: []string{"bucket"}},
+	{Measurement: "http_api_request*", Tags: []string{"path", "status"}},
+	{Tags: []string{"path"}},
+	// The network and block io metrics of the containers are specialised by
+	// the container and the network or device, ie "web.eth0" or "web.total".
+	{Measurement: "docker_container_net", Tags: []string{defaultContainerSpecialisationTag, "network"}},
+	{Measurement: "docker_container_blkio", Tags: []string{defaultContainerSpecialisationTag, "device"}},
+	{Tags: []string{defaultContainerSpecialisationTag}},
+	// The frontends and backends as a whole are specialised by their type and
+	// proxy, the servers by their type, proxy and server name, ie
+	// "frontend.www", "backend.app" and "server.app.web1".
+	{Measurement: "haproxy", Tags: []string{"type", "proxy"}, Template: "${type}.${proxy}", Pattern: `^(frontend|backend)\.`, Replacement: "$1."},
+	{Measurement: "haproxy", Tags: []string{"type", "proxy", "sv"}, Template: "${type}.${proxy}.${sv}"},
+	{Measurement: "haproxy", Tags: []string{"proxy", "sv"}},
+	{Measurement: "diskio", Tags: []string{"name"}},
+	{Measurement: "net", Tags: []string{"interface"}},
+	{Measurement: "ntpq", Tags: []string{"remote"}},
+	{Measurement: "kubernetes_pod_container", Tags: []string{"namespace", "pod_name", "container_name"}},
+	{Measurement: "kubernetes_pod_volume", Tags: []string{"namespace", "pod_name", "volume_name"}},
+	{Measurement: "kubernetes_pod_network", Tags: []string{"namespace", "pod_name"}},
+	{Measurement: "kube_pod_container_*", Tags: []string{"namespace", "pod", "container"}},
+	{Measurement: "kube_node_*", Tags: []string{"node"}},
+	{Measurement: "postgresql", Tags: []string{"db"}},
+	{Measurement: "pg_stat_user_tables", Tags: []string{"schemaname", "relname"}},
+	{Measurement: "pg_stat_user_indexes", Tags: []string{"schemaname", "relname", "indexrelname"}},
+	{Measurement: "mongodb_*", Tags: []string{"db_name"}},
+	{Measurement: "consul_health_checks", Tags: []string{"check_id"}},
+	{Measurement: "cassandra_ColumnFamily", Tags: []string{"keyspace", "scope"}},
+	{Measurement: "cassandra_*", Tags: []string{"scope"}},
+	{Measurement: "ceph_pgmap_state", Tags: []string{"state"}},
+	{Measurement: "ceph_pool_*", Tags: []string{"name"}},
+	{Measurement: "phpfpm", Tags: []string{"pool"}},
+	{Measurement: "nginx_plus_api_*_server_zones", Tags: []string{"zone"}},
+	{Measurement: "nginx_plus_api_*_upstream_peers", Tags: []string{"upstream", "upstream_address"}},
+	{Measurement: "nginx_plus_api_*_upstreams", Tags: []string{"upstream"}},
+	{Measurement: "nginx_plus_api_http_caches", Tags: []string{"cache"}},
+	{Measurement: "nginx_vts_upstream", Tags: []string{"upstream", "upstream_address"}},
+	{Measurement: "nginx_vts_filter", Tags: []string{"filter_key", "filter_name"}},
+	{Measurement: "nginx_vts_*", Tags: []string{"zone"}},
+	{Measurement: "uwsgi_workers", Tags: []string{"worker_id"}},
+	{Measurement: "uwsgi_apps", Tags: []string{"worker_id", "app_id"}},
+	{Measurement: "sqlserver_waitstats", Tags: []string{"wait_type"}},
+	{Measurement: "elasticsearch_indices_stats_*", Tags: []string{"index_name"}},
+	{Measurement: "minio_bucket_*", Tags: []string{"bucket"}},
+	{Measurement: "minio_node_disk_*", Tags: []string{"server", "disk"}},
+	{Measurement: "minio_node_*", Tags: []string{"server"}},
+	{Measurement: "task_executor_*", Tags: []string{"status"}},
+	{Measurement: "kafka.*", Tags: []string{"topic"}},
+	{Measurement: "kafka.*", Tags: []string{"brokerHost"}},
+}
+
+// containerSpecialisationRules returns the default rules with the tag of the
+// docker containers, each rule with the tag followed by the same rule with
+// the container name for the docker container metrics without the tag if
+// fallback is set.
+func containerSpecialisationRules(tag string, fallback bool) []SpecialisationRule {
+	rules := make([]SpecialisationRule, 0, len(defaultSpecialisationRules)+3)
+	for _, r := range defaultSpecialisationRules {
+		i := indexOf(r.Tags, defaultContainerSpecialisationTag)
+		if i < 0 {
+			rules = append(rules, r)
+			continue
+		}
+		if tag != "" {
+			r.Tags = replaceTag(r.Tags, i, tag)
+		}
+		rules = append(rules, r)
+		if fallback {
+			r.Tags = replaceTag(r.Tags, i, "container_name")
+			if r.Measurement == "" {
+				r.Measurement = "docker_container_*"
+			}
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+func indexOf(values []string, value string) int {
+	for i, v := range values {
+		if v == value {
+			return i
+		}
+	}
+	return -1
+}
+
+// replaceTag returns a copy of the tags with the tag at i replaced.
+func replaceTag(tags []string, i int, tag string) []string {
+	replaced := make([]string, len(tags))
+	copy(replaced, tags)
+	replaced[i] = tag
+	return replaced
+}
+
+// defaultSpecialisationOrder puts the specialisation of the translation
+// before the components of the rules.
+var defaultSpecialisationOrder = []string{"translation", "rules"}
+
+type specialisationRule struct {
+	measurement filter.Filter
+	tags        []string
+	template    string
+	pattern     *regexp.Regexp
+	replacement string
+	next        bool
+}
+
+// specialiser builds the specialisation of the data points of a metric from
+// the components given by the order, joined by the separator.  The
+// components are the specialisation of the translation, the components of
+// the rules applying to the metric, or the value of a tag.
+type specialiser struct {
+	rules     []specialisationRule
+	order     []string
+	separator string
+}
+
+func newSpecialiser(rules []SpecialisationRule, order []string, separator string) (*specialiser, error) {
+	if len(order) == 0 {
+		order = defaultSpecialisationOrder
+	}
+	if separator == "" {
+		separator = "."
+	}
+	for _, component := range order {
+		if component != "translation" && component != "rules" &&
+			(!strings.HasPrefix(component, "tag:") || component == "tag:") {
+			return nil, fmt.Errorf("invalid specialisation component %q, expected translation, rules or tag:<name>", component)
+		}
+	}
+
+	s := &specialiser{order: order, separator: separator}
+	for _, r := range rules {
+		measurement, err := filter.Compile([]string{r.Measurement})
+		if err != nil {
+			return nil, fmt.Errorf("invalid specialisation measurement %q: %v", r.Measurement, err)
+		}
+		if r.Measurement == "" {
+			measurement = nil
+		}
+
+		template := r.Template
+		if template == "" {
+			if len(r.Tags) == 0 {
+				return nil, fmt.Errorf("specialisation rules require tags or a template")
+			}
+			vars := make([]string, 0, len(r.Tags))
+			for _, tag := range r.Tags {
+				vars = append(vars, "${"+tag+"}")
+			}
+			template = strings.Join(vars, separator)
+		}
+
+		rule := specialisationRule{
+			measurement: measurement,
+			tags:        r.Tags,
+			template:    template,
+			replacement: r.Replacement,
+			next:        r.Continue,
+		}
+		if r.Pattern != "" {
+			rule.pattern, err = regexp.Compile(r.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid specialisation pattern %q: %v", r.Pattern, err)
+			}
+		}
+		s.rules = append(s.rules, rule)
+	}
+	return s, nil
+}
+
+// components returns the components of the rules applying to the metric, the
+// first rule applying and the following ones while they continue.
+func (s *specialiser) components(m telegraf.Metric) []string {
+	var components []string
+	for _, r := range s.rules {
+		component, ok := r.apply(m)
+		if !ok {
+			continue
+		}
+		components = append(components, component)
+		if !r.next {
+			break
+		}
+	}
+	return components
+}
+
+// specialisation returns the specialisation of a data point of the metric,
+// with the specialisation of its translation and the components of the
+// rules.  Empty components are left out.
+func (s *specialiser) specialisation(m telegraf.Metric, translation string, rules []string) string {
+	var parts []string
+	for _, component := range s.order {
+		switch component {
+		case "translation":
+			parts = append(parts, translation)
+		case "rules":
+			parts = append(parts, rules...)
+		default:
+			value, _ := m.GetTag(strings.TrimPrefix(component, "tag:"))
+			parts = append(parts, value)
+		}
+	}
+
+	specialisation := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part != "" {
+			specialisation = append(specialisation, part)
+		}
+	}
+	return strings.Join(specialisation, s.separator)
+}
+
+func (r *specialisationRule) apply(m telegraf.Metric) (string, bool) {
+	if r.measurement != nil && !r.measurement.Match(m.Name()) {
+		return "", false
+	}
+	for _, tag := range r.tags {
+		if value, ok := m.GetTag(tag); !ok || value == "" {
+			return "", false
+		}
+	}
+
+	specialisation := os.Expand(r.template, func(tag string) string {
+		value, _ := m.GetTag(tag)
+		return value
+	})
+	if r.pattern != nil {
+		if !r.pattern.MatchString(specialisation) {
+			return "", false
+		}
+		specialisation = r.pattern.ReplaceAllString(specialisation, r.replacement)
+	}
+	return specialisation, specialisation != ""
+}
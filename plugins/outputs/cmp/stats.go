@@ -0,0 +1,45 @@
+package cmp
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// requestStats are the internal metrics of the requests to an endpoint of
+// the API, reported by the internal input as cmp with an endpoint tag.
+type requestStats struct {
+	// requests is the number of requests sent, including the retries.
+	requests selfstat.Stat
+	// requestTime is the average time of the requests until their response
+	// since the last gather of the internal input.
+	requestTime selfstat.Stat
+	// requestBytes is the average size of the request bodies since the last
+	// gather of the internal input.
+	requestBytes selfstat.Stat
+	// sentBytes is the size of the request bodies sent.
+	sentBytes selfstat.Stat
+}
+
+func newRequestStats(stats *selfstat.PluginStats, endpoint string) *requestStats {
+	tags := map[string]string{"endpoint": endpoint}
+	return &requestStats{
+		requests:     stats.Register("requests", tags),
+		requestTime:  stats.RegisterTiming("request_time_ns", tags),
+		requestBytes: stats.RegisterTiming("request_bytes", tags),
+		sentBytes:    stats.Register("sent_bytes", tags),
+	}
+}
+
+// observeRequest adds a request of the endpoint with a body of size bytes
+// which took elapsed until its response.
+func (a *CMP) observeRequest(endpoint string, size int, elapsed time.Duration) {
+	stats, ok := a.requestStats[endpoint]
+	if !ok {
+		return
+	}
+	stats.requests.Incr(1)
+	stats.requestTime.Incr(elapsed.Nanoseconds())
+	stats.requestBytes.Incr(int64(size))
+	stats.sentBytes.Incr(int64(size))
+}
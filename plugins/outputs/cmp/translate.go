@@ -0,0 +1,322 @@
+package cmp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/influxdata/telegraf"
+)
+
+// TranslationPattern translates the metrics whose name matches the regular
+// expression Pattern.  The Name and Specialisation of the translation are
+// templates in which $1 or ${name} are replaced by the submatches of the
+// pattern, see regexp.Regexp.Expand.  Use ${1} when the submatch is followed
+// by a letter, digit or underscore.
+type TranslationPattern struct {
+	Pattern     string
+	Translation Translation
+}
+
+var translatePatterns = []TranslationPattern{
+	{
+		Pattern: `^vault_rollback_attempt_(.+)--mean$`,
+		Translation: Translation{
+			Name: "vault-rollback-attempts-$1",
+			Unit: "count",
+		},
+	},
+	{
+		Pattern: `^vault_route_rollback_(.+)--mean$`,
+		Translation: Translation{
+			Name: "vault-route-rollbacks-$1",
+			Unit: "count",
+		},
+	},
+	// couchdb 1.x reports the totals as current, 2.x as value
+	{
+		Pattern: `^couchdb-httpd\.request\.methods\.([a-z]+)\.(current|value)$`,
+		Translation: Translation{
+			Name:           "couchdb-request-methods",
+			Specialisation: "$1",
+			Counter:        true,
+			Unit:           "requests/s",
+		},
+	},
+	{
+		Pattern: `^couchdb-httpd\.status\.codes\.(\d+)\.(current|value)$`,
+		Translation: Translation{
+			Name:           "couchdb-status-codes",
+			Specialisation: "$1",
+			Counter:        true,
+			Unit:           "responses/s",
+		},
+	},
+}
+
+type translationPattern struct {
+	re          *regexp.Regexp
+	translation Translation
+}
+
+// translator finds the translation of a metric name, either by the exact
+// name or by the first pattern it matches.
+type translator struct {
+	translations map[string]Translation
+	patterns     []translationPattern
+
+	mu sync.Mutex
+	// templates are the parsed names which are templates, by name.
+	templates map[string]*template.Template
+}
+
+// nameData is the data of the names which are templates, ie
+// "{{.Measurement}}-{{.Tag "role"}}-latency".
+type nameData struct {
+	Measurement string
+	Field       string
+	tags        map[string]string
+}
+
+// Tag returns the value of the tag, empty if the metric does not have it.
+func (d *nameData) Tag(key string) string {
+	return d.tags[key]
+}
+
+// isNameTemplate reports whether the name is a text/template.
+func isNameTemplate(name string) bool {
+	return strings.Contains(name, "{{")
+}
+
+// newTranslator returns the translator of the translations and patterns, the
+// conversions of the translations with a source unit are set from their
+// units.
+func newTranslator(translations map[string]Translation, patterns []TranslationPattern) (*translator, error) {
+	t := &translator{
+		translations: make(map[string]Translation, len(translations)),
+		templates:    make(map[string]*template.Template),
+	}
+	for k, translation := range translations {
+		if err := normalizeTranslation(&translation); err != nil {
+			return nil, fmt.Errorf("invalid translation %q: %v", k, err)
+		}
+		if _, err := t.template(translation.Name); err != nil {
+			return nil, fmt.Errorf("invalid translation %q: %v", k, err)
+		}
+		t.translations[k] = translation
+	}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid translation pattern %q: %v", p.Pattern, err)
+		}
+		translation := p.Translation
+		if err := normalizeTranslation(&translation); err != nil {
+			return nil, fmt.Errorf("invalid translation pattern %q: %v", p.Pattern, err)
+		}
+		if _, err := t.template(translation.Name); err != nil {
+			return nil, fmt.Errorf("invalid translation pattern %q: %v", p.Pattern, err)
+		}
+		t.patterns = append(t.patterns, translationPattern{re: re, translation: translation})
+	}
+	return t, nil
+}
+
+// lookup returns the translation of the metric name.
+func (t *translator) lookup(metricName string) (Translation, bool) {
+	if translation, ok := t.translations[metricName]; ok {
+		return translation, true
+	}
+
+	for _, p := range t.patterns {
+		match := p.re.FindStringSubmatchIndex(metricName)
+		if match == nil {
+			continue
+		}
+		translation := p.translation
+		translation.Name = string(p.re.ExpandString(nil, translation.Name, metricName, match))
+		translation.Specialisation = string(p.re.ExpandString(nil, translation.Specialisation, metricName, match))
+		return translation, true
+	}
+	return Translation{}, false
+}
+
+// template returns the parsed name if it is a template, nil otherwise.  The
+// names are parsed once, and checked by executing them without tags so that
+// unknown fields or functions are reported when the translations are loaded.
+func (t *translator) template(name string) (*template.Template, error) {
+	if !isNameTemplate(name) {
+		return nil, nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if tmpl, ok := t.templates[name]; ok {
+		return tmpl, nil
+	}
+	tmpl, err := template.New("name").Option("missingkey=zero").Parse(name)
+	if err != nil {
+		return nil, err
+	}
+	if err := tmpl.Execute(ioutil.Discard, &nameData{}); err != nil {
+		return nil, err
+	}
+	t.templates[name] = tmpl
+	return tmpl, nil
+}
+
+// expandName returns the name of the translation of the field of the metric,
+// the name executed with the measurement, field and tags of the metric if it
+// is a template.
+func (t *translator) expandName(name string, m telegraf.Metric, field string) (string, error) {
+	tmpl, err := t.template(name)
+	if err != nil || tmpl == nil {
+		return name, err
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, &nameData{
+		Measurement: m.Name(),
+		Field:       field,
+		tags:        m.Tags(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// translationFile is the JSON document of a translations file, ie
+//
+//	{
+//	  "translations": {
+//	    "net-bytes.recv": {"name": "network-in", "unit": "B", "counter": true},
+//	    "app-latency.ms": {"name": "app-latency", "unit": "s", "source_unit": "ms"},
+//	    "app-requests": {"name": "{{.Measurement}}-{{.Tag \"role\"}}-requests", "counter": true},
+//	    "app-state": {"name": "app-state", "enum": {"running": 0, "degraded": 1, "*": 2}},
+//	    "app-version": {"name": "app-version", "status": true}
+//	  },
+//	  "patterns": [
+//	    {"pattern": "^vault_(.+)--mean$", "name": "vault-$1", "conversion": "divide_by(1000)"}
+//	  ],
+//	  "aliases": {"net-bytes.received": "net-bytes.recv"}
+//	}
+type translationFile struct {
+	Translations map[string]translationEntry `json:"translations"`
+	Patterns     []patternEntry              `json:"patterns"`
+	// Aliases are the metric names translated as another metric name.
+	Aliases map[string]string `json:"aliases"`
+}
+
+type translationEntry struct {
+	Name           string `json:"name"`
+	Specialisation string `json:"specialisation"`
+	Unit           string `json:"unit"`
+	SourceUnit     string `json:"source_unit"`
+	Counter        bool   `json:"counter"`
+	Conversion     string `json:"conversion"`
+	// Enum and Status are those of Translation.
+	Enum   map[string]float64 `json:"enum"`
+	Status bool               `json:"status"`
+}
+
+type patternEntry struct {
+	Pattern string `json:"pattern"`
+	translationEntry
+}
+
+func (e *translationEntry) translation() (Translation, error) {
+	if e.Name == "" {
+		return Translation{}, fmt.Errorf("missing name")
+	}
+	t := Translation{
+		Name:           e.Name,
+		Specialisation: e.Specialisation,
+		Unit:           e.Unit,
+		SourceUnit:     e.SourceUnit,
+		Counter:        e.Counter,
+		Enum:           e.Enum,
+		Status:         e.Status,
+	}
+	if e.Conversion != "" {
+		conversion, err := parseConversion(e.Conversion)
+		if err != nil {
+			return Translation{}, err
+		}
+		t.Conversion = conversion
+	}
+	return t, nil
+}
+
+// loadTranslations returns the built-in translations, patterns and aliases
+// extended with those of the translations file.  The translations and
+// aliases of the file replace the built-in ones of the same metric, and its
+// patterns are matched before the built-in patterns.
+func loadTranslations(filename string) (map[string]Translation, []TranslationPattern, map[string]string, error) {
+	buf, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var file translationFile
+	if err := json.Unmarshal(buf, &file); err != nil {
+		return nil, nil, nil, fmt.Errorf("error parsing translations file %s: %v", filename, err)
+	}
+
+	translations := make(map[string]Translation, len(translateMap)+len(file.Translations))
+	for k, t := range translateMap {
+		translations[k] = t
+	}
+	for k, e := range file.Translations {
+		t, err := e.translation()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid translation %q in %s: %v", k, filename, err)
+		}
+		translations[k] = t
+	}
+
+	patterns := make([]TranslationPattern, 0, len(file.Patterns)+len(translatePatterns))
+	for _, e := range file.Patterns {
+		t, err := e.translation()
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("invalid translation pattern %q in %s: %v", e.Pattern, filename, err)
+		}
+		patterns = append(patterns, TranslationPattern{Pattern: e.Pattern, Translation: t})
+	}
+	patterns = append(patterns, translatePatterns...)
+
+	aliases := make(map[string]string, len(translateAliases)+len(file.Aliases))
+	for alias, k := range translateAliases {
+		aliases[alias] = k
+	}
+	for alias, k := range file.Aliases {
+		aliases[alias] = k
+	}
+	return translations, patterns, aliases, nil
+}
+
+// withAliases returns the translations with the translation of each alias
+// set to the translation of its metric name, unless the alias has a
+// translation of its own.
+func withAliases(translations map[string]Translation, aliases map[string]string) (map[string]Translation, error) {
+	if len(aliases) == 0 {
+		return translations, nil
+	}
+	resolved := make(map[string]Translation, len(translations)+len(aliases))
+	for k, t := range translations {
+		resolved[k] = t
+	}
+	for alias, k := range aliases {
+		if _, ok := translations[alias]; ok {
+			continue
+		}
+		t, ok := translations[k]
+		if !ok {
+			return nil, fmt.Errorf("alias %q of %q without a translation", alias, k)
+		}
+		resolved[alias] = t
+	}
+	return resolved, nil
+}
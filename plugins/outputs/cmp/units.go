@@ -0,0 +1,95 @@
+package cmp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// unit is a unit of measure, its size is the value of the unit expressed in
+// the smallest unit of its dimension, so that the sizes are integers and the
+// conversions exact.
+type unit struct {
+	dimension string
+	size      float64
+}
+
+// units are the units translations may convert from and to.  The units of
+// bytes are decimal (KB is 1000 B) or binary (KiB is 1024 B), a ratio is a
+// fraction of 1.
+var units = map[string]unit{
+	"B":   {"bytes", 1},
+	"KB":  {"bytes", 1e3},
+	"MB":  {"bytes", 1e6},
+	"GB":  {"bytes", 1e9},
+	"TB":  {"bytes", 1e12},
+	"KiB": {"bytes", 1 << 10},
+	"MiB": {"bytes", 1 << 20},
+	"GiB": {"bytes", 1 << 30},
+	"TiB": {"bytes", 1 << 40},
+
+	"ns":  {"time", 1},
+	"us":  {"time", 1e3},
+	"ms":  {"time", 1e6},
+	"s":   {"time", 1e9},
+	"min": {"time", 60e9},
+	"h":   {"time", 3600e9},
+
+	"nanocores":  {"cpu", 1},
+	"millicores": {"cpu", 1e6},
+	"cores":      {"cpu", 1e9},
+
+	"ppm":     {"fraction", 1},
+	"percent": {"fraction", 1e4},
+	"ratio":   {"fraction", 1e6},
+}
+
+// lookupUnit returns the unit, or the unit per second with a /s suffix.
+func lookupUnit(name string) (unit, bool) {
+	perSecond := strings.HasSuffix(name, "/s")
+	u, ok := units[strings.TrimSuffix(name, "/s")]
+	if ok && perSecond {
+		u.dimension += "/s"
+	}
+	return u, ok
+}
+
+// unitConversion returns the conversion of values of the source unit to the
+// target unit, nil if they are the same.  It fails if either unit is unknown
+// or they measure different dimensions.
+func unitConversion(source, target string) (Conversion, error) {
+	from, ok := lookupUnit(source)
+	if !ok {
+		return nil, fmt.Errorf("unknown unit %q", source)
+	}
+	to, ok := lookupUnit(target)
+	if !ok {
+		return nil, fmt.Errorf("unknown unit %q", target)
+	}
+	if from.dimension != to.dimension {
+		return nil, fmt.Errorf("cannot convert %s of %s to %s of %s", source, from.dimension, target, to.dimension)
+	}
+	switch {
+	case from.size > to.size:
+		return multiplyBy(from.size / to.size), nil
+	case from.size < to.size:
+		return divideBy(to.size / from.size), nil
+	}
+	return nil, nil
+}
+
+// normalizeUnit sets the conversion of a translation with a source unit to the
+// conversion from its source unit to its unit.
+func normalizeUnit(t *Translation) error {
+	if t.SourceUnit == "" {
+		return nil
+	}
+	if t.Conversion != nil {
+		return fmt.Errorf("either a conversion or a source unit may be set")
+	}
+	conversion, err := unitConversion(t.SourceUnit, t.Unit)
+	if err != nil {
+		return err
+	}
+	t.Conversion = conversion
+	return nil
+}
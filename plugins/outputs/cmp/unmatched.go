@@ -0,0 +1,162 @@
+package cmp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+const defaultUnmatchedSummaryInterval = 10 * time.Minute
+
+// unmatchedTracker counts the metric names without a translation, so that
+// they are logged once an interval instead of on every write.
+type unmatchedTracker struct {
+	interval time.Duration
+
+	mu     sync.Mutex
+	counts map[string]int
+	since  time.Time
+}
+
+func newUnmatchedTracker(interval time.Duration, now time.Time) *unmatchedTracker {
+	return &unmatchedTracker{
+		interval: interval,
+		counts:   make(map[string]int),
+		since:    now,
+	}
+}
+
+func (u *unmatchedTracker) add(metricName string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.counts[metricName]++
+}
+
+// report logs the metric names without a translation and how many times
+// they were dropped once the interval has elapsed, and starts a new
+// interval.
+func (u *unmatchedTracker) report(log telegraf.Logger, now time.Time) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if now.Sub(u.since) < u.interval {
+		return
+	}
+	if len(u.counts) > 0 {
+		log.Infof("Dropped %d metric names without a translation since %s: %s",
+			len(u.counts), u.since.Format(time.RFC3339), u.summary())
+	}
+	u.counts = make(map[string]int)
+	u.since = now
+}
+
+// summary returns the metric names with their counts, most dropped first.
+func (u *unmatchedTracker) summary() string {
+	names := make([]string, 0, len(u.counts))
+	for name := range u.counts {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if u.counts[names[i]] != u.counts[names[j]] {
+			return u.counts[names[i]] > u.counts[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s (%d)", name, u.counts[name]))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// unmatchedExample is a line of the unmatched file.
+type unmatchedExample struct {
+	Key         string            `json:"key"`
+	Measurement string            `json:"measurement"`
+	Field       string            `json:"field"`
+	Tags        map[string]string `json:"tags"`
+	Value       interface{}       `json:"value"`
+	Time        string            `json:"time"`
+}
+
+// unmatchedExporter appends the metric names without a translation to a file
+// of JSON lines, once per name, with the tags and value of the first metric
+// seen, for the authors of translations.
+type unmatchedExporter struct {
+	filename string
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// newUnmatchedExporter reads the names already in the file, so that they are
+// not appended again after a restart.
+func newUnmatchedExporter(filename string) (*unmatchedExporter, error) {
+	e := &unmatchedExporter{
+		filename: filename,
+		seen:     make(map[string]bool),
+	}
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return e, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var example unmatchedExample
+		if err := json.Unmarshal(scanner.Bytes(), &example); err != nil {
+			return nil, fmt.Errorf("invalid line in %s: %v", filename, err)
+		}
+		e.seen[example.Key] = true
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// export appends the metric name to the file unless it is already there.
+func (e *unmatchedExporter) export(metricName string, m telegraf.Metric, field string, value interface{}) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.seen[metricName] {
+		return nil
+	}
+
+	line, err := json.Marshal(&unmatchedExample{
+		Key:         metricName,
+		Measurement: m.Name(),
+		Field:       field,
+		Tags:        m.Tags(),
+		Value:       value,
+		Time:        m.Time().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(e.filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	e.seen[metricName] = true
+	return nil
+}
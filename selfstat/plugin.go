@@ -0,0 +1,83 @@
+package selfstat
+
+import (
+	"sync"
+)
+
+// PluginStats registers the internal metrics of a single plugin instance.
+// Plugins get one by declaring a `Stats *selfstat.PluginStats` field, which
+// the agent sets when the plugin is loaded.
+//
+// Stats are reported by the internal input in the internal_<plugin name>
+// measurement, tagged with the tags identifying the plugin instance.  They
+// are removed from the registry when the plugin instance is unloaded.
+type PluginStats struct {
+	measurement string
+	tags        map[string]string
+
+	mu    sync.Mutex
+	stats []Stat
+}
+
+// NewPluginStats returns a PluginStats reporting in the internal_<name>
+// measurement with the given tags.
+func NewPluginStats(name string, tags map[string]string) *PluginStats {
+	return &PluginStats{
+		measurement: name,
+		tags:        tags,
+	}
+}
+
+// Register registers a stat of the plugin, see Register.  The tags are added
+// to the tags of the plugin instance.
+//
+// It is safe to call Register on a nil PluginStats, in which case the stat is
+// not reported.  This allows plugins to be used without an agent, such as in
+// their unit tests.
+func (p *PluginStats) Register(field string, tags map[string]string) Stat {
+	if p == nil {
+		return &stat{field: field, tags: tags}
+	}
+	return p.add(Register(p.measurement, field, p.mergeTags(tags)))
+}
+
+// RegisterTiming registers a timing stat of the plugin, see RegisterTiming and
+// Register.
+func (p *PluginStats) RegisterTiming(field string, tags map[string]string) Stat {
+	if p == nil {
+		return &timingStat{field: field, tags: tags}
+	}
+	return p.add(RegisterTiming(p.measurement, field, p.mergeTags(tags)))
+}
+
+// Unregister removes all stats of the plugin from the registry.
+func (p *PluginStats) Unregister() {
+	if p == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, s := range p.stats {
+		Unregister(s)
+	}
+	p.stats = nil
+}
+
+func (p *PluginStats) add(s Stat) Stat {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats = append(p.stats, s)
+	return s
+}
+
+func (p *PluginStats) mergeTags(tags map[string]string) map[string]string {
+	m := make(map[string]string, len(p.tags)+len(tags))
+	for k, v := range p.tags {
+		m[k] = v
+	}
+	for k, v := range tags {
+		m[k] = v
+	}
+	return m
+}
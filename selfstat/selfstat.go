@@ -87,6 +87,13 @@ func RegisterTiming(measurement, field string, tags map[string]string) Stat {
 	})
 }
 
+// Unregister removes the given stat from the selfstat registry.  Stats
+// returned by several calls to Register are only removed once each of them
+// has been unregistered.
+func Unregister(s Stat) {
+	registry.unregister(s)
+}
+
 // Metrics returns all registered stats as telegraf metrics.
 func Metrics() []telegraf.Metric {
 	registry.mu.Lock()
@@ -122,6 +129,7 @@ func Metrics() []telegraf.Metric {
 
 type rgstry struct {
 	stats map[uint64]map[string]Stat
+	refs  map[Stat]int
 	mu    sync.Mutex
 }
 
@@ -132,17 +140,39 @@ func (r *rgstry) register(s Stat) Stat {
 		// measurement exists
 		if stat, ok := stats[s.FieldName()]; ok {
 			// field already exists, so don't create a new one
+			r.refs[stat]++
 			return stat
 		}
 		r.stats[s.Key()][s.FieldName()] = s
+		r.refs[s]++
 		return s
 	} else {
 		// creating a new unique metric
 		r.stats[s.Key()] = map[string]Stat{s.FieldName(): s}
+		r.refs[s]++
 		return s
 	}
 }
 
+func (r *rgstry) unregister(s Stat) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats, ok := r.stats[s.Key()]
+	if !ok || stats[s.FieldName()] != s {
+		return
+	}
+
+	r.refs[s]--
+	if r.refs[s] > 0 {
+		return
+	}
+	delete(r.refs, s)
+	delete(stats, s.FieldName())
+	if len(stats) == 0 {
+		delete(r.stats, s.Key())
+	}
+}
+
 func key(measurement string, tags map[string]string) uint64 {
 	h := fnv.New64a()
 	h.Write([]byte(measurement))
@@ -165,5 +195,6 @@ func key(measurement string, tags map[string]string) uint64 {
 func init() {
 	registry = &rgstry{
 		stats: make(map[uint64]map[string]Stat),
+		refs:  make(map[Stat]int),
 	}
 }
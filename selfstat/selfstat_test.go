@@ -20,6 +20,7 @@ var (
 func testCleanup() {
 	registry = &rgstry{
 		stats: make(map[uint64]map[string]Stat),
+		refs:  make(map[Stat]int),
 	}
 	testLock.Unlock()
 }
@@ -219,3 +220,53 @@ func TestRegisterMetricsAndVerify(t *testing.T) {
 		},
 	)
 }
+
+func TestUnregister(t *testing.T) {
+	testLock.Lock()
+	defer testCleanup()
+
+	s1 := Register("test", "test_field1", map[string]string{"test": "foo"})
+	s2 := Register("test", "test_field1", map[string]string{"test": "foo"})
+	assert.Len(t, Metrics(), 1)
+
+	// the stat is shared and is kept until it is unregistered by both users
+	Unregister(s1)
+	assert.Len(t, Metrics(), 1)
+	Unregister(s2)
+	assert.Len(t, Metrics(), 0)
+}
+
+func TestPluginStats(t *testing.T) {
+	testLock.Lock()
+	defer testCleanup()
+
+	p := NewPluginStats("cmp", map[string]string{"output": "cmp", "alias": "a"})
+	s := p.Register("requests", map[string]string{"status": "200"})
+	s.Incr(3)
+
+	metrics := Metrics()
+	assert.Len(t, metrics, 1)
+	assert.Equal(t, "internal_cmp", metrics[0].Name())
+	assert.Equal(t, map[string]string{
+		"output": "cmp",
+		"alias":  "a",
+		"status": "200",
+	}, metrics[0].Tags())
+	assert.Equal(t, map[string]interface{}{"requests": int64(3)}, metrics[0].Fields())
+
+	p.Unregister()
+	assert.Len(t, Metrics(), 0)
+}
+
+func TestPluginStatsNil(t *testing.T) {
+	testLock.Lock()
+	defer testCleanup()
+
+	var p *PluginStats
+	s := p.Register("requests", nil)
+	s.Incr(1)
+	assert.Equal(t, int64(1), s.Get())
+	p.RegisterTiming("request_time_ns", nil).Set(10)
+	p.Unregister()
+	assert.Len(t, Metrics(), 0)
+}